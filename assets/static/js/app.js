@@ -3,27 +3,139 @@ const app = Vue.createApp({
     data() {
         return {
             bookmarks: [],
-            newBookmark: {}
+            newBookmark: {},
+            searchQuery: '',
+            searchTimer: null,
+            selectedTag: null,
+            credentials: {},
+            loginError: null,
+            accessToken: localStorage.getItem('accessToken'),
+            refreshToken: localStorage.getItem('refreshToken')
         }
     },
     created: function () {
-        this.loadBookmarks();
+        if (this.authenticated) {
+            this.loadBookmarks();
+        }
     },
     methods: {
+        login() {
+            let self = this;
+            $.ajax({
+                type: "POST",
+                url: '/api/auth/login',
+                data: JSON.stringify(this.credentials),
+                contentType: "application/json",
+                success: function (tokens) {
+                    self.credentials = {};
+                    self.loginError = null;
+                    self.storeTokens(tokens);
+                    self.loadBookmarks();
+                },
+                error: function () {
+                    self.loginError = 'Invalid email or password';
+                }
+            });
+        },
+
+        logout() {
+            if (this.refreshToken) {
+                $.ajax({
+                    type: "POST",
+                    url: '/api/auth/logout',
+                    data: JSON.stringify({refresh_token: this.refreshToken}),
+                    contentType: "application/json"
+                });
+            }
+            this.storeTokens({});
+            this.bookmarks = [];
+        },
+
+        storeTokens(tokens) {
+            this.accessToken = tokens.access_token || null;
+            this.refreshToken = tokens.refresh_token || null;
+            if (this.accessToken) {
+                localStorage.setItem('accessToken', this.accessToken);
+                localStorage.setItem('refreshToken', this.refreshToken);
+            } else {
+                localStorage.removeItem('accessToken');
+                localStorage.removeItem('refreshToken');
+            }
+        },
+
+        // request performs an authenticated API call, refreshing the access token once when it has expired.
+        request(options, retried) {
+            let self = this;
+            let success = options.success;
+            return $.ajax($.extend({}, options, {
+                headers: {Authorization: 'Bearer ' + this.accessToken},
+                success: success,
+                error: function (xhr) {
+                    if (xhr.status !== 401) {
+                        return;
+                    }
+                    if (retried || !self.refreshToken) {
+                        self.storeTokens({});
+                        return;
+                    }
+                    $.ajax({
+                        type: "POST",
+                        url: '/api/auth/refresh',
+                        data: JSON.stringify({refresh_token: self.refreshToken}),
+                        contentType: "application/json",
+                        success: function (tokens) {
+                            self.storeTokens(tokens);
+                            self.request(options, true);
+                        },
+                        error: function () {
+                            self.storeTokens({});
+                        }
+                    });
+                }
+            }));
+        },
+
         loadBookmarks() {
             let self = this;
-            $.getJSON("/api/bookmarks", function (data) {
-                self.bookmarks = data
+            let params = {};
+            if (this.searchQuery.trim() !== '') {
+                params.q = this.searchQuery.trim();
+            }
+            if (this.selectedTag) {
+                params.tag = this.selectedTag;
+            }
+            this.request({
+                url: "/api/bookmarks",
+                data: params,
+                dataType: "json",
+                success: function (data) {
+                    self.bookmarks = data.data
+                }
             });
         },
 
+        searchBookmarks() {
+            clearTimeout(this.searchTimer);
+            this.searchTimer = setTimeout(this.loadBookmarks, 300);
+        },
+
+        filterByTag(tag) {
+            this.selectedTag = tag;
+            this.loadBookmarks();
+        },
+
         saveBookmark() {
             let self = this;
+            let bookmark = {
+                title: this.newBookmark.title,
+                url: this.newBookmark.url,
+                tags: (this.newBookmark.tags || '').split(',')
+            };
 
-            $.ajax({
+            this.request({
                 type: "POST",
                 url: '/api/bookmarks',
-                data: JSON.stringify(this.newBookmark),
+                data: JSON.stringify(bookmark),
                 contentType: "application/json",
                 success: function () {
                     self.newBookmark = {};
@@ -34,7 +146,7 @@ const app = Vue.createApp({
 
         deleteBookmark(id) {
             let self = this;
-            $.ajax({
+            this.request({
                 type: "DELETE",
                 url: 'api/bookmarks/' + id,
                 success: function () {
@@ -43,6 +155,10 @@ const app = Vue.createApp({
             });
         }
     },
-    computed: {}
+    computed: {
+        authenticated() {
+            return !!this.accessToken;
+        }
+    }
 });
 app.mount("#app");
@@ -1,21 +1,76 @@
 package main
 
 import (
+	"errors"
 	"flag"
+	"fmt"
 	"log"
+	"os"
 
 	bookmarks "github.com/sivaprasadreddy/bookmarks-go/internal"
 	"github.com/sivaprasadreddy/bookmarks-go/internal/config"
 )
 
+const usage = `Usage: bookmarks [-conf file] [command]
+
+Commands:
+  serve                 run the server, the default command
+  migrate up [N]        apply all or the next N migrations
+  migrate down [N]      roll back the last or the last N migrations
+  migrate goto V        migrate up or down to version V
+  migrate force V       set the version to V and clear the dirty flag, without migrating
+  migrate version       print the current version
+  migrate status        list the migrations and whether they are applied
+  set-password EMAIL    set the password of a user to the line read from stdin
+
+Flags:
+`
+
 func main() {
 	var confFile string
 	flag.StringVar(&confFile, "conf", ".env", "config path, eg: -conf app.dev")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 	cfg, err := config.GetConfig(confFile)
 	if err != nil {
 		log.Fatal(err)
 	}
-	app := bookmarks.NewApp(cfg)
-	app.Run()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		args = []string{"serve"}
+	}
+	switch args[0] {
+	case "serve":
+		if len(args) > 1 {
+			usageError("serve takes no arguments")
+		}
+		app := bookmarks.NewApp(cfg)
+		app.Run()
+	case "migrate":
+		if err = runMigrate(cfg, args[1:], os.Stdout); err != nil {
+			if errors.Is(err, errUsage) {
+				usageError(err.Error())
+			}
+			log.Fatal(err)
+		}
+	case "set-password":
+		if err = runSetPassword(cfg, args[1:], os.Stdin, os.Stdout); err != nil {
+			if errors.Is(err, errUsage) {
+				usageError(err.Error())
+			}
+			log.Fatal(err)
+		}
+	default:
+		usageError(fmt.Sprintf("unknown command %q", args[0]))
+	}
+}
+
+func usageError(msg string) {
+	fmt.Fprintf(flag.CommandLine.Output(), "%s\n\n", msg)
+	flag.Usage()
+	os.Exit(2)
 }
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strconv"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/config"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/db"
+)
+
+var errUsage = errors.New("invalid command")
+
+// runMigrate runs a migrate subcommand against the database of the configured storage
+// backend, regardless of DB_RUN_MIGRATIONS, writing its outcome to out.
+func runMigrate(cfg config.AppConfig, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: missing subcommand", errUsage)
+	}
+	command, args := args[0], args[1:]
+	switch command {
+	case "up":
+		if len(args) == 0 {
+			return withMigrate(cfg, out, func(m *migrate.Migrate) error { return m.Up() })
+		}
+		n, err := parseCount(args)
+		if err != nil {
+			return err
+		}
+		return withMigrate(cfg, out, func(m *migrate.Migrate) error { return m.Steps(n) })
+	case "down":
+		n := 1
+		if len(args) > 0 {
+			var err error
+			if n, err = parseCount(args); err != nil {
+				return err
+			}
+		}
+		return withMigrate(cfg, out, func(m *migrate.Migrate) error { return m.Steps(-n) })
+	case "goto":
+		v, err := parseNumber(args)
+		if err != nil {
+			return err
+		}
+		if v < 0 {
+			return fmt.Errorf("%w: version must not be negative", errUsage)
+		}
+		return withMigrate(cfg, out, func(m *migrate.Migrate) error { return m.Migrate(uint(v)) })
+	case "force":
+		v, err := parseNumber(args)
+		if err != nil {
+			return err
+		}
+		// -1 forces the database back to having no migrations applied
+		if v < -1 {
+			return fmt.Errorf("%w: version must be -1 or more", errUsage)
+		}
+		return withMigrate(cfg, out, func(m *migrate.Migrate) error { return m.Force(v) })
+	case "version", "status":
+		if len(args) > 0 {
+			return fmt.Errorf("%w: %s takes no arguments", errUsage, command)
+		}
+		return withMigrate(cfg, nil, func(m *migrate.Migrate) error {
+			if command == "status" {
+				return printStatus(cfg, m, out)
+			}
+			return printVersion(m, out)
+		})
+	default:
+		return fmt.Errorf("%w: unknown subcommand %q", errUsage, command)
+	}
+}
+
+// withMigrate runs fn and, unless out is nil, prints the version it leaves the database
+// at, which also tells how far a failed migration got.
+func withMigrate(cfg config.AppConfig, out io.Writer, fn func(m *migrate.Migrate) error) error {
+	m, err := db.NewMigrate(cfg)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	err = fn(m)
+	switch {
+	case errors.Is(err, migrate.ErrNoChange):
+		err = nil
+		if out != nil {
+			fmt.Fprintln(out, "no change")
+		}
+	case errors.Is(err, fs.ErrNotExist):
+		err = fmt.Errorf("no such migration: %w", err)
+	}
+	if out != nil {
+		if versionErr := printVersion(m, out); err == nil {
+			err = versionErr
+		}
+	}
+	return err
+}
+
+func printVersion(m *migrate.Migrate, out io.Writer) error {
+	version, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		fmt.Fprintln(out, "version: none")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if dirty {
+		fmt.Fprintf(out, "version: %d (dirty)\n", version)
+	} else {
+		fmt.Fprintf(out, "version: %d\n", version)
+	}
+	return nil
+}
+
+func printStatus(cfg config.AppConfig, m *migrate.Migrate, out io.Writer) error {
+	migrations, err := db.ListMigrations(cfg)
+	if err != nil {
+		return err
+	}
+	current, dirty, err := m.Version()
+	applied := err == nil
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return err
+	}
+	for _, mg := range migrations {
+		status := "pending"
+		switch {
+		case applied && mg.Version == current && dirty:
+			status = "dirty"
+		case applied && mg.Version <= current:
+			status = "applied"
+		}
+		fmt.Fprintf(out, "%-16d %-8s %s\n", mg.Version, status, mg.Name)
+	}
+	return printVersion(m, out)
+}
+
+// parseNumber parses the only argument as an integer.
+func parseNumber(args []string) (int, error) {
+	if len(args) != 1 {
+		return 0, fmt.Errorf("%w: expected one number", errUsage)
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q is not a number", errUsage, args[0])
+	}
+	return n, nil
+}
+
+// parseCount parses the only argument as a positive number of migrations.
+func parseCount(args []string) (int, error) {
+	n, err := parseNumber(args)
+	if err == nil && n <= 0 {
+		err = fmt.Errorf("%w: the number of migrations must be positive", errUsage)
+	}
+	return n, err
+}
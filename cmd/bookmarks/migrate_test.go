@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"path"
+	"testing"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunMigrate(t *testing.T) {
+	cfg := config.AppConfig{StorageBackend: "sqlite", SQLitePath: path.Join(t.TempDir(), "bookmarks.db")}
+	run := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		err := runMigrate(cfg, args, &out)
+		return out.String(), err
+	}
+
+	out, err := run("status")
+	assert.Nil(t, err)
+	assert.Regexp(t, `1 +pending +init_schema\n2 +pending +sample_data\n3 +pending +disable_demo_password\nversion: none\n`, out)
+
+	out, err = run("up", "1")
+	assert.Nil(t, err)
+	assert.Equal(t, "version: 1\n", out)
+
+	out, err = run("up")
+	assert.Nil(t, err)
+	assert.Equal(t, "version: 3\n", out)
+
+	out, err = run("up")
+	assert.Nil(t, err)
+	assert.Equal(t, "no change\nversion: 3\n", out)
+
+	out, err = run("down")
+	assert.Nil(t, err)
+	assert.Equal(t, "version: 2\n", out)
+
+	out, err = run("goto", "1")
+	assert.Nil(t, err)
+	assert.Equal(t, "version: 1\n", out)
+
+	out, err = run("goto", "2")
+	assert.Nil(t, err)
+	assert.Equal(t, "version: 2\n", out)
+
+	out, err = run("force", "1")
+	assert.Nil(t, err)
+	assert.Equal(t, "version: 1\n", out)
+
+	out, err = run("status")
+	assert.Nil(t, err)
+	assert.Regexp(t, `1 +applied +init_schema\n2 +pending +sample_data\n3 +pending +disable_demo_password\nversion: 1\n`, out)
+
+	out, err = run("version")
+	assert.Nil(t, err)
+	assert.Equal(t, "version: 1\n", out)
+}
+
+func TestRunMigrateUsage(t *testing.T) {
+	for _, args := range [][]string{{}, {"sideways"}, {"down", "0"}, {"up", "x"}, {"goto"}, {"force", "-2"},
+		{"version", "1"}} {
+		err := runMigrate(config.AppConfig{}, args, &bytes.Buffer{})
+		assert.ErrorIs(t, err, errUsage, "%v", args)
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	bookmarks "github.com/sivaprasadreddy/bookmarks-go/internal"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/config"
+)
+
+// runSetPassword sets the password of the user whose email is the only argument to the
+// first line read from in, so that the password does not end up in the shell history.
+func runSetPassword(cfg config.AppConfig, args []string, in io.Reader, out io.Writer) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%w: set-password takes one email", errUsage)
+	}
+	password, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return err
+	}
+	password = strings.TrimRight(password, "\r\n")
+	// the same limits as for registering, bcrypt ignores everything after 72 bytes
+	if len(password) < 8 || len(password) > 72 {
+		return fmt.Errorf("the password must have 8 to 72 characters")
+	}
+	if err = bookmarks.SetPassword(cfg, args[0], password); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "password of %s updated\n", args[0])
+	return nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/config"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/db"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/domain"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestRunSetPassword(t *testing.T) {
+	cfg := config.AppConfig{StorageBackend: "sqlite", SQLitePath: path.Join(t.TempDir(), "bookmarks.db"),
+		DbRunMigrations: true}
+	sqliteDb := db.GetSQLiteDb(cfg, logging.NewLogger(cfg))
+	defer sqliteDb.Close()
+	users := domain.NewSQLiteUserRepo(sqliteDb, logging.NewLogger(cfg))
+	_, err := users.Create(context.Background(),
+		domain.User{Name: "Jane", Email: "jane@example.com", PasswordHash: "!", CreatedDate: time.Now()})
+	assert.Nil(t, err)
+
+	var out bytes.Buffer
+	err = runSetPassword(cfg, []string{"Jane@example.com"}, strings.NewReader("new-secret\n"), &out)
+	assert.Nil(t, err)
+	assert.Equal(t, "password of Jane@example.com updated\n", out.String())
+	user, err := users.FindByEmail(context.Background(), "jane@example.com")
+	assert.Nil(t, err)
+	assert.Nil(t, bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte("new-secret")))
+
+	err = runSetPassword(cfg, []string{"john@example.com"}, strings.NewReader("new-secret\n"), &out)
+	assert.ErrorIs(t, err, domain.ErrUserNotFound)
+	err = runSetPassword(cfg, []string{"jane@example.com"}, strings.NewReader("short\n"), &out)
+	assert.ErrorContains(t, err, "8 to 72 characters")
+	err = runSetPassword(cfg, nil, strings.NewReader("new-secret\n"), &out)
+	assert.ErrorIs(t, err, errUsage)
+}
@@ -1,61 +1,170 @@
 package api
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/sivaprasadreddy/bookmarks-go/internal/domain"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/jobs"
 	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 )
 
+// BookmarkController records every change of a bookmark in the audit log, in the
+// transaction making the change.
 type BookmarkController struct {
-	repo   domain.BookmarkRepository
-	logger *logging.Logger
+	repo        domain.BookmarkRepository
+	collections domain.CollectionRepository
+	audit       domain.AuditRepository
+	queue       *jobs.Queue
+	logger      *logging.Logger
 }
 
-func NewBookmarkController(repository domain.BookmarkRepository, logger *logging.Logger) *BookmarkController {
-	return &BookmarkController{repo: repository, logger: logger}
+func NewBookmarkController(repository domain.BookmarkRepository, collections domain.CollectionRepository,
+	audit domain.AuditRepository, queue *jobs.Queue, logger *logging.Logger) *BookmarkController {
+	return &BookmarkController{repo: repository, collections: collections, audit: audit, queue: queue,
+		logger: logger}
+}
+
+type BookmarksPage struct {
+	Data       []domain.Bookmark `json:"data"`
+	Total      int64             `json:"total"`
+	Page       int               `json:"page,omitempty"`
+	Size       int               `json:"size"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+	Next       string            `json:"next,omitempty"`
+	Prev       string            `json:"prev,omitempty"`
 }
 
 func (b BookmarkController) FindAll(c *gin.Context) {
-	b.logger.Info("Fetching all bookmarks")
+	b.logger.Info("Fetching bookmarks page")
 	ctx := c.Request.Context()
-	bookmarks, err := b.repo.FindAll(ctx)
+	query, err := parseBookmarkQuery(c)
+	if err != nil {
+		b.logger.Errorf("Error while parsing bookmarks query: %v", err)
+		abortWithProblem(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	page, err := b.repo.FindPage(ctx, query)
 	if err != nil {
-		b.logger.Errorf("Error while fetching bookmarks")
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-			"error": "Unable to fetch bookmarks",
-		})
+		b.logger.Errorf("Error while fetching bookmarks: %v", err)
+		abortWithProblem(c, http.StatusInternalServerError, "Unable to fetch bookmarks")
 		return
 	}
-	if bookmarks == nil {
-		bookmarks = []domain.Bookmark{}
+	c.JSON(http.StatusOK, newBookmarksPage(c, query, page))
+}
+
+func parseBookmarkQuery(c *gin.Context) (domain.BookmarkQuery, error) {
+	query := domain.BookmarkQuery{OwnerID: currentUserID(c), Page: 1, Size: domain.DefaultPageSize}
+	var err error
+	if v := c.Query("page"); v != "" {
+		if query.Page, err = strconv.Atoi(v); err != nil || query.Page < 1 {
+			return query, errors.New("page must be a positive number")
+		}
+	}
+	if v := c.Query("size"); v != "" {
+		query.Size, err = strconv.Atoi(v)
+		if err != nil || query.Size < 1 || query.Size > domain.MaxPageSize {
+			return query, fmt.Errorf("size must be between 1 and %d", domain.MaxPageSize)
+		}
 	}
-	c.JSON(http.StatusOK, bookmarks)
+	if v := c.Query("after"); v != "" {
+		if query.After, err = domain.DecodeCursor(v); err != nil {
+			return query, err
+		}
+	}
+	query.Query = strings.TrimSpace(c.Query("q"))
+	query.Tags = domain.NormalizeTags(c.QueryArray("tag"))
+	switch c.DefaultQuery("tag_mode", "all") {
+	case "all":
+	case "any":
+		query.AnyTag = true
+	default:
+		return query, errors.New("tag_mode must be one of all, any")
+	}
+	switch query.LinkStatus = c.Query("status"); query.LinkStatus {
+	case "", domain.LinkBroken, domain.LinkOK, domain.LinkUnchecked:
+	default:
+		return query, errors.New("status must be one of broken, ok, unchecked")
+	}
+	sort := c.Query("sort")
+	if sort == "" && query.Query != "" {
+		sort = "-relevance"
+	}
+	if query.Sort, err = domain.ParseSort(sort); err != nil {
+		return query, err
+	}
+	if query.Query == "" && domain.HasSortField(query.Sort, "relevance") {
+		return query, errors.New("sorting by relevance requires a search query")
+	}
+	return query, nil
+}
+
+func newBookmarksPage(c *gin.Context, query domain.BookmarkQuery, page domain.BookmarkPage) BookmarksPage {
+	resp := BookmarksPage{
+		Data:  page.Bookmarks,
+		Total: page.Total,
+		Size:  query.Size,
+	}
+	if resp.Data == nil {
+		resp.Data = []domain.Bookmark{}
+	}
+	link := func(set map[string]string) string {
+		u := *c.Request.URL
+		params := u.Query()
+		params.Del("page")
+		params.Del("after")
+		for k, v := range set {
+			params.Set(k, v)
+		}
+		u.RawQuery = params.Encode()
+		return u.RequestURI()
+	}
+	if page.HasMore {
+		resp.NextCursor = domain.EncodeCursor(page.Bookmarks[len(page.Bookmarks)-1].ID)
+	}
+	if query.After > 0 {
+		// cursor based scrolling only moves forward
+		if page.HasMore {
+			resp.Next = link(map[string]string{"after": resp.NextCursor})
+		}
+		return resp
+	}
+	resp.Page = query.Page
+	if page.HasMore {
+		resp.Next = link(map[string]string{"page": strconv.Itoa(query.Page + 1)})
+	}
+	if query.Page > 1 {
+		resp.Prev = link(map[string]string{"page": strconv.Itoa(query.Page - 1)})
+	}
+	return resp
 }
 
 func (b BookmarkController) FindByID(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		b.logger.Errorf("Error while parsing bookmarkID: %v", err)
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid bookmark id",
-		})
+		abortWithProblem(c, http.StatusBadRequest, "Invalid bookmark id")
 		return
 	}
 	b.logger.Infof("Fetching bookmark by id %d", id)
 	ctx := c.Request.Context()
-	bookmark, err := b.repo.FindByID(ctx, id)
+	bookmark, err := b.repo.FindByID(ctx, currentUserID(c), id)
 	if err != nil {
-		b.logger.Errorf("Error while fetching bookmark by id: %v", err)
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-			"error": "Unable to fetch bookmark by id",
-		})
+		abortWithError(c, err)
 		return
 	}
+	c.Header("ETag", etag(bookmark.Version))
 	c.JSON(http.StatusOK, bookmark)
 }
 
@@ -63,62 +172,182 @@ func (b BookmarkController) Create(c *gin.Context) {
 	b.logger.Info("create bookmark")
 	ctx := c.Request.Context()
 	var cb domain.CreateBookmarkModel
-	if err := c.ShouldBindJSON(&cb); err != nil {
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error": "Unable to parse request body. Error: " + err.Error(),
-		})
+	if !bindJSON(c, &cb) {
 		return
 	}
 	bookmark := domain.Bookmark{
-		Title:       cb.Title,
+		OwnerID:     currentUserID(c),
+		Title:       strings.TrimSpace(cb.Title),
 		URL:         cb.URL,
+		Tags:        domain.NormalizeTags(cb.Tags),
 		CreatedDate: time.Now(),
 	}
-	bookmark, err := b.repo.Create(ctx, bookmark)
+	keepTitle := bookmark.Title != ""
+	if !keepTitle {
+		// replaced by the title of the page once its metadata is fetched
+		bookmark.Title = bookmark.URL
+	}
+	bookmark, err := b.createBookmark(ctx, auditActor(c), bookmark)
 	if err != nil {
-		b.logger.Errorf("Error while create bookmark %v", err)
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-			"error": "Unable to create bookmark",
-		})
+		abortWithError(c, err)
 		return
 	}
+	b.enqueue(c, bookmark, jobs.KindFetchMetadata,
+		jobs.FetchMetadataPayload{BookmarkID: bookmark.ID, KeepTitle: keepTitle})
+	b.enqueue(c, bookmark, jobs.KindArchivePage, jobs.ArchivePagePayload{BookmarkID: bookmark.ID})
+	c.Header("ETag", etag(bookmark.Version))
 	c.JSON(http.StatusCreated, bookmark)
 }
 
+func bookmarkPath(id int) string {
+	return fmt.Sprintf("/api/bookmarks/%d", id)
+}
+
+// createBookmark creates the bookmark, recording it in the audit log.
+func (b BookmarkController) createBookmark(ctx context.Context, actor domain.AuditEntry,
+	bookmark domain.Bookmark) (domain.Bookmark, error) {
+	err := b.repo.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		if bookmark, err = b.repo.Create(ctx, bookmark); err != nil {
+			return err
+		}
+		return b.record(ctx, actor, domain.AuditCreate, nil, &bookmark)
+	})
+	return bookmark, err
+}
+
+// enqueue schedules background work on a bookmark, failing to do so does not fail the request.
+func (b BookmarkController) enqueue(c *gin.Context, bookmark domain.Bookmark, kind string, payload any) {
+	if _, err := b.queue.Enqueue(c.Request.Context(), bookmark.OwnerID, kind, payload); err != nil {
+		b.logger.Errorf("Error while enqueueing %s job for bookmark id=%d: %v", kind, bookmark.ID, err)
+	}
+}
+
+// enqueueForChangedURL schedules fetching the metadata and archiving the page of a bookmark
+// whose url was changed. Called in the transaction of the update, the jobs are only added
+// along with the new url.
+func (b BookmarkController) enqueueForChangedURL(ctx context.Context, current, updated domain.Bookmark) error {
+	if current.URL == updated.URL {
+		return nil
+	}
+	// the title was given along with the new url
+	_, err := b.queue.Enqueue(ctx, updated.OwnerID, jobs.KindFetchMetadata,
+		jobs.FetchMetadataPayload{BookmarkID: updated.ID, KeepTitle: true})
+	if err != nil {
+		return err
+	}
+	_, err = b.queue.Enqueue(ctx, updated.OwnerID, jobs.KindArchivePage, jobs.ArchivePagePayload{BookmarkID: updated.ID})
+	return err
+}
+
 func (b BookmarkController) Update(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		b.logger.Errorf("Error while parsing bookmarkID: %v", err)
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid bookmark id",
-		})
+		abortWithProblem(c, http.StatusBadRequest, "Invalid bookmark id")
 		return
 	}
 	b.logger.Infof("update bookmark id=%d", id)
-	ctx := c.Request.Context()
 	var ub domain.UpdateBookmarkModel
-	if err := c.ShouldBindJSON(&ub); err != nil {
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error": "Unable to parse request body. Error: " + err.Error(),
-		})
+	if !bindJSON(c, &ub) {
 		return
 	}
-	now := time.Now()
-	bookmark := domain.Bookmark{
-		ID:          id,
-		Title:       ub.Title,
-		URL:         ub.URL,
-		UpdatedDate: &now,
+	b.update(c, id, func(domain.Bookmark) (domain.UpdateBookmarkModel, error) {
+		return ub, nil
+	})
+}
+
+// Patch applies a JSON Merge Patch (RFC 7396) to the title, url and tags of a bookmark,
+// removing the tags when they are patched with null.
+func (b BookmarkController) Patch(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		abortWithProblem(c, http.StatusBadRequest, "Invalid bookmark id")
+		return
+	}
+	b.logger.Infof("patch bookmark id=%d", id)
+	if !isMergePatch(c.GetHeader("Content-Type")) {
+		abortWithProblem(c, http.StatusUnsupportedMediaType, "Content-Type must be "+MergePatchContentType)
+		return
+	}
+	patch, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxPatchSize))
+	if err != nil || !json.Valid(patch) {
+		abortWithProblem(c, http.StatusBadRequest, "Unable to parse request body")
+		return
 	}
-	_, err = b.repo.Update(ctx, bookmark)
+	b.update(c, id, func(current domain.Bookmark) (domain.UpdateBookmarkModel, error) {
+		doc, err := json.Marshal(domain.UpdateBookmarkModel{Title: current.Title, URL: current.URL,
+			Tags: current.Tags})
+		if err != nil {
+			return domain.UpdateBookmarkModel{}, err
+		}
+		if doc, err = mergePatch(doc, patch); err != nil {
+			return domain.UpdateBookmarkModel{}, domain.ValidationError(err)
+		}
+		var ub domain.UpdateBookmarkModel
+		dec := json.NewDecoder(bytes.NewReader(doc))
+		dec.DisallowUnknownFields()
+		if err = dec.Decode(&ub); err != nil {
+			return domain.UpdateBookmarkModel{}, domain.ValidationError(err)
+		}
+		if err = binding.Validator.ValidateStruct(&ub); err != nil {
+			return domain.UpdateBookmarkModel{}, domain.ValidationError(err)
+		}
+		if ub.Tags == nil {
+			ub.Tags = []string{}
+		}
+		return ub, nil
+	})
+}
+
+// update saves the changes edit makes to the current bookmark, provided the If-Match
+// header of the request matches its version, and responds with the updated bookmark.
+func (b BookmarkController) update(c *gin.Context, id int,
+	edit func(current domain.Bookmark) (domain.UpdateBookmarkModel, error)) {
+	ifMatch := c.GetHeader("If-Match")
+	actor := auditActor(c)
+	var bookmark domain.Bookmark
+	// the version is checked again by the update, so a concurrent write in between fails too
+	err := b.repo.InTx(c.Request.Context(), func(ctx context.Context) error {
+		current, err := b.repo.FindByID(ctx, currentUserID(c), id)
+		if err != nil {
+			return err
+		}
+		if !matchesETag(ifMatch, current.Version) {
+			return domain.ErrStaleBookmark
+		}
+		ub, err := edit(current)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		bookmark = domain.Bookmark{
+			ID:          id,
+			OwnerID:     current.OwnerID,
+			Title:       ub.Title,
+			URL:         ub.URL,
+			Version:     current.Version,
+			UpdatedDate: &now,
+		}
+		if ub.Tags != nil {
+			bookmark.Tags = domain.NormalizeTags(ub.Tags)
+		}
+		if _, err = b.repo.Update(ctx, bookmark); err != nil {
+			return err
+		}
+		if bookmark, err = b.repo.FindByID(ctx, current.OwnerID, id); err != nil {
+			return err
+		}
+		if err = b.record(ctx, actor, domain.AuditUpdate, &current, &bookmark); err != nil {
+			return err
+		}
+		return b.enqueueForChangedURL(ctx, current, bookmark)
+	})
 	if err != nil {
-		b.logger.Errorf("Error while update bookmark: %v", err)
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error": "Unable to update bookmark",
-		})
+		abortWithError(c, err)
 		return
 	}
-	bookmark, _ = b.repo.FindByID(c.Request.Context(), id)
+	c.Header("ETag", etag(bookmark.Version))
 	c.JSON(http.StatusOK, bookmark)
 }
 
@@ -126,20 +355,59 @@ func (b BookmarkController) Delete(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		b.logger.Errorf("Error while parsing bookmarkID: %v", err)
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid bookmark id",
-		})
+		abortWithProblem(c, http.StatusBadRequest, "Invalid bookmark id")
 		return
 	}
 	b.logger.Infof("delete bookmark with id=%d", id)
-	ctx := c.Request.Context()
-	err = b.repo.Delete(ctx, id)
-	if err != nil {
-		b.logger.Errorf("Error while deleting bookmark: %v", err)
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-			"error": "Unable to delete bookmark",
-		})
+	if err = b.deleteBookmark(c.Request.Context(), auditActor(c), id); err != nil {
+		abortWithError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, nil)
 }
+
+// deleteBookmark moves the bookmark to the trash, recording it in the audit log.
+func (b BookmarkController) deleteBookmark(ctx context.Context, actor domain.AuditEntry, id int) error {
+	return b.repo.InTx(ctx, func(ctx context.Context) error {
+		before, err := b.repo.FindByID(ctx, actor.OwnerID, id)
+		if err != nil {
+			return err
+		}
+		if err = b.repo.Delete(ctx, actor.OwnerID, id); err != nil {
+			return err
+		}
+		after := before
+		now := time.Now()
+		after.DeletedDate = &now
+		return b.record(ctx, actor, domain.AuditDelete, &before, &after)
+	})
+}
+
+// auditActor returns the audit entry describing who made the request, to be completed by record.
+func auditActor(c *gin.Context) domain.AuditEntry {
+	actor := domain.AuditEntry{OwnerID: currentUserID(c), ActorID: currentUserID(c), ClientIP: c.ClientIP()}
+	if id, ok := c.Get(apiKeyIDKey); ok {
+		apiKeyID := id.(int)
+		actor.APIKeyID = &apiKeyID
+	}
+	return actor
+}
+
+// record appends the change of a bookmark from before to after to the audit log.
+func (b BookmarkController) record(ctx context.Context, actor domain.AuditEntry, action string,
+	before, after *domain.Bookmark) error {
+	return b.audit.Record(ctx, auditEntry(actor, action, before, after))
+}
+
+func auditEntry(actor domain.AuditEntry, action string, before, after *domain.Bookmark) domain.AuditEntry {
+	entry := actor
+	entry.Action = action
+	entry.Changes = domain.BookmarkChanges(before, after)
+	entry.CreatedDate = time.Now()
+	if after != nil {
+		entry.BookmarkID = after.ID
+	} else {
+		entry.BookmarkID = before.ID
+	}
+	return entry
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/archive"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/domain"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// archiveCSP keeps archived pages from running scripts or loading anything
+// but the images and styles they still link to.
+const archiveCSP = "sandbox; default-src 'none'; img-src data: http: https:; style-src 'unsafe-inline' http: https:; " +
+	"font-src data: http: https:"
+
+type ArchiveController struct {
+	repo    domain.ArchiveRepository
+	storage archive.Storage
+	logger  *logging.Logger
+}
+
+func NewArchiveController(repository domain.ArchiveRepository, storage archive.Storage,
+	logger *logging.Logger) *ArchiveController {
+	return &ArchiveController{repo: repository, storage: storage, logger: logger}
+}
+
+// FindByBookmarkID serves the archived copy of the page of a bookmark.
+func (a ArchiveController) FindByBookmarkID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		a.logger.Errorf("Error while parsing bookmarkID: %v", err)
+		abortWithProblem(c, http.StatusBadRequest, "Invalid bookmark id")
+		return
+	}
+	a.logger.Infof("Fetching archive of bookmark id=%d", id)
+	ctx := c.Request.Context()
+	found, err := a.repo.FindByBookmarkID(ctx, currentUserID(c), id)
+	var content io.ReadCloser
+	if err == nil {
+		content, err = a.storage.Open(ctx, found.StorageKey)
+	}
+	if errors.Is(err, domain.ErrArchiveNotFound) || errors.Is(err, archive.ErrNotFound) {
+		abortWithProblem(c, http.StatusNotFound, "Bookmark has no archive")
+		return
+	}
+	if err != nil {
+		a.logger.Errorf("Error while fetching archive: %v", err)
+		abortWithProblem(c, http.StatusInternalServerError, "Unable to fetch archive")
+		return
+	}
+	defer content.Close()
+	headers := map[string]string{
+		"Content-Security-Policy": archiveCSP,
+		"X-Content-Type-Options":  "nosniff",
+		"Last-Modified":           found.CreatedDate.UTC().Format(http.TimeFormat),
+	}
+	if found.ExpiresDate != nil {
+		headers["Expires"] = found.ExpiresDate.UTC().Format(http.TimeFormat)
+	}
+	c.DataFromReader(http.StatusOK, found.Size, found.ContentType, content, headers)
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/domain"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditController struct {
+	repo   domain.AuditRepository
+	logger *logging.Logger
+}
+
+func NewAuditController(repository domain.AuditRepository, logger *logging.Logger) *AuditController {
+	return &AuditController{repo: repository, logger: logger}
+}
+
+type AuditPage struct {
+	Data       []domain.AuditEntry `json:"data"`
+	Size       int                 `json:"size"`
+	NextCursor string              `json:"next_cursor,omitempty"`
+}
+
+// FindAll returns the audit log of the user's bookmarks, the most recent changes first,
+// filtered by ?bookmark_id, ?action and the RFC 3339 times ?since and ?until.
+func (a AuditController) FindAll(c *gin.Context) {
+	a.logger.Info("Fetching audit log")
+	query, err := parseAuditQuery(c)
+	if err != nil {
+		abortWithProblem(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if v := c.Query("bookmark_id"); v != "" {
+		if query.BookmarkID, err = strconv.Atoi(v); err != nil || query.BookmarkID < 1 {
+			abortWithProblem(c, http.StatusBadRequest, "bookmark_id must be a positive number")
+			return
+		}
+	}
+	a.findPage(c, query)
+}
+
+// History returns the changes of a bookmark, the most recent first, which remain
+// available after the bookmark is deleted.
+func (a AuditController) History(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		abortWithProblem(c, http.StatusBadRequest, "Invalid bookmark id")
+		return
+	}
+	a.logger.Infof("Fetching history of bookmark id=%d", id)
+	query, err := parseAuditQuery(c)
+	if err != nil {
+		abortWithProblem(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	query.BookmarkID = id
+	a.findPage(c, query)
+}
+
+func (a AuditController) findPage(c *gin.Context, query domain.AuditQuery) {
+	entries, hasMore, err := a.repo.FindPage(c.Request.Context(), query)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	page := AuditPage{Data: entries, Size: query.Size}
+	if hasMore {
+		page.NextCursor = domain.EncodeCursor(entries[len(entries)-1].ID)
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+func parseAuditQuery(c *gin.Context) (domain.AuditQuery, error) {
+	query := domain.AuditQuery{OwnerID: currentUserID(c), Size: domain.DefaultPageSize}
+	var err error
+	if v := c.Query("size"); v != "" {
+		query.Size, err = strconv.Atoi(v)
+		if err != nil || query.Size < 1 || query.Size > domain.MaxPageSize {
+			return query, fmt.Errorf("size must be between 1 and %d", domain.MaxPageSize)
+		}
+	}
+	if v := c.Query("after"); v != "" {
+		if query.After, err = domain.DecodeCursor(v); err != nil {
+			return query, err
+		}
+	}
+	switch query.Action = c.Query("action"); query.Action {
+	case "", domain.AuditCreate, domain.AuditUpdate, domain.AuditDelete, domain.AuditRestore:
+	default:
+		return query, errors.New("action must be one of create, update, delete, restore")
+	}
+	for param, dest := range map[string]**time.Time{"since": &query.Since, "until": &query.Until} {
+		if v := c.Query(param); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return query, fmt.Errorf("%s must be an RFC 3339 time", param)
+			}
+			// stored times are in local time, as returned by time.Now
+			t = t.Local()
+			*dest = &t
+		}
+	}
+	return query, nil
+}
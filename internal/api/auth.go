@@ -0,0 +1,248 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/auth"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/domain"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const defaultRefreshTokenTTL = 30 * 24 * time.Hour
+
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// apiKeyScopeKey and apiKeyIDKey are the gin context keys holding the scope and id of the
+// API key a request was authenticated with, they are not set for bearer tokens.
+const (
+	apiKeyScopeKey = "apiKeyScope"
+	apiKeyIDKey    = "apiKeyID"
+)
+
+type AuthController struct {
+	repo       domain.UserRepository
+	tokens     domain.RefreshTokenRepository
+	apiKeys    domain.APIKeyRepository
+	issuer     *auth.TokenIssuer
+	refreshTTL time.Duration
+	logger     *logging.Logger
+}
+
+func NewAuthController(repository domain.UserRepository, tokens domain.RefreshTokenRepository,
+	apiKeys domain.APIKeyRepository, issuer *auth.TokenIssuer, refreshTTL time.Duration,
+	logger *logging.Logger) *AuthController {
+	if refreshTTL <= 0 {
+		refreshTTL = defaultRefreshTokenTTL
+	}
+	return &AuthController{repo: repository, tokens: tokens, apiKeys: apiKeys, issuer: issuer,
+		refreshTTL: refreshTTL, logger: logger}
+}
+
+func (a AuthController) Register(c *gin.Context) {
+	ctx := c.Request.Context()
+	var ru domain.RegisterUserModel
+	if !bindJSON(c, &ru) {
+		return
+	}
+	a.logger.Infof("register user email=%s", ru.Email)
+	hash, err := bcrypt.GenerateFromPassword([]byte(ru.Password), bcrypt.DefaultCost)
+	if err != nil {
+		a.logger.Errorf("Error while hashing password: %v", err)
+		abortWithProblem(c, http.StatusInternalServerError, "Unable to register user")
+		return
+	}
+	user := domain.User{
+		Name:         ru.Name,
+		Email:        ru.Email,
+		PasswordHash: string(hash),
+		CreatedDate:  time.Now(),
+	}
+	user, err = a.repo.Create(ctx, user)
+	if errors.Is(err, domain.ErrEmailTaken) {
+		abortWithProblem(c, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		a.logger.Errorf("Error while registering user: %v", err)
+		abortWithProblem(c, http.StatusInternalServerError, "Unable to register user")
+		return
+	}
+	c.JSON(http.StatusCreated, user)
+}
+
+// Login exchanges the user's credentials for an access and a refresh token.
+func (a AuthController) Login(c *gin.Context) {
+	ctx := c.Request.Context()
+	var lm domain.LoginModel
+	if !bindJSON(c, &lm) {
+		return
+	}
+	user, err := a.repo.FindByEmail(ctx, lm.Email)
+	if err == nil {
+		err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(lm.Password))
+	}
+	// a disabled password is stored as a hash that is too short to match anything
+	if errors.Is(err, domain.ErrUserNotFound) || errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) ||
+		errors.Is(err, bcrypt.ErrHashTooShort) {
+		abortUnauthorized(c, "Invalid email or password")
+		return
+	}
+	if err != nil {
+		a.logger.Errorf("Error while authenticating user: %v", err)
+		abortWithProblem(c, http.StatusInternalServerError, "Unable to authenticate user")
+		return
+	}
+	refreshToken, hash, err := auth.NewRefreshToken()
+	if err == nil {
+		err = a.tokens.Create(ctx, a.newRefreshToken(user.ID, hash))
+	}
+	if err != nil {
+		a.logger.Errorf("Error while creating refresh token: %v", err)
+		abortWithProblem(c, http.StatusInternalServerError, "Unable to issue tokens")
+		return
+	}
+	a.respondWithTokens(c, user.ID, refreshToken)
+}
+
+// Refresh rotates a refresh token, the presented token can not be used again.
+func (a AuthController) Refresh(c *gin.Context) {
+	ctx := c.Request.Context()
+	var rm domain.RefreshTokenModel
+	if !bindJSON(c, &rm) {
+		return
+	}
+	refreshToken, hash, err := auth.NewRefreshToken()
+	var userID int
+	if err == nil {
+		userID, err = a.tokens.Rotate(ctx, auth.HashToken(rm.RefreshToken), a.newRefreshToken(0, hash))
+	}
+	if errors.Is(err, domain.ErrInvalidRefreshToken) {
+		abortUnauthorized(c, "Invalid refresh token")
+		return
+	}
+	if err != nil {
+		a.logger.Errorf("Error while rotating refresh token: %v", err)
+		abortWithProblem(c, http.StatusInternalServerError, "Unable to issue tokens")
+		return
+	}
+	a.respondWithTokens(c, userID, refreshToken)
+}
+
+func (a AuthController) Logout(c *gin.Context) {
+	var rm domain.RefreshTokenModel
+	if !bindJSON(c, &rm) {
+		return
+	}
+	if err := a.tokens.Revoke(c.Request.Context(), auth.HashToken(rm.RefreshToken)); err != nil {
+		a.logger.Errorf("Error while revoking refresh token: %v", err)
+		abortWithProblem(c, http.StatusInternalServerError, "Unable to revoke refresh token")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// RequireUser authenticates requests carrying either a bearer access token or
+// an "ApiKey" and puts the user id into the request context, see currentUserID.
+// Read-only API keys are limited to safe methods.
+func (a AuthController) RequireUser(c *gin.Context) {
+	scheme, credentials, _ := strings.Cut(c.GetHeader("Authorization"), " ")
+	if credentials == "" {
+		abortUnauthorized(c, "Authentication required")
+		return
+	}
+	var userID int
+	switch scheme {
+	case "Bearer":
+		var err error
+		if userID, err = a.issuer.Verify(credentials); err != nil {
+			a.logger.Infof("Rejected access token: %v", err)
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			abortUnauthorized(c, "Invalid or expired access token")
+			return
+		}
+	case "ApiKey":
+		key, err := a.apiKeys.Authenticate(c.Request.Context(), auth.HashToken(credentials), time.Now())
+		if errors.Is(err, domain.ErrAPIKeyNotFound) {
+			abortUnauthorized(c, "Invalid or revoked API key")
+			return
+		}
+		if err != nil {
+			a.logger.Errorf("Error while authenticating api key: %v", err)
+			abortWithProblem(c, http.StatusInternalServerError, "Unable to authenticate user")
+			return
+		}
+		if key.Scope == domain.ScopeRead && !isSafeMethod(c.Request.Method) {
+			abortWithProblem(c, http.StatusForbidden, "API key is read-only")
+			return
+		}
+		userID = key.UserID
+		c.Set(apiKeyScopeKey, key.Scope)
+		c.Set(apiKeyIDKey, key.ID)
+	default:
+		abortUnauthorized(c, "Unsupported authorization scheme")
+		return
+	}
+	c.Request = c.Request.WithContext(auth.WithUserID(c.Request.Context(), userID))
+	c.Next()
+}
+
+// DenyAPIKeys rejects requests authenticated with an API key, so that keys
+// can only be managed by the user themselves.
+func (a AuthController) DenyAPIKeys(c *gin.Context) {
+	if _, ok := c.Get(apiKeyScopeKey); ok {
+		abortWithProblem(c, http.StatusForbidden, "API keys can not be used for this operation")
+		return
+	}
+	c.Next()
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+func (a AuthController) newRefreshToken(userID int, hash string) domain.RefreshToken {
+	now := time.Now()
+	return domain.RefreshToken{
+		UserID:      userID,
+		TokenHash:   hash,
+		ExpiresAt:   now.Add(a.refreshTTL),
+		CreatedDate: now,
+	}
+}
+
+func (a AuthController) respondWithTokens(c *gin.Context, userID int, refreshToken string) {
+	accessToken, expiresAt, err := a.issuer.Issue(userID)
+	if err != nil {
+		a.logger.Errorf("Error while signing access token: %v", err)
+		abortWithProblem(c, http.StatusInternalServerError, "Unable to issue tokens")
+		return
+	}
+	c.JSON(http.StatusOK, TokenResponse{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(time.Until(expiresAt).Round(time.Second).Seconds()),
+		RefreshToken: refreshToken,
+	})
+}
+
+func abortUnauthorized(c *gin.Context, message string) {
+	if c.Writer.Header().Get("WWW-Authenticate") == "" {
+		c.Header("WWW-Authenticate", "Bearer")
+	}
+	abortWithProblem(c, http.StatusUnauthorized, message)
+}
+
+func currentUserID(c *gin.Context) int {
+	return auth.UserID(c.Request.Context())
+}
@@ -0,0 +1,200 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/domain"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/jobs"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BulkResult is the outcome of one operation of a bulk request, Status being the
+// status the operation would have been answered with on its own.
+type BulkResult struct {
+	Index      int              `json:"index"`
+	Op         string           `json:"op"`
+	Status     int              `json:"status"`
+	ID         int              `json:"id,omitempty"`
+	Bookmark   *domain.Bookmark `json:"bookmark,omitempty"`
+	Error      string           `json:"error,omitempty"`
+	ExistingID int              `json:"existing_id,omitempty"`
+}
+
+type BulkResponse struct {
+	Succeeded int          `json:"succeeded"`
+	Failed    int          `json:"failed"`
+	Results   []BulkResult `json:"results"`
+}
+
+// Bulk applies a list of operations to the bookmarks of the user. In the default atomic
+// mode the first failing operation rolls back all others, which then fail with 424 Failed
+// Dependency. In best-effort mode every operation is applied or fails on its own.
+func (b BookmarkController) Bulk(c *gin.Context) {
+	var model domain.BulkModel
+	if !bindJSON(c, &model) {
+		return
+	}
+	atomic := model.Mode != "best-effort"
+	b.logger.Infof("bulk update of %d bookmarks atomic=%t", len(model.Operations), atomic)
+	ctx := c.Request.Context()
+	actor := auditActor(c)
+	results := make([]BulkResult, len(model.Operations))
+	if atomic {
+		failed := -1
+		err := b.repo.InTx(ctx, func(ctx context.Context) error {
+			for i, op := range model.Operations {
+				result, err := b.applyBulkOperation(ctx, actor, i, op)
+				results[i] = result
+				if err != nil {
+					failed = i
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil && failed < 0 {
+			abortWithError(c, err)
+			return
+		}
+		if failed >= 0 {
+			for i := range results {
+				if i != failed {
+					results[i] = BulkResult{Index: i, Op: model.Operations[i].Op, Status: http.StatusFailedDependency,
+						ID: model.Operations[i].ID, Error: fmt.Sprintf("not applied, operation %d failed", failed)}
+				}
+			}
+		}
+	} else {
+		for i, op := range model.Operations {
+			err := b.repo.InTx(ctx, func(ctx context.Context) error {
+				var err error
+				results[i], err = b.applyBulkOperation(ctx, actor, i, op)
+				return err
+			})
+			if err != nil && results[i].Status < http.StatusBadRequest {
+				// the operation succeeded but could not be committed
+				results[i] = b.failedBulkResult(results[i], err)
+			}
+		}
+	}
+
+	response := BulkResponse{Results: results}
+	for _, result := range results {
+		if result.Status >= http.StatusBadRequest {
+			response.Failed++
+			continue
+		}
+		response.Succeeded++
+		if result.Op == domain.BulkCreate {
+			b.enqueue(c, *result.Bookmark, jobs.KindFetchMetadata,
+				jobs.FetchMetadataPayload{BookmarkID: result.ID, KeepTitle: true})
+			b.enqueue(c, *result.Bookmark, jobs.KindArchivePage, jobs.ArchivePagePayload{BookmarkID: result.ID})
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// applyBulkOperation applies op, returning its result along with the error it failed with.
+func (b BookmarkController) applyBulkOperation(ctx context.Context, actor domain.AuditEntry, index int,
+	op domain.BulkOperationModel) (BulkResult, error) {
+	result := BulkResult{Index: index, Op: op.Op, Status: http.StatusOK, ID: op.ID}
+	var bookmark domain.Bookmark
+	var err error
+	switch op.Op {
+	case domain.BulkCreate:
+		bookmark, err = b.createBookmark(ctx, actor, domain.Bookmark{
+			OwnerID:     actor.OwnerID,
+			Title:       strings.TrimSpace(op.Bookmark.Title),
+			URL:         op.Bookmark.URL,
+			Tags:        domain.NormalizeTags(op.Bookmark.Tags),
+			CreatedDate: time.Now(),
+		})
+		result.Status = http.StatusCreated
+	case domain.BulkUpdate:
+		bookmark, err = b.editBookmark(ctx, actor, op, func(bookmark *domain.Bookmark) error {
+			bookmark.Title = strings.TrimSpace(op.Bookmark.Title)
+			bookmark.URL = op.Bookmark.URL
+			if op.Bookmark.Tags != nil {
+				bookmark.Tags = op.Bookmark.Tags
+			}
+			return nil
+		})
+	case domain.BulkDelete:
+		err = b.deleteBookmark(ctx, actor, op.ID)
+	case domain.BulkAddTag, domain.BulkRemoveTag:
+		bookmark, err = b.editBookmark(ctx, actor, op, func(bookmark *domain.Bookmark) error {
+			tag := domain.NormalizeTags([]string{op.Tag})
+			if len(tag) == 0 {
+				return domain.ValidationError(errors.New("tag must not be blank"))
+			}
+			tags := []string{}
+			for _, t := range bookmark.Tags {
+				if t != tag[0] {
+					tags = append(tags, t)
+				}
+			}
+			if op.Op == domain.BulkAddTag {
+				tags = append(tags, tag[0])
+			}
+			bookmark.Tags = tags
+			return nil
+		})
+	case domain.BulkMoveToCollection:
+		err = b.collections.MoveBookmarkToCollection(ctx, actor.OwnerID, op.ID, op.CollectionID, op.Position)
+	}
+	if err != nil {
+		return b.failedBulkResult(result, err), err
+	}
+	if bookmark.ID != 0 {
+		result.ID, result.Bookmark = bookmark.ID, &bookmark
+	}
+	return result, nil
+}
+
+func (b BookmarkController) failedBulkResult(result BulkResult, err error) BulkResult {
+	status, detail := errorStatus(err)
+	if status == http.StatusInternalServerError {
+		b.logger.Errorf("Error while applying bulk operation %d %s: %v", result.Index, result.Op, err)
+	}
+	result.Status, result.Error, result.Bookmark = status, detail, nil
+	var dup *domain.DuplicateURLError
+	if errors.As(err, &dup) {
+		result.ExistingID = dup.ExistingID
+	}
+	return result
+}
+
+// editBookmark saves the changes edit makes to the bookmark of op, provided the version
+// of op, if any, is the current version of the bookmark.
+func (b BookmarkController) editBookmark(ctx context.Context, actor domain.AuditEntry, op domain.BulkOperationModel,
+	edit func(bookmark *domain.Bookmark) error) (domain.Bookmark, error) {
+	current, err := b.repo.FindByID(ctx, actor.OwnerID, op.ID)
+	if err != nil {
+		return domain.Bookmark{}, err
+	}
+	bookmark := current
+	bookmark.Tags = append([]string{}, current.Tags...)
+	if err = edit(&bookmark); err != nil {
+		return domain.Bookmark{}, err
+	}
+	now := time.Now()
+	bookmark.Tags = domain.NormalizeTags(bookmark.Tags)
+	bookmark.Version = op.Version
+	bookmark.UpdatedDate = &now
+	if _, err = b.repo.Update(ctx, bookmark); err != nil {
+		return domain.Bookmark{}, err
+	}
+	if bookmark, err = b.repo.FindByID(ctx, actor.OwnerID, op.ID); err != nil {
+		return domain.Bookmark{}, err
+	}
+	if err = b.record(ctx, actor, domain.AuditUpdate, &current, &bookmark); err != nil {
+		return domain.Bookmark{}, err
+	}
+	return bookmark, b.enqueueForChangedURL(ctx, current, bookmark)
+}
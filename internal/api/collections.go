@@ -0,0 +1,204 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/domain"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CollectionController struct {
+	repo   domain.CollectionRepository
+	logger *logging.Logger
+}
+
+func NewCollectionController(repository domain.CollectionRepository, logger *logging.Logger) *CollectionController {
+	return &CollectionController{repo: repository, logger: logger}
+}
+
+func (cc CollectionController) FindAll(c *gin.Context) {
+	cc.logger.Info("Fetching all collections")
+	collections, err := cc.repo.FindAll(c.Request.Context(), currentUserID(c))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, collections)
+}
+
+func (cc CollectionController) FindByID(c *gin.Context) {
+	id, ok := collectionID(c)
+	if !ok {
+		return
+	}
+	collection, err := cc.repo.FindByID(c.Request.Context(), currentUserID(c), id)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, collection)
+}
+
+func (cc CollectionController) Create(c *gin.Context) {
+	var model domain.SaveCollectionModel
+	if !bindJSON(c, &model) {
+		return
+	}
+	cc.logger.Infof("create collection name=%s", model.Name)
+	collection, err := cc.repo.Create(c.Request.Context(), domain.Collection{
+		OwnerID:     currentUserID(c),
+		ParentID:    model.ParentID,
+		Name:        model.Name,
+		CreatedDate: time.Now(),
+	})
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, collection)
+}
+
+func (cc CollectionController) Update(c *gin.Context) {
+	id, ok := collectionID(c)
+	if !ok {
+		return
+	}
+	var model domain.SaveCollectionModel
+	if !bindJSON(c, &model) {
+		return
+	}
+	cc.logger.Infof("update collection id=%d", id)
+	now := time.Now()
+	collection, err := cc.repo.Update(c.Request.Context(), domain.Collection{
+		ID:          id,
+		OwnerID:     currentUserID(c),
+		ParentID:    model.ParentID,
+		Name:        model.Name,
+		UpdatedDate: &now,
+	})
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, collection)
+}
+
+// Delete removes the collection, ?mode=cascade deletes its sub-collections too while
+// the default ?mode=reparent moves them and its bookmarks to its parent.
+func (cc CollectionController) Delete(c *gin.Context) {
+	id, ok := collectionID(c)
+	if !ok {
+		return
+	}
+	mode := domain.DeleteMode(c.DefaultQuery("mode", string(domain.DeleteReparent)))
+	if mode != domain.DeleteCascade && mode != domain.DeleteReparent {
+		abortWithProblem(c, http.StatusBadRequest, "mode must be one of cascade, reparent")
+		return
+	}
+	cc.logger.Infof("delete collection id=%d mode=%s", id, mode)
+	if err := cc.repo.Delete(c.Request.Context(), currentUserID(c), id, mode); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, nil)
+}
+
+func (cc CollectionController) FindBookmarks(c *gin.Context) {
+	id, ok := collectionID(c)
+	if !ok {
+		return
+	}
+	cc.logger.Infof("Fetching bookmarks of collection id=%d", id)
+	bookmarks, err := cc.repo.FindBookmarks(c.Request.Context(), currentUserID(c), id)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, bookmarks)
+}
+
+func (cc CollectionController) AddBookmark(c *gin.Context) {
+	id, ok := collectionID(c)
+	if !ok {
+		return
+	}
+	var model domain.AddCollectionBookmarkModel
+	if !bindJSON(c, &model) {
+		return
+	}
+	cc.logger.Infof("add bookmark id=%d to collection id=%d", model.BookmarkID, id)
+	err := cc.repo.AddBookmark(c.Request.Context(), currentUserID(c), id, model.BookmarkID, model.Position)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, nil)
+}
+
+func (cc CollectionController) RemoveBookmark(c *gin.Context) {
+	id, ok := collectionID(c)
+	if !ok {
+		return
+	}
+	bookmarkID, err := strconv.Atoi(c.Param("bookmarkId"))
+	if err != nil {
+		abortWithProblem(c, http.StatusBadRequest, "Invalid bookmark id")
+		return
+	}
+	cc.logger.Infof("remove bookmark id=%d from collection id=%d", bookmarkID, id)
+	if err = cc.repo.RemoveBookmark(c.Request.Context(), currentUserID(c), id, bookmarkID); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, nil)
+}
+
+// MoveBookmark moves a bookmark to another collection or, when the target is the
+// same collection, to another position within it.
+func (cc CollectionController) MoveBookmark(c *gin.Context) {
+	cc.transferBookmark(c, "move", cc.repo.MoveBookmark)
+}
+
+// CopyBookmark adds a bookmark of the collection to another collection, keeping it in both.
+func (cc CollectionController) CopyBookmark(c *gin.Context) {
+	cc.transferBookmark(c, "copy", cc.repo.CopyBookmark)
+}
+
+func (cc CollectionController) transferBookmark(c *gin.Context, action string,
+	transfer func(ctx context.Context, ownerID, id, bookmarkID, targetID int, position *int) error) {
+	id, ok := collectionID(c)
+	if !ok {
+		return
+	}
+	bookmarkID, err := strconv.Atoi(c.Param("bookmarkId"))
+	if err != nil {
+		abortWithProblem(c, http.StatusBadRequest, "Invalid bookmark id")
+		return
+	}
+	var model domain.TransferBookmarkModel
+	if !bindJSON(c, &model) {
+		return
+	}
+	cc.logger.Infof("%s bookmark id=%d from collection id=%d to collection id=%d", action, bookmarkID, id,
+		model.CollectionID)
+	err = transfer(c.Request.Context(), currentUserID(c), id, bookmarkID, model.CollectionID, model.Position)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, nil)
+}
+
+func collectionID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		abortWithProblem(c, http.StatusBadRequest, "Invalid collection id")
+		return 0, false
+	}
+	return id, true
+}
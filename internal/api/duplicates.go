@@ -0,0 +1,46 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DuplicateGroup struct {
+	NormalizedURL string            `json:"normalized_url"`
+	Bookmarks     []domain.Bookmark `json:"bookmarks"`
+}
+
+// FindDuplicates reports the bookmarks of the user sharing the same normalized url,
+// which may exist among bookmarks saved before urls were normalized.
+func (b BookmarkController) FindDuplicates(c *gin.Context) {
+	b.logger.Info("Fetching duplicate bookmarks")
+	ctx := c.Request.Context()
+	groups := map[string]*DuplicateGroup{}
+	var order []string
+	err := b.repo.ForEach(ctx, currentUserID(c), func(bookmark domain.Bookmark) error {
+		key := domain.NormalizedURL(bookmark.URL)
+		group, ok := groups[key]
+		if !ok {
+			group = &DuplicateGroup{NormalizedURL: key}
+			groups[key] = group
+			order = append(order, key)
+		}
+		group.Bookmarks = append(group.Bookmarks, bookmark)
+		return nil
+	})
+	if err != nil {
+		b.logger.Errorf("Error while fetching duplicate bookmarks: %v", err)
+		abortWithProblem(c, http.StatusInternalServerError, "Unable to fetch duplicate bookmarks")
+		return
+	}
+	duplicates := []DuplicateGroup{}
+	for _, key := range order {
+		if group := groups[key]; len(group.Bookmarks) > 1 {
+			duplicates = append(duplicates, *group)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": duplicates, "total": len(duplicates)})
+}
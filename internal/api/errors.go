@@ -0,0 +1,149 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/domain"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	// RequestIDHeader carries the id of a request, taken from the client when valid or generated.
+	RequestIDHeader = "X-Request-ID"
+	requestIDKey    = "requestID"
+	problemJSON     = "application/problem+json"
+)
+
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// Problem is an RFC 7807 problem details body, sent as application/problem+json for all errors.
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+	// ExistingID and Existing point at the bookmark already having a duplicate url.
+	ExistingID int    `json:"existing_id,omitempty"`
+	Existing   string `json:"existing,omitempty"`
+}
+
+// RequestID assigns every request an id, which is echoed in the X-Request-ID
+// response header and in problem details.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = newRequestID()
+		}
+		c.Set(requestIDKey, id)
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ErrorHandler responds to the errors handlers attached with abortWithError, mapping
+// domain.ErrNotFound, domain.ErrConflict, domain.ErrValidation and domain.ErrPreconditionFailed
+// to 404, 409, 422 and 412. Other errors are logged and answered with 500 without revealing
+// their details.
+func ErrorHandler(logger *logging.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		last := c.Errors.Last()
+		if last == nil || c.Writer.Written() {
+			return
+		}
+		err := last.Err
+		status, detail := errorStatus(err)
+		if status == http.StatusInternalServerError {
+			logger.Errorf("Error while handling %s %s request_id=%s: %v", c.Request.Method, c.Request.URL.Path,
+				c.GetString(requestIDKey), err)
+		}
+		problem := newProblem(c, status, detail)
+		var dup *domain.DuplicateURLError
+		if errors.As(err, &dup) {
+			problem.ExistingID = dup.ExistingID
+			problem.Existing = bookmarkPath(dup.ExistingID)
+		}
+		writeProblem(c, problem)
+	}
+}
+
+// errorStatus returns the status and detail err is answered with, hiding the details of unexpected errors.
+func errorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound, err.Error()
+	case errors.Is(err, domain.ErrConflict):
+		return http.StatusConflict, err.Error()
+	case errors.Is(err, domain.ErrValidation):
+		return http.StatusUnprocessableEntity, err.Error()
+	case errors.Is(err, domain.ErrPreconditionFailed):
+		return http.StatusPreconditionFailed, err.Error()
+	default:
+		return http.StatusInternalServerError, "The request could not be processed"
+	}
+}
+
+// abortWithError stops the handler chain, leaving the response to ErrorHandler.
+func abortWithError(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// abortWithProblem stops the handler chain responding with a problem of status.
+func abortWithProblem(c *gin.Context, status int, detail string) {
+	c.Abort()
+	writeProblem(c, newProblem(c, status, detail))
+}
+
+// bindJSON binds the request body to obj. Bodies failing validation are answered with
+// 422 and malformed bodies with 400, in which case bindJSON returns false.
+func bindJSON(c *gin.Context, obj any) bool {
+	err := c.ShouldBindJSON(obj)
+	if err == nil {
+		return true
+	}
+	var invalid validator.ValidationErrors
+	if errors.As(err, &invalid) {
+		abortWithError(c, domain.ValidationError(err))
+	} else {
+		abortWithProblem(c, http.StatusBadRequest, "Unable to parse request body. Error: "+err.Error())
+	}
+	return false
+}
+
+func newProblem(c *gin.Context, status int, detail string) Problem {
+	return Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  c.Request.URL.Path,
+		RequestID: c.GetString(requestIDKey),
+	}
+}
+
+func writeProblem(c *gin.Context, problem Problem) {
+	body, err := json.Marshal(problem)
+	if err != nil {
+		c.Status(problem.Status)
+		return
+	}
+	c.Data(problem.Status, problemJSON, body)
+}
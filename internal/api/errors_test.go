@@ -0,0 +1,106 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/domain"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func newErrorTestRouter(err error) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	logger := &logging.Logger{SugaredLogger: zap.NewNop().Sugar()}
+	r := gin.New()
+	r.Use(RequestID(), ErrorHandler(logger))
+	r.GET("/fail", func(c *gin.Context) {
+		abortWithError(c, err)
+	})
+	r.POST("/bind", func(c *gin.Context) {
+		var model domain.SaveCollectionModel
+		if bindJSON(c, &model) {
+			c.Status(http.StatusNoContent)
+		}
+	})
+	return r
+}
+
+func TestErrorHandlerMapsDomainErrors(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		detail string
+	}{
+		{domain.ErrBookmarkNotFound, http.StatusNotFound, "bookmark not found"},
+		{domain.ErrCollectionCycle, http.StatusConflict, "collection can not be nested in itself"},
+		{domain.ValidationError(errors.New("title must not be blank")), http.StatusUnprocessableEntity,
+			"title must not be blank"},
+		{errors.New("connection refused"), http.StatusInternalServerError, "The request could not be processed"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/fail", nil)
+		req.Header.Set(RequestIDHeader, "req-42")
+		newErrorTestRouter(tt.err).ServeHTTP(w, req)
+
+		assert.Equal(t, tt.status, w.Code)
+		assert.Equal(t, problemJSON, w.Header().Get("Content-Type"))
+		assert.Equal(t, "req-42", w.Header().Get(RequestIDHeader))
+		var problem Problem
+		assert.Nil(t, json.NewDecoder(w.Body).Decode(&problem))
+		assert.Equal(t, tt.status, problem.Status)
+		assert.Equal(t, http.StatusText(tt.status), problem.Title)
+		assert.Equal(t, tt.detail, problem.Detail)
+		assert.Equal(t, "/fail", problem.Instance)
+		assert.Equal(t, "req-42", problem.RequestID)
+	}
+}
+
+func TestErrorHandlerPointsAtDuplicateBookmark(t *testing.T) {
+	w := httptest.NewRecorder()
+	newErrorTestRouter(&domain.DuplicateURLError{ExistingID: 7}).
+		ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fail", nil))
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	var problem Problem
+	assert.Nil(t, json.NewDecoder(w.Body).Decode(&problem))
+	assert.Equal(t, 7, problem.ExistingID)
+	assert.Equal(t, "/api/bookmarks/7", problem.Existing)
+	assert.NotEmpty(t, problem.RequestID)
+}
+
+func TestBindJSON(t *testing.T) {
+	router := newErrorTestRouter(nil)
+	tests := []struct {
+		body   string
+		status int
+	}{
+		{`{"name": "Reading list"}`, http.StatusNoContent},
+		{`{"name": ""}`, http.StatusUnprocessableEntity},
+		{`{"name": `, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/bind", strings.NewReader(tt.body)))
+
+		assert.Equal(t, tt.status, w.Code, tt.body)
+	}
+}
+
+func TestRequestIDIsGeneratedForInvalidHeader(t *testing.T) {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/fail", nil)
+	req.Header.Set(RequestIDHeader, "not a valid id\n")
+	newErrorTestRouter(domain.ErrNotFound).ServeHTTP(w, req)
+
+	id := w.Header().Get(RequestIDHeader)
+	assert.Len(t, id, 32)
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/domain"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/export"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Export streams all bookmarks of the user as an attachment in the requested format.
+func (b BookmarkController) Export(c *gin.Context) {
+	format, err := export.LookupFormat(c.DefaultQuery("format", "json"))
+	if err != nil {
+		abortWithProblem(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	b.logger.Infof("export bookmarks format=%s", format.Name)
+	ctx := c.Request.Context()
+	// large exports may take longer than the server's write timeout
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	filename := fmt.Sprintf("bookmarks-%s.%s", time.Now().Format("2006-01-02"), format.Extension)
+	c.Header("Content-Type", format.ContentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Status(http.StatusOK)
+
+	buf := bufio.NewWriter(c.Writer)
+	w := format.NewWriter(buf)
+	err = b.repo.ForEach(ctx, currentUserID(c), func(bookmark domain.Bookmark) error {
+		return w.Write(bookmark)
+	})
+	if err == nil {
+		err = w.Close()
+	}
+	if err == nil {
+		err = buf.Flush()
+	}
+	if err != nil {
+		// the response has already started, all we can do is to cut it short
+		b.logger.Errorf("Error while exporting bookmarks: %v", err)
+		_ = c.Error(err)
+		c.Abort()
+	}
+}
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/domain"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/netscape"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxImportFileSize = 10 << 20
+	importBatchSize   = 500
+)
+
+type ImportSummary struct {
+	Created        int           `json:"created"`
+	Skipped        int           `json:"skipped"`
+	Failed         int           `json:"failed"`
+	SkippedEntries []ImportEntry `json:"skipped_entries"`
+	FailedEntries  []ImportEntry `json:"failed_entries"`
+}
+
+type ImportEntry struct {
+	Title  string `json:"title"`
+	URL    string `json:"url"`
+	Reason string `json:"reason"`
+}
+
+// Import creates bookmarks from an uploaded Netscape bookmark file, turning
+// the folders into tags and skipping urls that are already bookmarked.
+func (b BookmarkController) Import(c *gin.Context) {
+	b.logger.Info("import bookmarks")
+	ctx := c.Request.Context()
+	ownerID := currentUserID(c)
+	actor := auditActor(c)
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportFileSize)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		abortWithProblem(c, http.StatusBadRequest, "Unable to read uploaded file. Error: "+err.Error())
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		abortWithProblem(c, http.StatusBadRequest, "Unable to read uploaded file. Error: "+err.Error())
+		return
+	}
+	defer file.Close()
+	entries, err := netscape.Parse(file)
+	if err != nil {
+		abortWithProblem(c, http.StatusBadRequest, "Unable to parse bookmarks file. Error: "+err.Error())
+		return
+	}
+
+	summary := ImportSummary{SkippedEntries: []ImportEntry{}, FailedEntries: []ImportEntry{}}
+	seen := map[string]bool{}
+	var bookmarks []domain.Bookmark
+	now := time.Now()
+	for _, e := range entries {
+		entry := ImportEntry{Title: e.Title, URL: e.URL}
+		switch {
+		case !isHTTPURL(e.URL):
+			entry.Reason = "invalid url"
+			summary.FailedEntries = append(summary.FailedEntries, entry)
+		case seen[domain.NormalizedURL(e.URL)]:
+			entry.Reason = "duplicate in file"
+			summary.SkippedEntries = append(summary.SkippedEntries, entry)
+		default:
+			seen[domain.NormalizedURL(e.URL)] = true
+			bookmark := domain.Bookmark{
+				OwnerID:     ownerID,
+				Title:       e.Title,
+				URL:         e.URL,
+				Tags:        domain.NormalizeTags(append(e.Folders, e.Tags...)),
+				CreatedDate: e.AddDate,
+			}
+			if bookmark.Title == "" {
+				bookmark.Title = e.URL
+			}
+			if bookmark.CreatedDate.IsZero() {
+				bookmark.CreatedDate = now
+			}
+			// an invalid row would fail the batch it is saved with
+			if err := domain.ValidateBookmark(bookmark); err != nil {
+				entry.Reason = err.Error()
+				summary.FailedEntries = append(summary.FailedEntries, entry)
+				continue
+			}
+			bookmarks = append(bookmarks, bookmark)
+		}
+	}
+
+	for start := 0; start < len(bookmarks); start += importBatchSize {
+		batch := bookmarks[start:min(start+importBatchSize, len(bookmarks))]
+		urls := make([]string, len(batch))
+		for i, bm := range batch {
+			urls[i] = domain.NormalizedURL(bm.URL)
+		}
+		existing, err := b.repo.ExistingURLs(ctx, ownerID, urls)
+		if err != nil {
+			b.logger.Errorf("Error while importing bookmarks: %v", err)
+			abortWithProblem(c, http.StatusInternalServerError, "Unable to import bookmarks")
+			return
+		}
+		exists := map[string]bool{}
+		for _, u := range existing {
+			exists[u] = true
+		}
+		var toCreate []domain.Bookmark
+		for _, bm := range batch {
+			if exists[domain.NormalizedURL(bm.URL)] {
+				summary.SkippedEntries = append(summary.SkippedEntries,
+					ImportEntry{Title: bm.Title, URL: bm.URL, Reason: "already bookmarked"})
+				continue
+			}
+			toCreate = append(toCreate, bm)
+		}
+		err = b.repo.InTx(ctx, func(ctx context.Context) error {
+			created, err := b.repo.CreateAll(ctx, toCreate)
+			if err != nil {
+				return err
+			}
+			entries := make([]domain.AuditEntry, len(created))
+			for i := range created {
+				entries[i] = auditEntry(actor, domain.AuditCreate, nil, &created[i])
+			}
+			return b.audit.Record(ctx, entries...)
+		})
+		if err == nil {
+			summary.Created += len(toCreate)
+			continue
+		}
+		// save the bookmarks of the failed batch one by one to tell which of them failed
+		b.logger.Errorf("Error while importing a batch of bookmarks, saving them one by one: %v", err)
+		for _, bm := range toCreate {
+			if _, err = b.createBookmark(ctx, actor, bm); err != nil {
+				summary.FailedEntries = append(summary.FailedEntries,
+					ImportEntry{Title: bm.Title, URL: bm.URL, Reason: importFailure(err)})
+				continue
+			}
+			summary.Created++
+		}
+	}
+	summary.Skipped = len(summary.SkippedEntries)
+	summary.Failed = len(summary.FailedEntries)
+	c.JSON(http.StatusOK, summary)
+}
+
+// importFailure describes why a bookmark could not be imported.
+func importFailure(err error) string {
+	if status, detail := errorStatus(err); status != http.StatusInternalServerError {
+		return detail
+	}
+	return "unable to save bookmark"
+}
+
+func isHTTPURL(s string) bool {
+	u, err := url.ParseRequestURI(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/domain"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JobController struct {
+	repo   domain.JobRepository
+	logger *logging.Logger
+}
+
+func NewJobController(repository domain.JobRepository, logger *logging.Logger) *JobController {
+	return &JobController{repo: repository, logger: logger}
+}
+
+// FindAll returns the most recent jobs of the user, optionally filtered by status.
+func (j JobController) FindAll(c *gin.Context) {
+	j.logger.Info("Fetching jobs")
+	status := c.Query("status")
+	switch status {
+	case "", domain.JobPending, domain.JobRunning, domain.JobSucceeded, domain.JobDead:
+	default:
+		abortWithProblem(c, http.StatusBadRequest, "status must be one of pending, running, succeeded, dead")
+		return
+	}
+	limit := domain.DefaultPageSize
+	if v := c.Query("limit"); v != "" {
+		var err error
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > domain.MaxPageSize {
+			abortWithProblem(c, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", domain.MaxPageSize))
+			return
+		}
+	}
+	ctx := c.Request.Context()
+	jobs, err := j.repo.FindAll(ctx, currentUserID(c), status, limit)
+	if err != nil {
+		j.logger.Errorf("Error while fetching jobs: %v", err)
+		abortWithProblem(c, http.StatusInternalServerError, "Unable to fetch jobs")
+		return
+	}
+	if jobs == nil {
+		jobs = []domain.Job{}
+	}
+	c.JSON(http.StatusOK, jobs)
+}
+
+func (j JobController) FindByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		abortWithProblem(c, http.StatusBadRequest, "Invalid job id")
+		return
+	}
+	ctx := c.Request.Context()
+	job, err := j.repo.FindByID(ctx, currentUserID(c), id)
+	if errors.Is(err, domain.ErrJobNotFound) {
+		abortWithProblem(c, http.StatusNotFound, "Job not found")
+		return
+	}
+	if err != nil {
+		j.logger.Errorf("Error while fetching job by id: %v", err)
+		abortWithProblem(c, http.StatusInternalServerError, "Unable to fetch job by id")
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/auth"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/domain"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreatedAPIKey is returned once when a key is created, the key itself can
+// not be retrieved afterwards.
+type CreatedAPIKey struct {
+	domain.APIKey
+	Key string `json:"key"`
+}
+
+type APIKeyController struct {
+	repo   domain.APIKeyRepository
+	logger *logging.Logger
+}
+
+func NewAPIKeyController(repository domain.APIKeyRepository, logger *logging.Logger) *APIKeyController {
+	return &APIKeyController{repo: repository, logger: logger}
+}
+
+func (k APIKeyController) FindAll(c *gin.Context) {
+	k.logger.Info("Fetching api keys")
+	ctx := c.Request.Context()
+	keys, err := k.repo.FindAll(ctx, currentUserID(c))
+	if err != nil {
+		k.logger.Errorf("Error while fetching api keys: %v", err)
+		abortWithProblem(c, http.StatusInternalServerError, "Unable to fetch api keys")
+		return
+	}
+	if keys == nil {
+		keys = []domain.APIKey{}
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+func (k APIKeyController) Create(c *gin.Context) {
+	k.logger.Info("create api key")
+	ctx := c.Request.Context()
+	var ck domain.CreateAPIKeyModel
+	if !bindJSON(c, &ck) {
+		return
+	}
+	key, prefix, hash, err := auth.NewAPIKey()
+	var apiKey domain.APIKey
+	if err == nil {
+		apiKey, err = k.repo.Create(ctx, domain.APIKey{
+			UserID:      currentUserID(c),
+			Label:       ck.Label,
+			Scope:       ck.Scope,
+			Prefix:      prefix,
+			KeyHash:     hash,
+			CreatedDate: time.Now(),
+		})
+	}
+	if err != nil {
+		k.logger.Errorf("Error while creating api key: %v", err)
+		abortWithProblem(c, http.StatusInternalServerError, "Unable to create api key")
+		return
+	}
+	c.JSON(http.StatusCreated, CreatedAPIKey{APIKey: apiKey, Key: key})
+}
+
+func (k APIKeyController) Revoke(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		abortWithProblem(c, http.StatusBadRequest, "Invalid api key id")
+		return
+	}
+	k.logger.Infof("revoke api key id=%d", id)
+	err = k.repo.Revoke(c.Request.Context(), currentUserID(c), id)
+	if errors.Is(err, domain.ErrAPIKeyNotFound) {
+		abortWithProblem(c, http.StatusNotFound, "API key not found")
+		return
+	}
+	if err != nil {
+		k.logger.Errorf("Error while revoking api key: %v", err)
+		abortWithProblem(c, http.StatusInternalServerError, "Unable to revoke api key")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"encoding/json"
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// maxPatchSize bounds the size of merge patch documents.
+const maxPatchSize = 64 << 10
+
+// MergePatchContentType is the media type of JSON Merge Patch documents.
+const MergePatchContentType = "application/merge-patch+json"
+
+// isMergePatch reports whether contentType, a Content-Type header, is MergePatchContentType.
+func isMergePatch(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == MergePatchContentType
+}
+
+// mergePatch applies the JSON Merge Patch (RFC 7396) patch to the JSON document target.
+func mergePatch(target, patch []byte) ([]byte, error) {
+	var t, p any
+	if err := json.Unmarshal(target, &t); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, err
+	}
+	return json.Marshal(mergeValue(t, p))
+}
+
+func mergeValue(target, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = map[string]any{}
+	}
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+		} else {
+			targetObject[name] = mergeValue(targetObject[name], value)
+		}
+	}
+	return targetObject
+}
+
+// etag is the entity tag of a version of a resource.
+func etag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// matchesETag reports whether an If-Match header allows modifying the given version.
+// An empty header matches any version, weak tags never match.
+func matchesETag(ifMatch string, version int) bool {
+	if ifMatch == "" {
+		return true
+	}
+	for _, tag := range strings.Split(ifMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == etag(version) {
+			return true
+		}
+	}
+	return false
+}
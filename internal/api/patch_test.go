@@ -0,0 +1,51 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergePatch(t *testing.T) {
+	// examples of RFC 7396, appendix A
+	tests := []struct {
+		target, patch, result string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		result, err := mergePatch([]byte(tt.target), []byte(tt.patch))
+
+		assert.Nil(t, err)
+		assert.JSONEq(t, tt.result, string(result), tt.patch)
+	}
+}
+
+func TestMergePatchRejectsInvalidJSON(t *testing.T) {
+	_, err := mergePatch([]byte(`{}`), []byte(`{"a":`))
+
+	assert.NotNil(t, err)
+}
+
+func TestMatchesETag(t *testing.T) {
+	assert.True(t, matchesETag("", 3))
+	assert.True(t, matchesETag("*", 3))
+	assert.True(t, matchesETag(`"3"`, 3))
+	assert.True(t, matchesETag(`"2", "3"`, 3))
+	assert.False(t, matchesETag(`"2"`, 3))
+	assert.False(t, matchesETag(`W/"3"`, 3))
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/domain"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TagController struct {
+	repo   domain.TagRepository
+	logger *logging.Logger
+}
+
+func NewTagController(repository domain.TagRepository, logger *logging.Logger) *TagController {
+	return &TagController{repo: repository, logger: logger}
+}
+
+func (t TagController) FindAll(c *gin.Context) {
+	t.logger.Info("Fetching all tags")
+	ctx := c.Request.Context()
+	tags, err := t.repo.FindAll(ctx, currentUserID(c))
+	if err != nil {
+		t.logger.Errorf("Error while fetching tags: %v", err)
+		abortWithProblem(c, http.StatusInternalServerError, "Unable to fetch tags")
+		return
+	}
+	if tags == nil {
+		tags = []domain.Tag{}
+	}
+	c.JSON(http.StatusOK, tags)
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FindTrash lists the deleted bookmarks of the user which are not purged yet.
+func (b BookmarkController) FindTrash(c *gin.Context) {
+	b.logger.Info("Fetching trashed bookmarks")
+	bookmarks, err := b.repo.FindTrash(c.Request.Context(), currentUserID(c))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": bookmarks, "total": len(bookmarks)})
+}
+
+// Restore moves a deleted bookmark back out of the trash.
+func (b BookmarkController) Restore(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		abortWithProblem(c, http.StatusBadRequest, "Invalid bookmark id")
+		return
+	}
+	b.logger.Infof("restore bookmark with id=%d", id)
+	actor := auditActor(c)
+	var bookmark domain.Bookmark
+	err = b.repo.InTx(c.Request.Context(), func(ctx context.Context) error {
+		if bookmark, err = b.repo.Restore(ctx, actor.OwnerID, id); err != nil {
+			return err
+		}
+		before := bookmark
+		now := time.Now()
+		before.DeletedDate = &now
+		return b.record(ctx, actor, domain.AuditRestore, &before, &bookmark)
+	})
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.Header("ETag", etag(bookmark.Version))
+	c.JSON(http.StatusOK, bookmark)
+}
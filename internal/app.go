@@ -11,21 +11,52 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/jackc/pgx/v5"
 	"github.com/sivaprasadreddy/bookmarks-go/assets"
 	"github.com/sivaprasadreddy/bookmarks-go/internal/api"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/archive"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/auth"
 	"github.com/sivaprasadreddy/bookmarks-go/internal/config"
 	"github.com/sivaprasadreddy/bookmarks-go/internal/db"
 	"github.com/sivaprasadreddy/bookmarks-go/internal/domain"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/jobs"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/linkcheck"
 	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/metadata"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/trash"
 )
 
 type App struct {
-	Router             *gin.Engine
-	cfg                config.AppConfig
-	logger             *logging.Logger
-	db                 *pgx.Conn
-	bookmarkController *api.BookmarkController
+	Router               *gin.Engine
+	cfg                  config.AppConfig
+	logger               *logging.Logger
+	repos                repositories
+	closeDb              func()
+	bookmarkController   *api.BookmarkController
+	tagController        *api.TagController
+	authController       *api.AuthController
+	apiKeyController     *api.APIKeyController
+	jobController        *api.JobController
+	archiveController    *api.ArchiveController
+	collectionController *api.CollectionController
+	auditController      *api.AuditController
+	jobs                 *jobs.Pool
+	linkChecker          *linkcheck.Scheduler
+	archivePurger        *archive.Purger
+	trashPurger          *trash.Purger
+}
+
+// repositories are the repositories of the configured storage backend.
+type repositories struct {
+	users         domain.UserRepository
+	refreshTokens domain.RefreshTokenRepository
+	apiKeys       domain.APIKeyRepository
+	bookmarks     domain.BookmarkRepository
+	tags          domain.TagRepository
+	jobs          domain.JobRepository
+	linkChecks    domain.LinkCheckRepository
+	archives      domain.ArchiveRepository
+	collections   domain.CollectionRepository
+	audit         domain.AuditRepository
 }
 
 func NewApp(cfg config.AppConfig) *App {
@@ -36,29 +67,173 @@ func NewApp(cfg config.AppConfig) *App {
 
 func (app *App) init() {
 	app.logger = logging.NewLogger(app.cfg)
-	app.db = db.GetDb(app.cfg, app.logger)
+	app.initRepositories()
 
-	bookmarksRepo := domain.NewBookmarkRepo(app.db, app.logger)
-	app.bookmarkController = api.NewBookmarkController(bookmarksRepo, app.logger)
+	tokenIssuer, err := auth.NewTokenIssuer(app.cfg)
+	if err != nil {
+		app.logger.Fatalf("Invalid JWT configuration: %v", err)
+	}
+	usersRepo := app.repos.users
+	apiKeysRepo := app.repos.apiKeys
+	app.authController = api.NewAuthController(usersRepo, app.repos.refreshTokens, apiKeysRepo, tokenIssuer,
+		app.cfg.JwtRefreshTokenTTL, app.logger)
+	app.apiKeyController = api.NewAPIKeyController(apiKeysRepo, app.logger)
+	bookmarksRepo := app.repos.bookmarks
+	fetcher := metadata.NewHTTPFetcher(metadata.HTTPFetcherOptions{
+		Timeout:              app.cfg.MetadataFetchTimeout,
+		MaxBytes:             app.cfg.MetadataMaxBytes,
+		AllowPrivateNetworks: app.cfg.FetchAllowPrivateNetworks,
+	})
+	jobsRepo := app.repos.jobs
+	app.jobs = jobs.NewPool(jobsRepo, jobs.Options{
+		Workers:      app.cfg.JobsWorkers,
+		PollInterval: app.cfg.JobsPollInterval,
+		BackoffBase:  app.cfg.JobsBackoffBase,
+		BackoffMax:   app.cfg.JobsBackoffMax,
+	}, app.logger)
+	app.jobs.Handle(jobs.KindFetchMetadata, jobs.FetchMetadata(bookmarksRepo, fetcher, app.logger))
+	storage, err := archive.NewLocalStorage(app.cfg.ArchiveDir)
+	if err != nil {
+		app.logger.Fatalf("Invalid archive directory: %v", err)
+	}
+	archiver := archive.NewArchiver(archive.Options{
+		Timeout:              app.cfg.ArchiveFetchTimeout,
+		MaxBytes:             app.cfg.ArchiveMaxBytes,
+		InlineResources:      app.cfg.ArchiveInlineResources,
+		AllowPrivateNetworks: app.cfg.FetchAllowPrivateNetworks,
+	})
+	archivesRepo := app.repos.archives
+	app.jobs.Handle(jobs.KindArchivePage, jobs.ArchivePage(bookmarksRepo, archivesRepo, archiver, storage,
+		app.cfg.ArchiveRetention, app.logger))
+	app.archivePurger = archive.NewPurger(archivesRepo, storage, app.cfg.ArchivePurgeInterval, app.logger)
+	app.archiveController = api.NewArchiveController(archivesRepo, storage, app.logger)
+	queue := jobs.NewQueue(jobsRepo, app.cfg.JobsMaxAttempts)
+	app.jobController = api.NewJobController(jobsRepo, app.logger)
+	collectionsRepo := app.repos.collections
+	auditRepo := app.repos.audit
+	app.bookmarkController = api.NewBookmarkController(bookmarksRepo, collectionsRepo, auditRepo, queue, app.logger)
+	app.auditController = api.NewAuditController(auditRepo, app.logger)
+	app.trashPurger = trash.NewPurger(bookmarksRepo, app.cfg.TrashRetention, app.cfg.TrashPurgeInterval, app.logger)
+	checker := linkcheck.NewChecker(linkcheck.Options{
+		Timeout:              app.cfg.LinkCheckTimeout,
+		Concurrency:          app.cfg.LinkCheckConcurrency,
+		HostInterval:         app.cfg.LinkCheckHostInterval,
+		AllowPrivateNetworks: app.cfg.FetchAllowPrivateNetworks,
+	})
+	app.linkChecker = linkcheck.NewScheduler(app.repos.linkChecks, checker,
+		linkcheck.SchedulerOptions{
+			Interval:     app.cfg.LinkCheckInterval,
+			RecheckAfter: app.cfg.LinkCheckRecheckAfter,
+			BatchSize:    app.cfg.LinkCheckBatchSize,
+		}, app.logger)
+	app.tagController = api.NewTagController(app.repos.tags, app.logger)
+	app.collectionController = api.NewCollectionController(collectionsRepo, app.logger)
 
 	app.Router = app.setupRoutes()
 }
 
+// initRepositories connects to the configured storage backend and creates its repositories.
+func (app *App) initRepositories() {
+	switch app.cfg.StorageBackend {
+	case "", "postgres", "memory":
+		pool := db.GetDb(app.cfg, app.logger)
+		app.closeDb = pool.Close
+		app.repos = repositories{
+			users:         domain.NewUserRepo(pool, app.logger),
+			refreshTokens: domain.NewRefreshTokenRepo(pool, app.logger),
+			apiKeys:       domain.NewAPIKeyRepo(pool, app.logger),
+			bookmarks:     domain.NewBookmarkRepo(pool, app.logger),
+			tags:          domain.NewTagRepo(pool, app.logger),
+			jobs:          domain.NewJobRepo(pool, app.logger),
+			linkChecks:    domain.NewLinkCheckRepo(pool, app.logger),
+			archives:      domain.NewArchiveRepo(pool, app.logger),
+			collections:   domain.NewCollectionRepo(pool, app.logger),
+			audit:         domain.NewAuditRepo(pool, app.logger),
+		}
+		if app.cfg.StorageBackend == "memory" {
+			app.logger.Warn("Storing bookmarks in memory, they are lost when the server stops")
+			app.repos.bookmarks = domain.NewMemoryBookmarkRepo(app.logger)
+		}
+	case "sqlite":
+		sqliteDb := db.GetSQLiteDb(app.cfg, app.logger)
+		app.closeDb = func() { _ = sqliteDb.Close() }
+		app.repos = repositories{
+			users:         domain.NewSQLiteUserRepo(sqliteDb, app.logger),
+			refreshTokens: domain.NewSQLiteRefreshTokenRepo(sqliteDb, app.logger),
+			apiKeys:       domain.NewSQLiteAPIKeyRepo(sqliteDb, app.logger),
+			bookmarks:     domain.NewSQLiteBookmarkRepo(sqliteDb, app.logger),
+			tags:          domain.NewSQLiteTagRepo(sqliteDb, app.logger),
+			jobs:          domain.NewSQLiteJobRepo(sqliteDb, app.logger),
+			linkChecks:    domain.NewSQLiteLinkCheckRepo(sqliteDb, app.logger),
+			archives:      domain.NewSQLiteArchiveRepo(sqliteDb, app.logger),
+			collections:   domain.NewSQLiteCollectionRepo(sqliteDb, app.logger),
+			audit:         domain.NewSQLiteAuditRepo(sqliteDb, app.logger),
+		}
+	default:
+		app.logger.Fatalf("Invalid storage backend: %q", app.cfg.StorageBackend)
+	}
+}
+
 func (app *App) setupRoutes() *gin.Engine {
 	r := gin.Default()
+	if err := r.SetTrustedProxies(app.cfg.TrustedProxies); err != nil {
+		app.logger.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+	r.Use(api.RequestID(), api.ErrorHandler(app.logger))
 
 	r.Any("/", app.rootRouteHandler)
 	r.GET("/static/*filepath", func(c *gin.Context) {
 		c.FileFromFS(path.Join("/", c.Request.URL.Path), http.FS(assets.StaticFS))
 	})
 
-	apiRouter := r.Group("/api/bookmarks")
+	authRouter := r.Group("/api/auth")
 	{
-		apiRouter.GET("", app.bookmarkController.FindAll)
-		apiRouter.GET("/:id", app.bookmarkController.FindByID)
-		apiRouter.POST("", app.bookmarkController.Create)
-		apiRouter.PUT("/:id", app.bookmarkController.Update)
-		apiRouter.DELETE("/:id", app.bookmarkController.Delete)
+		authRouter.POST("/register", app.authController.Register)
+		authRouter.POST("/login", app.authController.Login)
+		authRouter.POST("/refresh", app.authController.Refresh)
+		authRouter.POST("/logout", app.authController.Logout)
+	}
+
+	apiRouter := r.Group("/api", app.authController.RequireUser)
+	{
+		apiRouter.GET("/bookmarks", app.bookmarkController.FindAll)
+		apiRouter.GET("/bookmarks/export", app.bookmarkController.Export)
+		apiRouter.GET("/bookmarks/duplicates", app.bookmarkController.FindDuplicates)
+		apiRouter.GET("/bookmarks/:id", app.bookmarkController.FindByID)
+		apiRouter.GET("/bookmarks/:id/archive", app.archiveController.FindByBookmarkID)
+		apiRouter.GET("/bookmarks/:id/history", app.auditController.History)
+		apiRouter.POST("/bookmarks", app.bookmarkController.Create)
+		apiRouter.POST("/bookmarks/import", app.bookmarkController.Import)
+		apiRouter.POST("/bookmarks/bulk", app.bookmarkController.Bulk)
+		apiRouter.PUT("/bookmarks/:id", app.bookmarkController.Update)
+		apiRouter.PATCH("/bookmarks/:id", app.bookmarkController.Patch)
+		apiRouter.DELETE("/bookmarks/:id", app.bookmarkController.Delete)
+
+		apiRouter.GET("/trash", app.bookmarkController.FindTrash)
+		apiRouter.POST("/trash/:id/restore", app.bookmarkController.Restore)
+
+		apiRouter.GET("/tags", app.tagController.FindAll)
+
+		apiRouter.GET("/audit", app.auditController.FindAll)
+
+		apiRouter.GET("/collections", app.collectionController.FindAll)
+		apiRouter.GET("/collections/:id", app.collectionController.FindByID)
+		apiRouter.POST("/collections", app.collectionController.Create)
+		apiRouter.PUT("/collections/:id", app.collectionController.Update)
+		apiRouter.DELETE("/collections/:id", app.collectionController.Delete)
+		apiRouter.GET("/collections/:id/bookmarks", app.collectionController.FindBookmarks)
+		apiRouter.POST("/collections/:id/bookmarks", app.collectionController.AddBookmark)
+		apiRouter.DELETE("/collections/:id/bookmarks/:bookmarkId", app.collectionController.RemoveBookmark)
+		apiRouter.POST("/collections/:id/bookmarks/:bookmarkId/move", app.collectionController.MoveBookmark)
+		apiRouter.POST("/collections/:id/bookmarks/:bookmarkId/copy", app.collectionController.CopyBookmark)
+
+		apiRouter.GET("/jobs", app.jobController.FindAll)
+		apiRouter.GET("/jobs/:id", app.jobController.FindByID)
+
+		keysRouter := apiRouter.Group("/keys", app.authController.DenyAPIKeys)
+		keysRouter.GET("", app.apiKeyController.FindAll)
+		keysRouter.POST("", app.apiKeyController.Create)
+		keysRouter.DELETE("/:id", app.apiKeyController.Revoke)
 	}
 
 	return r
@@ -89,6 +264,11 @@ func (app *App) Run() {
 		MaxHeaderBytes: 1 << 20,
 	}
 
+	app.jobs.Start()
+	app.linkChecker.Start()
+	app.archivePurger.Start()
+	app.trashPurger.Start()
+
 	// Initializing the server in a goroutine so that
 	// it won't block the graceful shutdown handling below
 	go func() {
@@ -111,5 +291,18 @@ func (app *App) Run() {
 	if err := srv.Shutdown(ctx); err != nil {
 		app.logger.Fatal("Server forced to shutdown: ", err)
 	}
+	if err := app.jobs.Stop(ctx); err != nil {
+		app.logger.Errorf("Job workers forced to stop: %v", err)
+	}
+	if err := app.linkChecker.Stop(ctx); err != nil {
+		app.logger.Errorf("Link checker forced to stop: %v", err)
+	}
+	if err := app.archivePurger.Stop(ctx); err != nil {
+		app.logger.Errorf("Archive purger forced to stop: %v", err)
+	}
+	if err := app.trashPurger.Stop(ctx); err != nil {
+		app.logger.Errorf("Trash purger forced to stop: %v", err)
+	}
+	app.closeDb()
 	app.logger.Infoln("Server exiting")
 }
@@ -1,66 +1,481 @@
 package bookmarks
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"path"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/sivaprasadreddy/bookmarks-go/internal/api"
 	"github.com/sivaprasadreddy/bookmarks-go/internal/config"
 	"github.com/sivaprasadreddy/bookmarks-go/internal/domain"
 	"github.com/sivaprasadreddy/bookmarks-go/testsupport"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
+	"github.com/testcontainers/testcontainers-go"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	demoEmail    = "demo@example.com"
+	demoPassword = "demo1234"
 )
 
 type ControllerTestSuite struct {
 	suite.Suite
+	// backend is the storage backend under test, Postgres unless set
+	backend     string
 	PgContainer *testsupport.PostgresContainer
 	cfg         config.AppConfig
 	app         *App
 	router      http.Handler
+	demoToken   string
 }
 
 func (suite *ControllerTestSuite) SetupSuite() {
-	suite.PgContainer = testsupport.InitPostgresContainer()
+	if suite.backend != "sqlite" {
+		suite.PgContainer = testsupport.InitPostgresContainer()
+	}
 	cfg, err := config.GetConfig(".env")
 	if err != nil {
 		log.Fatal(err)
 	}
+	if suite.backend == "sqlite" {
+		cfg.StorageBackend = "sqlite"
+		cfg.SQLitePath = path.Join(suite.T().TempDir(), "bookmarks.db")
+	}
+	// .env leaves the secret empty so that it has to be configured explicitly
+	cfg.JwtSecret = "a-test-secret-that-is-long-enough!"
+	// the metadata tests serve pages from a local httptest server
+	cfg.FetchAllowPrivateNetworks = true
+	cfg.MetadataFetchTimeout = 2 * time.Second
+	cfg.LinkCheckTimeout = 2 * time.Second
+	cfg.LinkCheckHostInterval = time.Millisecond
+	cfg.ArchiveDir = suite.T().TempDir()
+	// trashed bookmarks are purged whenever the purger runs
+	cfg.TrashRetention = time.Millisecond
 	suite.cfg = cfg
 
 	suite.app = NewApp(suite.cfg)
 	suite.router = suite.app.Router
+	suite.setDemoPassword()
+	suite.demoToken = suite.login(demoEmail, demoPassword).AccessToken
 }
 
 func (suite *ControllerTestSuite) TearDownSuite() {
-	suite.PgContainer.CloseFn()
+	if suite.PgContainer != nil {
+		suite.PgContainer.CloseFn()
+	}
 }
 
 func TestControllerTestSuite(t *testing.T) {
+	// the Postgres suite needs a container runtime, the SQLite suite runs everywhere
+	testcontainers.SkipIfProviderIsNotHealthy(t)
 	suite.Run(t, new(ControllerTestSuite))
 }
 
-func (suite *ControllerTestSuite) TestGetAllBookmarks() {
+func TestSQLiteControllerTestSuite(t *testing.T) {
+	suite.Run(t, &ControllerTestSuite{backend: "sqlite"})
+}
+
+// setDemoPassword gives the demo account of the sample data back its password, a migration
+// disables it.
+func (suite *ControllerTestSuite) setDemoPassword() {
+	hash, err := bcrypt.GenerateFromPassword([]byte(demoPassword), bcrypt.MinCost)
+	suite.Require().Nil(err)
+	suite.Require().Nil(suite.app.repos.users.UpdatePassword(context.Background(), demoEmail, string(hash)))
+}
+
+// request sends an API request authenticated as the demo user.
+func (suite *ControllerTestSuite) request(method, url string, body io.Reader) *httptest.ResponseRecorder {
+	return suite.requestWithToken(suite.demoToken, method, url, body)
+}
+
+func (suite *ControllerTestSuite) requestWithToken(token, method, url string, body io.Reader) *httptest.ResponseRecorder {
+	authorization := ""
+	if token != "" {
+		authorization = "Bearer " + token
+	}
+	return suite.requestWithAuthorization(authorization, method, url, body)
+}
+
+func (suite *ControllerTestSuite) requestWithAuthorization(authorization, method, url string, body io.Reader) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, url, body)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	if method == http.MethodPatch {
+		req.Header.Set("Content-Type", api.MergePatchContentType)
+	}
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+// requestIfMatch sends an API request authenticated as the demo user with an If-Match header.
+func (suite *ControllerTestSuite) requestIfMatch(etag, method, url string, body io.Reader) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, url, body)
+	req.Header.Set("Authorization", "Bearer "+suite.demoToken)
+	req.Header.Set("If-Match", etag)
+	if method == http.MethodPatch {
+		req.Header.Set("Content-Type", api.MergePatchContentType)
+	}
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+// runJobs runs the queued background jobs that are due.
+func (suite *ControllerTestSuite) runJobs() {
+	for {
+		ran, err := suite.app.jobs.RunOnce(context.Background())
+		suite.Require().Nil(err)
+		if !ran {
+			return
+		}
+	}
+}
+
+func (suite *ControllerTestSuite) login(email, password string) api.TokenResponse {
+	reqBody := fmt.Sprintf(`{"email": %q, "password": %q}`, email, password)
+	w := suite.requestWithToken("", http.MethodPost, "/api/auth/login", strings.NewReader(reqBody))
+	var tokens api.TokenResponse
+	_ = json.NewDecoder(w.Body).Decode(&tokens)
+	return tokens
+}
+
+func (suite *ControllerTestSuite) TestRegisterAndLogin() {
 	t := suite.T()
-	req, _ := http.NewRequest("GET", "/api/bookmarks", nil)
+	reqBody := `{"name": "Alice", "email": "alice@example.com", "password": "alice-secret"}`
+
+	w := suite.requestWithToken("", http.MethodPost, "/api/auth/register", strings.NewReader(reqBody))
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var user domain.User
+	err := json.NewDecoder(w.Body).Decode(&user)
+	assert.Nil(t, err)
+	assert.NotZero(t, user.ID)
+	assert.Equal(t, "alice@example.com", user.Email)
+	assert.NotContains(t, w.Body.String(), "password")
+
+	w = suite.requestWithToken("", http.MethodPost, "/api/auth/register", strings.NewReader(reqBody))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = suite.requestWithToken("", http.MethodPost, "/api/auth/login",
+		strings.NewReader(`{"email": "alice@example.com", "password": "alice-secret"}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var tokens api.TokenResponse
+	assert.Nil(t, json.NewDecoder(w.Body).Decode(&tokens))
+	assert.NotEmpty(t, tokens.AccessToken)
+	assert.NotEmpty(t, tokens.RefreshToken)
+	assert.Equal(t, "Bearer", tokens.TokenType)
+
+	w = suite.requestWithToken("", http.MethodPost, "/api/auth/login",
+		strings.NewReader(`{"email": "alice@example.com", "password": "wrong-password"}`))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func (suite *ControllerTestSuite) TestRefreshTokenRotation() {
+	t := suite.T()
+	tokens := suite.login(demoEmail, demoPassword)
+	refresh := func(refreshToken string) *httptest.ResponseRecorder {
+		reqBody := fmt.Sprintf(`{"refresh_token": %q}`, refreshToken)
+		return suite.requestWithToken("", http.MethodPost, "/api/auth/refresh", strings.NewReader(reqBody))
+	}
+
+	w := refresh(tokens.RefreshToken)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var rotated api.TokenResponse
+	assert.Nil(t, json.NewDecoder(w.Body).Decode(&rotated))
+	assert.NotEqual(t, tokens.RefreshToken, rotated.RefreshToken)
+
+	w = suite.requestWithToken(rotated.AccessToken, http.MethodGet, "/api/bookmarks", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// reusing a rotated token revokes the whole token family
+	w = refresh(tokens.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = refresh(rotated.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func (suite *ControllerTestSuite) TestAPIKeys() {
+	t := suite.T()
+	createKey := func(scope string) api.CreatedAPIKey {
+		reqBody := fmt.Sprintf(`{"label": "ci %s", "scope": %q}`, scope, scope)
+		w := suite.request(http.MethodPost, "/api/keys", strings.NewReader(reqBody))
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var key api.CreatedAPIKey
+		assert.Nil(t, json.NewDecoder(w.Body).Decode(&key))
+		return key
+	}
+	readKey := createKey(domain.ScopeRead)
+	writeKey := createKey(domain.ScopeReadWrite)
+	assert.True(t, strings.HasPrefix(readKey.Key, readKey.Prefix))
+
+	w := suite.requestWithAuthorization("ApiKey "+readKey.Key, http.MethodGet, "/api/bookmarks", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	reqBody := `{"title": "Posted from CI", "url": "https://example.com/ci"}`
+	w = suite.requestWithAuthorization("ApiKey "+readKey.Key, http.MethodPost, "/api/bookmarks", strings.NewReader(reqBody))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = suite.requestWithAuthorization("ApiKey "+writeKey.Key, http.MethodPost, "/api/bookmarks", strings.NewReader(reqBody))
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	// keys can only be managed with a bearer token
+	w = suite.requestWithAuthorization("ApiKey "+writeKey.Key, http.MethodGet, "/api/keys", nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = suite.request(http.MethodGet, "/api/keys", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), readKey.Key)
+	var keys []domain.APIKey
+	assert.Nil(t, json.NewDecoder(w.Body).Decode(&keys))
+	assert.Len(t, keys, 2)
+	assert.NotNil(t, keys[0].LastUsedDate)
+
+	w = suite.request(http.MethodDelete, fmt.Sprintf("/api/keys/%d", readKey.ID), nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = suite.requestWithAuthorization("ApiKey "+readKey.Key, http.MethodGet, "/api/bookmarks", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func (suite *ControllerTestSuite) TestAPIRequiresAuthentication() {
+	t := suite.T()
+
+	w := suite.requestWithToken("", http.MethodGet, "/api/bookmarks", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = suite.requestWithToken("not-a-valid-token", http.MethodGet, "/api/bookmarks", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func (suite *ControllerTestSuite) TestBookmarksAreScopedToOwner() {
+	t := suite.T()
+	reqBody := `{"name": "Bob", "email": "bob@example.com", "password": "bob-secret"}`
+	w := suite.requestWithToken("", http.MethodPost, "/api/auth/register", strings.NewReader(reqBody))
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	bobToken := suite.login("bob@example.com", "bob-secret").AccessToken
+	w = suite.requestWithToken(bobToken, http.MethodGet, "/api/bookmarks", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response api.BookmarksPage
+	assert.Nil(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Zero(t, response.Total)
+
+	w = suite.requestWithToken(bobToken, http.MethodGet, "/api/bookmarks/3", nil)
+	assert.NotEqual(t, http.StatusOK, w.Code)
+
+	suite.requestWithToken(bobToken, http.MethodDelete, "/api/bookmarks/3", nil)
+	w = suite.request(http.MethodGet, "/api/bookmarks/3", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func (suite *ControllerTestSuite) TestBookmarkRepositoryContract() {
+	t := suite.T()
+	owners := 0
+	testsupport.RunBookmarkRepositoryContract(t, suite.app.repos.bookmarks, func() int {
+		owners++
+		user, err := suite.app.repos.users.Create(context.Background(), domain.User{Name: "Owner",
+			Email: fmt.Sprintf("contract-owner-%d@example.com", owners), PasswordHash: "-", CreatedDate: time.Now()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return user.ID
+	})
+}
+
+func (suite *ControllerTestSuite) TestExportBookmarks() {
+	t := suite.T()
+	for format, contentType := range map[string]string{
+		"html": "text/html",
+		"json": "application/json",
+		"csv":  "text/csv",
+		"atom": "application/atom+xml",
+	} {
+		w := suite.request(http.MethodGet, "/api/bookmarks/export?format="+format, nil)
+
+		assert.Equal(t, http.StatusOK, w.Code, format)
+		assert.Contains(t, w.Header().Get("Content-Type"), contentType)
+		assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment; filename=\"bookmarks-")
+		assert.Contains(t, w.Body.String(), "https://www.marcobehler.com/guides/java-microservices-a-practical-guide")
+	}
+
+	w := suite.request(http.MethodGet, "/api/bookmarks/export?format=json", nil)
+	var exported []domain.Bookmark
+	assert.Nil(t, json.NewDecoder(w.Body).Decode(&exported))
+	assert.NotEmpty(t, exported)
+	assert.Equal(t, []string{"docker"}, exported[0].Tags)
+
+	w = suite.request(http.MethodGet, "/api/bookmarks/export?format=pdf", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func (suite *ControllerTestSuite) TestImportBookmarks() {
+	t := suite.T()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", "bookmarks.html")
+	_, _ = part.Write([]byte(`<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<DL><p>
+    <DT><H3>Reading List</H3>
+    <DL><p>
+        <DT><A HREF="https://go.dev/blog/" ADD_DATE="1700000000">The Go Blog</A>
+        <DT><A HREF="https://go.dev/blog/">The Go Blog again</A>
+        <DT><A HREF="https://www.marcobehler.com/guides/java-microservices-a-practical-guide">Java Microservices</A>
+        <DT><A HREF="javascript:alert(1)">Bookmarklet</A>
+    </DL><p>
+</DL><p>`))
+	_ = form.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, "/api/bookmarks/import", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+suite.demoToken)
 	w := httptest.NewRecorder()
 	suite.router.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
+	var summary api.ImportSummary
+	assert.Nil(t, json.NewDecoder(w.Body).Decode(&summary))
+	assert.Equal(t, 1, summary.Created)
+	assert.Equal(t, 2, summary.Skipped)
+	assert.Equal(t, 1, summary.Failed)
 
-	actualResponseJSON := w.Body.String()
-	assert.NotEqual(t, "[]", actualResponseJSON)
+	w = suite.request(http.MethodGet, "/api/bookmarks?tag=reading+list", nil)
+	var response api.BookmarksPage
+	assert.Nil(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, int64(1), response.Total)
+	assert.Equal(t, "The Go Blog", response.Data[0].Title)
+	assert.Equal(t, int64(1700000000), response.Data[0].CreatedDate.Unix())
 }
 
-func (suite *ControllerTestSuite) TestGetBookmarkByID() {
+func (suite *ControllerTestSuite) TestImportReportsInvalidBookmarks() {
 	t := suite.T()
-	req, _ := http.NewRequest(http.MethodGet, "/api/bookmarks/1", nil)
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", "bookmarks.html")
+	_, _ = fmt.Fprintf(part, `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<DL><p>
+    <DT><A HREF="https://example.com/import/valid" TAGS="imported">Valid</A>
+    <DT><A HREF="https://example.com/import/long-tag" TAGS="%s">Long tag</A>
+</DL><p>`, strings.Repeat("t", 51))
+	_ = form.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, "/api/bookmarks/import", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+suite.demoToken)
 	w := httptest.NewRecorder()
 	suite.router.ServeHTTP(w, req)
 
+	assert.Equal(t, http.StatusOK, w.Code)
+	var summary api.ImportSummary
+	assert.Nil(t, json.NewDecoder(w.Body).Decode(&summary))
+	assert.Equal(t, 1, summary.Created)
+	assert.Equal(t, 1, summary.Failed)
+	assert.Equal(t, "https://example.com/import/long-tag", summary.FailedEntries[0].URL)
+	assert.Contains(t, summary.FailedEntries[0].Reason, "longer than 50 characters")
+}
+
+func (suite *ControllerTestSuite) TestGetAllBookmarks() {
+	t := suite.T()
+	w := suite.request("GET", "/api/bookmarks", nil)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response api.BookmarksPage
+	err := json.NewDecoder(w.Body).Decode(&response)
+
+	assert.Nil(t, err)
+	assert.NotEmpty(t, response.Data)
+	assert.Equal(t, 1, response.Page)
+	assert.Equal(t, int64(len(response.Data)), response.Total)
+	assert.Empty(t, response.Prev)
+}
+
+func (suite *ControllerTestSuite) TestGetBookmarksPage() {
+	t := suite.T()
+	w := suite.request(http.MethodGet, "/api/bookmarks?page=2&size=2&sort=id", nil)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response api.BookmarksPage
+	err := json.NewDecoder(w.Body).Decode(&response)
+
+	assert.Nil(t, err)
+	assert.Len(t, response.Data, 2)
+	assert.Equal(t, 2, response.Page)
+	assert.Less(t, response.Data[0].ID, response.Data[1].ID)
+	assert.Equal(t, "/api/bookmarks?page=1&size=2&sort=id", response.Prev)
+	assert.Equal(t, "/api/bookmarks?page=3&size=2&sort=id", response.Next)
+}
+
+func (suite *ControllerTestSuite) TestGetBookmarksAfterCursor() {
+	t := suite.T()
+	w := suite.request(http.MethodGet, "/api/bookmarks?size=2&sort=id", nil)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var firstPage api.BookmarksPage
+	assert.Nil(t, json.NewDecoder(w.Body).Decode(&firstPage))
+	assert.NotEmpty(t, firstPage.NextCursor)
+
+	w = suite.request(http.MethodGet, "/api/bookmarks?size=2&sort=id&after="+firstPage.NextCursor, nil)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var secondPage api.BookmarksPage
+	assert.Nil(t, json.NewDecoder(w.Body).Decode(&secondPage))
+	assert.Len(t, secondPage.Data, 2)
+	assert.Greater(t, secondPage.Data[0].ID, firstPage.Data[1].ID)
+	assert.Zero(t, secondPage.Page)
+	assert.Empty(t, secondPage.Prev)
+}
+
+func (suite *ControllerTestSuite) TestGetBookmarksInvalidQuery() {
+	t := suite.T()
+	for _, query := range []string{"size=0", "page=-1", "sort=unknown", "after=abc"} {
+		w := suite.request(http.MethodGet, "/api/bookmarks?"+query, nil)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func (suite *ControllerTestSuite) TestSearchBookmarks() {
+	t := suite.T()
+	w := suite.request(http.MethodGet, "/api/bookmarks?q=microservices", nil)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response api.BookmarksPage
+	err := json.NewDecoder(w.Body).Decode(&response)
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), response.Total)
+	assert.Equal(t, "Java Microservices: A Practical Guide", response.Data[0].Title)
+	assert.Greater(t, response.Data[0].Rank, float32(0))
+	assert.Contains(t, response.Data[0].Snippet, "<mark>Microservices</mark>")
+}
+
+func (suite *ControllerTestSuite) TestSortByRelevanceRequiresQuery() {
+	t := suite.T()
+	w := suite.request(http.MethodGet, "/api/bookmarks?sort=-relevance", nil)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func (suite *ControllerTestSuite) TestGetBookmarkByID() {
+	t := suite.T()
+	w := suite.request(http.MethodGet, "/api/bookmarks/1", nil)
+
 	assert.Equal(t, http.StatusOK, w.Code)
 
 	var response domain.Bookmark
@@ -73,6 +488,220 @@ func (suite *ControllerTestSuite) TestGetBookmarkByID() {
 	assert.NotNil(t, response.CreatedDate)
 }
 
+func (suite *ControllerTestSuite) createCollection(name string, parentID *int) domain.Collection {
+	body, _ := json.Marshal(domain.SaveCollectionModel{Name: name, ParentID: parentID})
+	w := suite.request(http.MethodPost, "/api/collections", bytes.NewReader(body))
+	suite.Require().Equal(http.StatusCreated, w.Code)
+	var collection domain.Collection
+	suite.Require().Nil(json.NewDecoder(w.Body).Decode(&collection))
+	return collection
+}
+
+func (suite *ControllerTestSuite) collectionBookmarkIDs(collectionID int) []int {
+	w := suite.request(http.MethodGet, fmt.Sprintf("/api/collections/%d/bookmarks", collectionID), nil)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	var bookmarks []domain.Bookmark
+	suite.Require().Nil(json.NewDecoder(w.Body).Decode(&bookmarks))
+	ids := []int{}
+	for _, b := range bookmarks {
+		ids = append(ids, b.ID)
+	}
+	return ids
+}
+
+func (suite *ControllerTestSuite) auditPage(url string) api.AuditPage {
+	w := suite.request(http.MethodGet, url, nil)
+	suite.Require().Equal(http.StatusOK, w.Code)
+	var page api.AuditPage
+	suite.Require().Nil(json.NewDecoder(w.Body).Decode(&page))
+	return page
+}
+
+func (suite *ControllerTestSuite) TestAuditLog() {
+	t := suite.T()
+	w := suite.request(http.MethodPost, "/api/bookmarks",
+		strings.NewReader(`{"title": "Audited", "url": "https://example.com/audited"}`))
+	suite.Require().Equal(http.StatusCreated, w.Code)
+	var bookmark domain.Bookmark
+	suite.Require().Nil(json.NewDecoder(w.Body).Decode(&bookmark))
+	path := fmt.Sprintf("/api/bookmarks/%d", bookmark.ID)
+	w = suite.request(http.MethodPatch, path, strings.NewReader(`{"title": "Audited again"}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = suite.request(http.MethodDelete, path, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = suite.request(http.MethodPost, fmt.Sprintf("/api/trash/%d/restore", bookmark.ID), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	history := suite.auditPage(path + "/history")
+	var actions []string
+	for _, e := range history.Data {
+		assert.Equal(t, bookmark.ID, e.BookmarkID)
+		actions = append(actions, e.Action)
+	}
+	assert.Equal(t, []string{"restore", "delete", "update", "create"}, actions)
+	assert.Equal(t, domain.AuditChange{From: "Audited", To: "Audited again"}, history.Data[2].Changes["title"])
+	assert.Equal(t, "https://example.com/audited", history.Data[3].Changes["url"].To)
+	assert.Equal(t, domain.AuditChange{To: true}, history.Data[1].Changes["deleted"])
+
+	page := suite.auditPage(fmt.Sprintf("/api/audit?bookmark_id=%d&action=update", bookmark.ID))
+	assert.Len(t, page.Data, 1)
+	page = suite.auditPage(fmt.Sprintf("/api/bookmarks/%d/history?size=3", bookmark.ID))
+	assert.Len(t, page.Data, 3)
+	suite.Require().NotEmpty(page.NextCursor)
+	page = suite.auditPage(fmt.Sprintf("/api/bookmarks/%d/history?after=%s", bookmark.ID, page.NextCursor))
+	assert.Len(t, page.Data, 1)
+	assert.Empty(t, page.NextCursor)
+
+	// X-Forwarded-For is ignored as no proxies are trusted
+	req, _ := http.NewRequest(http.MethodPatch, path, strings.NewReader(`{"title": "Forwarded"}`))
+	req.Header.Set("Authorization", "Bearer "+suite.demoToken)
+	req.Header.Set("Content-Type", api.MergePatchContentType)
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	req.RemoteAddr = "192.0.2.1:41234"
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	page = suite.auditPage(path + "/history?size=1")
+	suite.Require().Len(page.Data, 1)
+	assert.Equal(t, "192.0.2.1", page.Data[0].ClientIP)
+
+	w = suite.request(http.MethodGet, "/api/audit?action=explode", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = suite.request(http.MethodGet, "/api/audit?since=yesterday", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func (suite *ControllerTestSuite) bulk(body string) api.BulkResponse {
+	w := suite.request(http.MethodPost, "/api/bookmarks/bulk", strings.NewReader(body))
+	suite.Require().Equal(http.StatusOK, w.Code)
+	var response api.BulkResponse
+	suite.Require().Nil(json.NewDecoder(w.Body).Decode(&response))
+	return response
+}
+
+func (suite *ControllerTestSuite) TestBulkOperations() {
+	t := suite.T()
+	response := suite.bulk(`{"operations": [
+		{"op": "create", "bookmark": {"title": "Bulk 1", "url": "https://example.com/bulk-1"}},
+		{"op": "create", "bookmark": {"title": "Bulk 2", "url": "https://example.com/bulk-2", "tags": ["old"]}}]}`)
+	assert.Equal(t, 2, response.Succeeded)
+	assert.Equal(t, http.StatusCreated, response.Results[0].Status)
+	first, second := response.Results[0].ID, response.Results[1].ID
+	collection := suite.createCollection("Bulk", nil)
+	operations := fmt.Sprintf(`[
+		{"op": "add-tag", "id": %d, "tag": "Bulk"},
+		{"op": "remove-tag", "id": %d, "tag": "old"},
+		{"op": "move-to-collection", "id": %d, "collection_id": %d},
+		{"op": "delete", "id": 9999}]`, first, second, first, collection.ID)
+
+	// the missing bookmark rolls back the other operations
+	response = suite.bulk(`{"operations": ` + operations + `}`)
+	assert.Equal(t, 0, response.Succeeded)
+	assert.Equal(t, 4, response.Failed)
+	assert.Equal(t, http.StatusFailedDependency, response.Results[0].Status)
+	assert.Equal(t, http.StatusNotFound, response.Results[3].Status)
+	assert.Empty(t, suite.collectionBookmarkIDs(collection.ID))
+
+	response = suite.bulk(`{"mode": "best-effort", "operations": ` + operations + `}`)
+	assert.Equal(t, 3, response.Succeeded)
+	assert.Equal(t, 1, response.Failed)
+	assert.Equal(t, []string{"bulk"}, response.Results[0].Bookmark.Tags)
+	assert.Empty(t, response.Results[1].Bookmark.Tags)
+	assert.Equal(t, http.StatusNotFound, response.Results[3].Status)
+	assert.Equal(t, []int{first}, suite.collectionBookmarkIDs(collection.ID))
+
+	response = suite.bulk(fmt.Sprintf(`{"mode": "best-effort", "operations": [
+		{"op": "update", "id": %d, "version": 99, "bookmark": {"title": "Stale", "url": "https://example.com/bulk-2"}},
+		{"op": "delete", "id": %d},
+		{"op": "create", "bookmark": {"title": "Again", "url": "https://example.com/bulk-1/"}}]}`, second, second))
+	assert.Equal(t, http.StatusPreconditionFailed, response.Results[0].Status)
+	assert.Equal(t, http.StatusOK, response.Results[1].Status)
+	assert.Equal(t, http.StatusConflict, response.Results[2].Status)
+	assert.Equal(t, first, response.Results[2].ExistingID)
+	w := suite.request(http.MethodGet, fmt.Sprintf("/api/bookmarks/%d", second), nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = suite.request(http.MethodPost, "/api/bookmarks/bulk", strings.NewReader(`{"operations": [{"op": "explode"}]}`))
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+}
+
+func (suite *ControllerTestSuite) TestCollections() {
+	t := suite.T()
+	reading := suite.createCollection("Reading list", nil)
+	java := suite.createCollection("Java", &reading.ID)
+	assert.Equal(t, reading.ID, *java.ParentID)
+
+	readingBookmarks := fmt.Sprintf("/api/collections/%d/bookmarks", reading.ID)
+	for _, body := range []string{`{"bookmark_id": 3}`, `{"bookmark_id": 4}`, `{"bookmark_id": 1, "position": 0}`} {
+		w := suite.request(http.MethodPost, readingBookmarks, strings.NewReader(body))
+		assert.Equal(t, http.StatusCreated, w.Code)
+	}
+	assert.Equal(t, []int{1, 3, 4}, suite.collectionBookmarkIDs(reading.ID))
+
+	w := suite.request(http.MethodPost, readingBookmarks, strings.NewReader(`{"bookmark_id": 3}`))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// reorder within the collection
+	w = suite.request(http.MethodPost, readingBookmarks+"/4/move",
+		strings.NewReader(fmt.Sprintf(`{"collection_id": %d, "position": 0}`, reading.ID)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []int{4, 1, 3}, suite.collectionBookmarkIDs(reading.ID))
+
+	w = suite.request(http.MethodPost, readingBookmarks+"/1/move",
+		strings.NewReader(fmt.Sprintf(`{"collection_id": %d}`, java.ID)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = suite.request(http.MethodPost, readingBookmarks+"/3/copy",
+		strings.NewReader(fmt.Sprintf(`{"collection_id": %d}`, java.ID)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []int{4, 3}, suite.collectionBookmarkIDs(reading.ID))
+	assert.Equal(t, []int{1, 3}, suite.collectionBookmarkIDs(java.ID))
+
+	// a collection can not be nested in its own subtree
+	w = suite.request(http.MethodPut, fmt.Sprintf("/api/collections/%d", reading.ID),
+		strings.NewReader(fmt.Sprintf(`{"name": "Reading list", "parent_id": %d}`, java.ID)))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// re-parenting moves the sub-collections and bookmarks to the parent
+	spring := suite.createCollection("Spring", &java.ID)
+	w = suite.request(http.MethodDelete, fmt.Sprintf("/api/collections/%d", java.ID), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []int{4, 3, 1}, suite.collectionBookmarkIDs(reading.ID))
+	w = suite.request(http.MethodGet, fmt.Sprintf("/api/collections/%d", spring.ID), nil)
+	var moved domain.Collection
+	assert.Nil(t, json.NewDecoder(w.Body).Decode(&moved))
+	assert.Equal(t, reading.ID, *moved.ParentID)
+
+	w = suite.request(http.MethodDelete, fmt.Sprintf("/api/collections/%d?mode=cascade", reading.ID), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = suite.request(http.MethodGet, fmt.Sprintf("/api/collections/%d", spring.ID), nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = suite.request(http.MethodGet, "/api/bookmarks/1", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func (suite *ControllerTestSuite) TestBookmarkNotFound() {
+	t := suite.T()
+	w := suite.request(http.MethodGet, "/api/bookmarks/9999", nil)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	var problem api.Problem
+	assert.Nil(t, json.NewDecoder(w.Body).Decode(&problem))
+	assert.Equal(t, http.StatusNotFound, problem.Status)
+	assert.Equal(t, "/api/bookmarks/9999", problem.Instance)
+	assert.Equal(t, w.Header().Get(api.RequestIDHeader), problem.RequestID)
+
+	w = suite.request(http.MethodDelete, "/api/bookmarks/9999", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func (suite *ControllerTestSuite) TestCreateInvalidBookmark() {
+	t := suite.T()
+	w := suite.request(http.MethodPost, "/api/bookmarks", strings.NewReader(`{"url": "ftp://example.com/file"}`))
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+}
+
 func (suite *ControllerTestSuite) TestCreateBookmark() {
 	t := suite.T()
 	reqBody := strings.NewReader(`
@@ -82,9 +711,7 @@ func (suite *ControllerTestSuite) TestCreateBookmark() {
 		}
 	`)
 
-	req, _ := http.NewRequest(http.MethodPost, "/api/bookmarks", reqBody)
-	w := httptest.NewRecorder()
-	suite.router.ServeHTTP(w, req)
+	w := suite.request(http.MethodPost, "/api/bookmarks", reqBody)
 
 	assert.Equal(t, http.StatusCreated, w.Code)
 
@@ -99,6 +726,412 @@ func (suite *ControllerTestSuite) TestCreateBookmark() {
 	assert.Nil(t, response.UpdatedDate)
 }
 
+func (suite *ControllerTestSuite) TestCreateDuplicateBookmark() {
+	t := suite.T()
+	w := suite.request(http.MethodPost, "/api/bookmarks",
+		strings.NewReader(`{"title": "Duplicate", "url": "http://example.com/dup/"}`))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var existing domain.Bookmark
+	assert.Nil(t, json.NewDecoder(w.Body).Decode(&existing))
+
+	w = suite.request(http.MethodPost, "/api/bookmarks",
+		strings.NewReader(`{"title": "Duplicate", "url": "https://Example.com/dup?utm_source=newsletter#top"}`))
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	var response struct {
+		ExistingID int    `json:"existing_id"`
+		Existing   string `json:"existing"`
+	}
+	assert.Nil(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, existing.ID, response.ExistingID)
+	assert.Equal(t, fmt.Sprintf("/api/bookmarks/%d", existing.ID), response.Existing)
+
+	w = suite.request(http.MethodPut, "/api/bookmarks/3",
+		strings.NewReader(`{"title": "Duplicate", "url": "https://example.com/dup"}`))
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func (suite *ControllerTestSuite) TestFindDuplicateBookmarks() {
+	t := suite.T()
+	// the sample bookmarks predate url normalization, so the same url is still accepted
+	w := suite.request(http.MethodPost, "/api/bookmarks",
+		strings.NewReader(`{"title": "Testcontainers", "url": "http://sivalabs.in/2020/02/spring-boot-integration-testing-using-testcontainers-starter"}`))
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	w = suite.request(http.MethodGet, "/api/bookmarks/duplicates", nil)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Data  []api.DuplicateGroup `json:"data"`
+		Total int                  `json:"total"`
+	}
+	assert.Nil(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, 1, response.Total)
+	assert.Equal(t, "https://sivalabs.in/2020/02/spring-boot-integration-testing-using-testcontainers-starter",
+		response.Data[0].NormalizedURL)
+	assert.Len(t, response.Data[0].Bookmarks, 2)
+}
+
+func (suite *ControllerTestSuite) TestCreateBookmarkFetchesMetadata() {
+	t := suite.T()
+	page := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = io.WriteString(w, `<html><head>
+			<title>Metadata test page</title>
+			<meta property="og:description" content="A page describing itself">
+			<link rel="icon" href="/static/icon.png">
+			<link rel="canonical" href="/canonical">
+			</head><body>Hello</body></html>`)
+	}))
+	defer page.Close()
+
+	reqBody := strings.NewReader(fmt.Sprintf(`{"url": %q}`, page.URL+"/article?utm_source=test"))
+	w := suite.request(http.MethodPost, "/api/bookmarks", reqBody)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var response domain.Bookmark
+	assert.Nil(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, response.URL, response.Title)
+
+	suite.runJobs()
+
+	w = suite.request(http.MethodGet, fmt.Sprintf("/api/bookmarks/%d", response.ID), nil)
+	var stored domain.Bookmark
+	assert.Nil(t, json.NewDecoder(w.Body).Decode(&stored))
+	assert.Equal(t, "Metadata test page", stored.Title)
+	assert.Equal(t, "A page describing itself", stored.Description)
+	assert.Equal(t, page.URL+"/static/icon.png", stored.FaviconURL)
+	assert.Equal(t, page.URL+"/canonical", stored.CanonicalURL)
+}
+
+func (suite *ControllerTestSuite) TestUpdateBookmarkURLFetchesMetadata() {
+	t := suite.T()
+	page := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = fmt.Fprintf(w, `<html><head><title>Page %[1]s</title>
+			<meta name="description" content="Describing %[1]s"></head><body><p>Content of %[1]s</p></body></html>`,
+			r.URL.Path)
+	}))
+	defer page.Close()
+	w := suite.request(http.MethodPost, "/api/bookmarks", strings.NewReader(fmt.Sprintf(`{"url": %q}`, page.URL+"/old")))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var created domain.Bookmark
+	assert.Nil(t, json.NewDecoder(w.Body).Decode(&created))
+	suite.runJobs()
+
+	reqBody := strings.NewReader(fmt.Sprintf(`{"title": "Moved", "url": %q}`, page.URL+"/new"))
+	w = suite.request(http.MethodPut, fmt.Sprintf("/api/bookmarks/%d", created.ID), reqBody)
+	assert.Equal(t, http.StatusOK, w.Code)
+	suite.runJobs()
+
+	w = suite.request(http.MethodGet, fmt.Sprintf("/api/bookmarks/%d", created.ID), nil)
+	var stored domain.Bookmark
+	assert.Nil(t, json.NewDecoder(w.Body).Decode(&stored))
+	assert.Equal(t, "Moved", stored.Title)
+	assert.Equal(t, "Describing /new", stored.Description)
+	archived := suite.request(http.MethodGet, fmt.Sprintf("/api/bookmarks/%d/archive", created.ID), nil)
+	assert.Equal(t, http.StatusOK, archived.Code)
+	assert.Contains(t, archived.Body.String(), "<p>Content of /new</p>")
+}
+
+func (suite *ControllerTestSuite) TestFetchMetadataKeepsConcurrentChanges() {
+	t := suite.T()
+	var bookmarkID int
+	var rename sync.Once
+	page := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the user renames the bookmark while its page is fetched for the metadata, the first job
+		rename.Do(func() {
+			reqBody := strings.NewReader(fmt.Sprintf(`{"title": "Renamed", "url": %q}`, "http://"+r.Host+r.URL.Path))
+			renamed := suite.request(http.MethodPut, fmt.Sprintf("/api/bookmarks/%d", bookmarkID), reqBody)
+			assert.Equal(t, http.StatusOK, renamed.Code)
+		})
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = io.WriteString(w, `<html><head><title>Page title</title></head></html>`)
+	}))
+	defer page.Close()
+
+	w := suite.request(http.MethodPost, "/api/bookmarks", strings.NewReader(fmt.Sprintf(`{"url": %q}`, page.URL+"/race")))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var response domain.Bookmark
+	assert.Nil(t, json.NewDecoder(w.Body).Decode(&response))
+	bookmarkID = response.ID
+
+	suite.runJobs()
+
+	w = suite.request(http.MethodGet, fmt.Sprintf("/api/bookmarks/%d", bookmarkID), nil)
+	var stored domain.Bookmark
+	assert.Nil(t, json.NewDecoder(w.Body).Decode(&stored))
+	assert.Equal(t, "Renamed", stored.Title)
+}
+
+func (suite *ControllerTestSuite) TestFilterBrokenLinks() {
+	t := suite.T()
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/gone" {
+			http.NotFound(w, r)
+		}
+	}))
+	defer site.Close()
+	for _, path := range []string{"/alive", "/gone"} {
+		reqBody := strings.NewReader(fmt.Sprintf(`{"title": "Link check", "url": %q}`, site.URL+path))
+		w := suite.request(http.MethodPost, "/api/bookmarks", reqBody)
+		assert.Equal(t, http.StatusCreated, w.Code)
+	}
+
+	for {
+		n, err := suite.app.linkChecker.RunOnce(context.Background())
+		suite.Require().Nil(err)
+		if n == 0 {
+			break
+		}
+	}
+
+	w := suite.request(http.MethodGet, "/api/bookmarks?status=broken&size=100", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response api.BookmarksPage
+	assert.Nil(t, json.NewDecoder(w.Body).Decode(&response))
+	var urls []string
+	for _, b := range response.Data {
+		assert.Equal(t, 1, b.LinkFailures)
+		urls = append(urls, b.URL)
+	}
+	assert.Contains(t, urls, site.URL+"/gone")
+	assert.NotContains(t, urls, site.URL+"/alive")
+
+	w = suite.request(http.MethodGet, "/api/bookmarks?status=dead", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func (suite *ControllerTestSuite) TestLinkCheckOfChangedURLIsDropped() {
+	t := suite.T()
+	ctx := context.Background()
+	b, err := suite.app.repos.bookmarks.Create(ctx, domain.Bookmark{OwnerID: 1, Title: "Moved",
+		URL: "https://example.com/moved-here", CreatedDate: time.Now()})
+	suite.Require().Nil(err)
+
+	// the url was changed while the old one was checked
+	check := domain.LinkCheck{BookmarkID: b.ID, URL: "https://example.com/moved-away", CheckedDate: time.Now(),
+		Failed: true}
+	suite.Require().Nil(suite.app.repos.linkChecks.Record(ctx, check))
+	found, err := suite.app.repos.bookmarks.FindByID(ctx, 1, b.ID)
+	suite.Require().Nil(err)
+	assert.Nil(t, found.LinkCheckedDate)
+	assert.Equal(t, 0, found.LinkFailures)
+
+	check.URL = b.URL
+	suite.Require().Nil(suite.app.repos.linkChecks.Record(ctx, check))
+	found, err = suite.app.repos.bookmarks.FindByID(ctx, 1, b.ID)
+	suite.Require().Nil(err)
+	assert.NotNil(t, found.LinkCheckedDate)
+	assert.Equal(t, 1, found.LinkFailures)
+}
+
+func (suite *ControllerTestSuite) TestArchiveBookmark() {
+	t := suite.T()
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = io.WriteString(w, `<html><head><title>Archive me</title><script>track()</script></head>
+			<body><p>Worth keeping</p></body></html>`)
+	}))
+	defer site.Close()
+	reqBody := strings.NewReader(fmt.Sprintf(`{"url": %q}`, site.URL+"/keep"))
+	w := suite.request(http.MethodPost, "/api/bookmarks", reqBody)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var bookmark domain.Bookmark
+	assert.Nil(t, json.NewDecoder(w.Body).Decode(&bookmark))
+
+	suite.runJobs()
+
+	w = suite.request(http.MethodGet, fmt.Sprintf("/api/bookmarks/%d/archive", bookmark.ID), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "<p>Worth keeping</p>")
+	assert.NotContains(t, w.Body.String(), "track()")
+	assert.Contains(t, w.Header().Get("Content-Security-Policy"), "sandbox")
+	assert.Equal(t, fmt.Sprint(w.Body.Len()), w.Header().Get("Content-Length"))
+
+	w = suite.request(http.MethodGet, "/api/bookmarks/1/archive", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func (suite *ControllerTestSuite) TestSearchPageContent() {
+	t := suite.T()
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = io.WriteString(w, `<html><head><title>Home brewing</title></head><body>
+			<nav>Zymurgy shop</nav>
+			<article><p>Zymurgy is the branch of chemistry dealing with fermentation, as in brewing beer.</p></article>
+			</body></html>`)
+	}))
+	defer site.Close()
+	reqBody := strings.NewReader(fmt.Sprintf(`{"title": "Brewing notes", "url": %q}`, site.URL+"/brewing"))
+	w := suite.request(http.MethodPost, "/api/bookmarks", reqBody)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	suite.runJobs()
+
+	w = suite.request(http.MethodGet, "/api/bookmarks?q=fermentation", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response api.BookmarksPage
+	assert.Nil(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Len(t, response.Data, 1)
+	assert.Equal(t, "Brewing notes", response.Data[0].Title)
+	assert.Contains(t, response.Data[0].Snippet, "<mark>fermentation</mark>")
+}
+
+func (suite *ControllerTestSuite) TestGetJobs() {
+	t := suite.T()
+	reqBody := strings.NewReader(`{"title": "Unreachable", "url": "http://127.0.0.1:1/unreachable"}`)
+	w := suite.request(http.MethodPost, "/api/bookmarks", reqBody)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	suite.runJobs()
+
+	w = suite.request(http.MethodGet, "/api/jobs?status=pending", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var jobs []domain.Job
+	assert.Nil(t, json.NewDecoder(w.Body).Decode(&jobs))
+	assert.GreaterOrEqual(t, len(jobs), 2)
+	// newest first, creating a bookmark fetches its metadata and then archives it
+	assert.Equal(t, "archive_page", jobs[0].Kind)
+	assert.Equal(t, "fetch_metadata", jobs[1].Kind)
+	assert.Equal(t, 1, jobs[1].Attempts)
+	assert.NotEmpty(t, jobs[1].LastError)
+
+	w = suite.request(http.MethodGet, fmt.Sprintf("/api/jobs/%d", jobs[0].ID), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = suite.request(http.MethodGet, "/api/jobs?status=unknown", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func (suite *ControllerTestSuite) TestCreateBookmarkWithTags() {
+	t := suite.T()
+	reqBody := strings.NewReader(`
+		{
+			"title": "Go testing tips",
+			"url":   "https://example.com/go-testing",
+			"tags":  ["golang", " Testing ", "GoLang"]
+		}
+	`)
+
+	w := suite.request(http.MethodPost, "/api/bookmarks", reqBody)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var response domain.Bookmark
+	err := json.NewDecoder(w.Body).Decode(&response)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"golang", "testing"}, response.Tags)
+}
+
+func (suite *ControllerTestSuite) TestFilterBookmarksByTags() {
+	t := suite.T()
+	w := suite.request(http.MethodGet, "/api/bookmarks?tag=spring-boot&tag=testing", nil)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response api.BookmarksPage
+	assert.Nil(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.NotEmpty(t, response.Data)
+	for _, b := range response.Data {
+		assert.Contains(t, b.Tags, "spring-boot")
+		assert.Contains(t, b.Tags, "testing")
+	}
+
+	w = suite.request(http.MethodGet, "/api/bookmarks?tag=docker&tag=ci&tag_mode=any", nil)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	response = api.BookmarksPage{}
+	assert.Nil(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, int64(2), response.Total)
+}
+
+func (suite *ControllerTestSuite) TestGetTags() {
+	t := suite.T()
+	w := suite.request(http.MethodGet, "/api/tags", nil)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response []domain.Tag
+	err := json.NewDecoder(w.Body).Decode(&response)
+
+	assert.Nil(t, err)
+	assert.NotEmpty(t, response)
+	assert.Equal(t, "java", response[0].Name)
+	assert.GreaterOrEqual(t, response[0].Count, 4)
+}
+
+func (suite *ControllerTestSuite) TestTrashAndRestoreBookmark() {
+	t := suite.T()
+	createBookmark := func() domain.Bookmark {
+		reqBody := strings.NewReader(`{"title": "Trash me", "url": "https://example.com/trash", "tags": ["trash"]}`)
+		w := suite.request(http.MethodPost, "/api/bookmarks", reqBody)
+		suite.Require().Equal(http.StatusCreated, w.Code)
+		var bookmark domain.Bookmark
+		suite.Require().Nil(json.NewDecoder(w.Body).Decode(&bookmark))
+		return bookmark
+	}
+	trashIDs := func() []int {
+		w := suite.request(http.MethodGet, "/api/trash", nil)
+		suite.Require().Equal(http.StatusOK, w.Code)
+		var response struct {
+			Data []domain.Bookmark `json:"data"`
+		}
+		suite.Require().Nil(json.NewDecoder(w.Body).Decode(&response))
+		ids := []int{}
+		for _, b := range response.Data {
+			assert.NotNil(t, b.DeletedDate)
+			ids = append(ids, b.ID)
+		}
+		return ids
+	}
+	trashed := createBookmark()
+	path := fmt.Sprintf("/api/bookmarks/%d", trashed.ID)
+	restorePath := fmt.Sprintf("/api/trash/%d/restore", trashed.ID)
+
+	w := suite.request(http.MethodDelete, path, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = suite.request(http.MethodGet, path, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = suite.request(http.MethodDelete, path, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, trashIDs(), trashed.ID)
+
+	// the url is free to be bookmarked again, which keeps the trashed bookmark from being restored
+	again := createBookmark()
+	w = suite.request(http.MethodPost, restorePath, nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = suite.request(http.MethodDelete, fmt.Sprintf("/api/bookmarks/%d", again.ID), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = suite.request(http.MethodPost, restorePath, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var restored domain.Bookmark
+	assert.Nil(t, json.NewDecoder(w.Body).Decode(&restored))
+	assert.Nil(t, restored.DeletedDate)
+	assert.Equal(t, []string{"trash"}, restored.Tags)
+	w = suite.request(http.MethodGet, path, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, trashIDs(), trashed.ID)
+	w = suite.request(http.MethodPost, restorePath, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = suite.request(http.MethodDelete, path, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	time.Sleep(5 * time.Millisecond)
+	for {
+		n, err := suite.app.trashPurger.RunOnce(context.Background())
+		suite.Require().Nil(err)
+		if n == 0 {
+			break
+		}
+	}
+	assert.Empty(t, trashIDs())
+	w = suite.request(http.MethodPost, restorePath, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
 func (suite *ControllerTestSuite) TestUpdateBookmark() {
 	t := suite.T()
 	reqBody := strings.NewReader(`
@@ -108,9 +1141,7 @@ func (suite *ControllerTestSuite) TestUpdateBookmark() {
 		}
 	`)
 
-	req, _ := http.NewRequest(http.MethodPut, "/api/bookmarks/1", reqBody)
-	w := httptest.NewRecorder()
-	suite.router.ServeHTTP(w, req)
+	w := suite.request(http.MethodPut, "/api/bookmarks/1", reqBody)
 
 	assert.Equal(t, http.StatusOK, w.Code)
 
@@ -125,12 +1156,106 @@ func (suite *ControllerTestSuite) TestUpdateBookmark() {
 	assert.NotNil(t, response.UpdatedDate)
 }
 
-func (suite *ControllerTestSuite) TestDeleteBookmark() {
+func (suite *ControllerTestSuite) TestPatchBookmark() {
 	t := suite.T()
+	w := suite.request(http.MethodPost, "/api/bookmarks",
+		strings.NewReader(`{"title": "Patch me", "url": "https://example.com/patch", "tags": ["go"]}`))
+	suite.Require().Equal(http.StatusCreated, w.Code)
+	var created domain.Bookmark
+	assert.Nil(t, json.NewDecoder(w.Body).Decode(&created))
+	path := fmt.Sprintf("/api/bookmarks/%d", created.ID)
 
-	req, _ := http.NewRequest(http.MethodDelete, "/api/bookmarks/2", nil)
-	w := httptest.NewRecorder()
+	w = suite.request(http.MethodGet, path, nil)
+	assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+
+	w = suite.requestIfMatch(`"1"`, http.MethodPatch, path, strings.NewReader(`{"title": "Patched", "tags": null}`))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+	var patched domain.Bookmark
+	assert.Nil(t, json.NewDecoder(w.Body).Decode(&patched))
+	assert.Equal(t, "Patched", patched.Title)
+	assert.Equal(t, "https://example.com/patch", patched.URL)
+	assert.Empty(t, patched.Tags)
+	assert.Equal(t, 2, patched.Version)
+
+	// writes based on the first version are stale now
+	w = suite.requestIfMatch(`"1"`, http.MethodPatch, path, strings.NewReader(`{"title": "Stale"}`))
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	w = suite.requestIfMatch(`"1"`, http.MethodPut, path,
+		strings.NewReader(`{"title": "Stale", "url": "https://example.com/patch"}`))
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+	w = suite.request(http.MethodPatch, path, strings.NewReader(`{"title": null}`))
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	w = suite.request(http.MethodPatch, path, strings.NewReader(`{"version": 7}`))
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	w = suite.request(http.MethodPatch, "/api/bookmarks/9999", strings.NewReader(`{"title": "Missing"}`))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	for _, contentType := range []string{"", "application/json", "text/plain"} {
+		req, _ := http.NewRequest(http.MethodPatch, path, strings.NewReader(`{"title": "Not a merge patch"}`))
+		req.Header.Set("Authorization", "Bearer "+suite.demoToken)
+		req.Header.Set("Content-Type", contentType)
+		w = httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code, contentType)
+	}
+	req, _ := http.NewRequest(http.MethodPatch, path, strings.NewReader(`{"title": "Merge patch"}`))
+	req.Header.Set("Authorization", "Bearer "+suite.demoToken)
+	req.Header.Set("Content-Type", "Application/Merge-Patch+JSON; charset=utf-8")
+	w = httptest.NewRecorder()
 	suite.router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func (suite *ControllerTestSuite) TestUpdateMissingBookmark() {
+	t := suite.T()
+	reqBody := strings.NewReader(`{"title": "Missing", "url": "https://example.com/missing"}`)
+
+	w := suite.request(http.MethodPut, "/api/bookmarks/9999", reqBody)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func (suite *ControllerTestSuite) TestInTxRollsBackOnError() {
+	t := suite.T()
+	ctx := context.Background()
+	bookmarks, jobsRepo := suite.app.repos.bookmarks, suite.app.repos.jobs
+	created, err := bookmarks.Create(ctx, domain.Bookmark{OwnerID: 1, Title: "Before", URL: "https://example.com/tx",
+		CreatedDate: time.Now()})
+	suite.Require().Nil(err)
+	failure := errors.New("failure after the writes")
+
+	err = bookmarks.InTx(ctx, func(ctx context.Context) error {
+		updated := created
+		updated.Title = "After"
+		if _, err := bookmarks.Update(ctx, updated); err != nil {
+			return err
+		}
+		if _, err := jobsRepo.Enqueue(ctx, domain.Job{UserID: 1, Kind: "rolled_back", RunAt: time.Now(),
+			CreatedDate: time.Now()}); err != nil {
+			return err
+		}
+		return failure
+	})
+
+	assert.ErrorIs(t, err, failure)
+	found, err := bookmarks.FindByID(ctx, 1, created.ID)
+	suite.Require().Nil(err)
+	assert.Equal(t, "Before", found.Title)
+	assert.Equal(t, created.Version, found.Version)
+	jobs, err := jobsRepo.FindAll(ctx, 1, "", 100)
+	suite.Require().Nil(err)
+	for _, j := range jobs {
+		assert.NotEqual(t, "rolled_back", j.Kind)
+	}
+}
+
+func (suite *ControllerTestSuite) TestDeleteBookmark() {
+	t := suite.T()
+
+	w := suite.request(http.MethodDelete, "/api/bookmarks/2", nil)
 
 	assert.Equal(t, http.StatusOK, w.Code)
 }
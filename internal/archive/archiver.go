@@ -0,0 +1,339 @@
+// Package archive saves readable offline copies of bookmarked pages.
+package archive
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/httpclient"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/net/html/charset"
+)
+
+const (
+	DefaultTimeout  = 30 * time.Second
+	DefaultMaxBytes = 10 << 20
+)
+
+var (
+	ErrNotHTML  = errors.New("page is not an HTML document")
+	ErrTooLarge = errors.New("page exceeds the archive size limit")
+)
+
+// cssURL matches the url() references of a style sheet.
+var cssURL = regexp.MustCompile(`url\(\s*['"]?([^'")]+?)['"]?\s*\)`)
+
+// removedElements are dropped from archives as they run code or embed other documents.
+var removedElements = map[atom.Atom]bool{
+	atom.Script: true,
+	atom.Iframe: true,
+	atom.Frame:  true,
+	atom.Object: true,
+	atom.Embed:  true,
+	atom.Applet: true,
+	atom.Base:   true,
+}
+
+type Options struct {
+	Timeout time.Duration
+	// MaxBytes limits the size of the page, inlined resources included.
+	// Resources not fitting anymore are left linked.
+	MaxBytes int64
+	// InlineResources embeds style sheets and images into the archived page.
+	InlineResources      bool
+	AllowPrivateNetworks bool
+}
+
+type Archiver struct {
+	client *http.Client
+	opts   Options
+}
+
+func NewArchiver(opts Options) *Archiver {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = DefaultMaxBytes
+	}
+	client := httpclient.New(httpclient.Options{
+		Timeout:              opts.Timeout,
+		AllowPrivateNetworks: opts.AllowPrivateNetworks,
+	})
+	return &Archiver{client: client, opts: opts}
+}
+
+// Archive downloads the page and returns it as a single HTML document without
+// scripts, linking or embedding the resources it refers to.
+func (a *Archiver) Archive(ctx context.Context, pageURL string) ([]byte, error) {
+	resp, err := a.get(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	contentType := resp.Header.Get("Content-Type")
+	if contentType != "" && !strings.Contains(contentType, "html") {
+		return nil, ErrNotHTML
+	}
+	body, err := readLimited(resp.Body, a.opts.MaxBytes)
+	if err != nil {
+		return nil, err
+	}
+	r, err := charset.NewReader(bytes.NewReader(body), contentType)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := html.ParseWithOptions(r, html.ParseOptionEnableScripting(false))
+	if err != nil {
+		return nil, err
+	}
+
+	base := resp.Request.URL
+	if href, ok := findBase(doc); ok {
+		if u, err := base.Parse(href); err == nil {
+			base = u
+		}
+	}
+	sanitize(doc)
+	if a.opts.InlineResources {
+		budget := a.opts.MaxBytes - int64(len(body))
+		a.inline(ctx, doc, base, &budget)
+	}
+	setBase(doc, base)
+
+	var out bytes.Buffer
+	if err = html.Render(&out, doc); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func (a *Archiver) get(ctx context.Context, resourceURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, resourceURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", httpclient.UserAgent)
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("fetching %s: unexpected status %s", resourceURL, resp.Status)
+	}
+	return resp, nil
+}
+
+// fetch downloads a resource of at most budget bytes whose media type starts with mediaPrefix.
+func (a *Archiver) fetch(ctx context.Context, resourceURL, mediaPrefix string, budget int64) ([]byte, string, error) {
+	resp, err := a.get(ctx, resourceURL)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if !strings.HasPrefix(mediaType, mediaPrefix) {
+		return nil, "", fmt.Errorf("unexpected content type %q of %s", mediaType, resourceURL)
+	}
+	data, err := readLimited(resp.Body, budget)
+	return data, mediaType, err
+}
+
+// inline replaces linked style sheets and images by embedded copies while they fit in the budget.
+func (a *Archiver) inline(ctx context.Context, n *html.Node, base *url.URL, budget *int64) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+		switch c.DataAtom {
+		case atom.Link:
+			if !hasToken(attr(c, "rel"), "stylesheet") || *budget <= 0 {
+				break
+			}
+			u, err := base.Parse(attr(c, "href"))
+			if err != nil {
+				break
+			}
+			css, _, err := a.fetch(ctx, u.String(), "text/css", *budget)
+			if err != nil {
+				break
+			}
+			*budget -= int64(len(css))
+			text := a.inlineCSS(ctx, string(css), u, budget)
+			style := &html.Node{Type: html.ElementNode, Data: "style", DataAtom: atom.Style}
+			style.AppendChild(&html.Node{Type: html.TextNode, Data: strings.ReplaceAll(text, "</", `<\/`)})
+			n.InsertBefore(style, c)
+			n.RemoveChild(c)
+			c = style
+		case atom.Style:
+			if c.FirstChild != nil && c.FirstChild.Type == html.TextNode {
+				c.FirstChild.Data = a.inlineCSS(ctx, c.FirstChild.Data, base, budget)
+			}
+		case atom.Img:
+			u, err := base.Parse(attr(c, "src"))
+			if err != nil || u.Scheme == "data" {
+				break
+			}
+			if uri, ok := a.dataURI(ctx, u, budget); ok {
+				setAttr(c, "src", uri)
+				removeAttr(c, "srcset")
+			}
+		}
+		a.inline(ctx, c, base, budget)
+	}
+}
+
+// inlineCSS embeds the resources a style sheet refers to, resolved against its URL.
+func (a *Archiver) inlineCSS(ctx context.Context, css string, cssBase *url.URL, budget *int64) string {
+	return cssURL.ReplaceAllStringFunc(css, func(match string) string {
+		ref := cssURL.FindStringSubmatch(match)[1]
+		u, err := cssBase.Parse(strings.TrimSpace(ref))
+		if err != nil || u.Scheme == "data" {
+			return match
+		}
+		if uri, ok := a.dataURI(ctx, u, budget); ok {
+			return `url("` + uri + `")`
+		}
+		return `url("` + u.String() + `")`
+	})
+}
+
+func (a *Archiver) dataURI(ctx context.Context, u *url.URL, budget *int64) (string, bool) {
+	if *budget <= 0 {
+		return "", false
+	}
+	data, mediaType, err := a.fetch(ctx, u.String(), "", *budget)
+	if err != nil || !(strings.HasPrefix(mediaType, "image/") || strings.HasPrefix(mediaType, "font/")) {
+		return "", false
+	}
+	uri := "data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString(data)
+	if int64(len(uri)) > *budget {
+		return "", false
+	}
+	*budget -= int64(len(uri))
+	return uri, true
+}
+
+// sanitize removes scripts, embedded documents and event handler attributes.
+func sanitize(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.ElementNode && removedElements[c.DataAtom] {
+			n.RemoveChild(c)
+			c = next
+			continue
+		}
+		if c.Type == html.ElementNode {
+			attrs := c.Attr[:0]
+			for _, a := range c.Attr {
+				value := strings.ToLower(strings.TrimSpace(a.Val))
+				if strings.HasPrefix(a.Key, "on") || strings.HasPrefix(value, "javascript:") {
+					continue
+				}
+				attrs = append(attrs, a)
+			}
+			c.Attr = attrs
+		}
+		sanitize(c)
+		c = next
+	}
+}
+
+func findBase(n *html.Node) (string, bool) {
+	if n.Type == html.ElementNode && n.DataAtom == atom.Base {
+		if href := attr(n, "href"); href != "" {
+			return href, true
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if href, ok := findBase(c); ok {
+			return href, true
+		}
+	}
+	return "", false
+}
+
+// setBase makes the links left in the archive resolve against the original page.
+func setBase(doc *html.Node, base *url.URL) {
+	head := findElement(doc, atom.Head)
+	if head == nil {
+		return
+	}
+	node := &html.Node{Type: html.ElementNode, Data: "base", DataAtom: atom.Base,
+		Attr: []html.Attribute{{Key: "href", Val: base.String()}}}
+	head.InsertBefore(node, head.FirstChild)
+}
+
+func findElement(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findElement(c, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func setAttr(n *html.Node, key, value string) {
+	for i := range n.Attr {
+		if n.Attr[i].Key == key {
+			n.Attr[i].Val = value
+			return
+		}
+	}
+	n.Attr = append(n.Attr, html.Attribute{Key: key, Val: value})
+}
+
+func removeAttr(n *html.Node, key string) {
+	attrs := n.Attr[:0]
+	for _, a := range n.Attr {
+		if a.Key != key {
+			attrs = append(attrs, a)
+		}
+	}
+	n.Attr = attrs
+}
+
+func hasToken(list, token string) bool {
+	for _, t := range strings.Fields(strings.ToLower(list)) {
+		if t == token {
+			return true
+		}
+	}
+	return false
+}
+
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, ErrTooLarge
+	}
+	return data, nil
+}
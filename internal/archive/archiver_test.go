@@ -0,0 +1,93 @@
+package archive
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const page = `<!doctype html>
+<html>
+<head>
+  <title>Archived</title>
+  <base href="/blog/">
+  <link rel="stylesheet" href="style.css">
+  <script>alert("hi")</script>
+</head>
+<body onload="track()">
+  <p>Hello <a href="next.html">next</a> <a href="javascript:void(0)">js</a></p>
+  <img src="/logo.png" srcset="/logo-2x.png 2x">
+  <iframe src="https://ads.example.com"></iframe>
+</body>
+</html>`
+
+func newSite() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/blog/post":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = w.Write([]byte(page))
+		case "/blog/style.css":
+			w.Header().Set("Content-Type", "text/css")
+			_, _ = w.Write([]byte(`body { background: url('img/bg.png') }`))
+		case "/blog/img/bg.png", "/logo.png":
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write([]byte("\x89PNG"))
+		case "/data.json":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte("{}"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestArchiveInlinesResources(t *testing.T) {
+	site := newSite()
+	defer site.Close()
+	archiver := NewArchiver(Options{InlineResources: true, AllowPrivateNetworks: true})
+
+	data, err := archiver.Archive(context.Background(), site.URL+"/blog/post")
+
+	assert.Nil(t, err)
+	archived := string(data)
+	assert.Contains(t, archived, `<base href="`+site.URL+`/blog/"/>`)
+	assert.Contains(t, archived, `<style>body { background: url("data:image/png;base64,iVBORw==") }</style>`)
+	assert.Contains(t, archived, `<img src="data:image/png;base64,iVBORw=="/>`)
+	assert.Contains(t, archived, `<a href="next.html">next</a>`)
+	assert.NotContains(t, archived, "script")
+	assert.NotContains(t, archived, "iframe")
+	assert.NotContains(t, archived, "onload")
+	assert.NotContains(t, archived, "javascript:")
+	assert.NotContains(t, archived, "srcset")
+}
+
+func TestArchiveWithoutInlining(t *testing.T) {
+	site := newSite()
+	defer site.Close()
+	archiver := NewArchiver(Options{AllowPrivateNetworks: true})
+
+	data, err := archiver.Archive(context.Background(), site.URL+"/blog/post")
+
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), `<link rel="stylesheet" href="style.css"/>`)
+	assert.Contains(t, string(data), `<img src="/logo.png" srcset="/logo-2x.png 2x"/>`)
+}
+
+func TestArchiveRejectsLargeAndNonHTMLPages(t *testing.T) {
+	site := newSite()
+	defer site.Close()
+
+	_, err := NewArchiver(Options{MaxBytes: 64, AllowPrivateNetworks: true}).
+		Archive(context.Background(), site.URL+"/blog/post")
+	assert.ErrorIs(t, err, ErrTooLarge)
+
+	_, err = NewArchiver(Options{AllowPrivateNetworks: true}).Archive(context.Background(), site.URL+"/data.json")
+	assert.ErrorIs(t, err, ErrNotHTML)
+
+	_, err = NewArchiver(Options{AllowPrivateNetworks: true}).Archive(context.Background(), site.URL+"/missing")
+	assert.NotNil(t, err)
+}
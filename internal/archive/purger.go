@@ -0,0 +1,69 @@
+package archive
+
+import (
+	"context"
+	"time"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/domain"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/schedule"
+)
+
+const (
+	DefaultPurgeInterval = time.Hour
+	purgeBatchSize       = 100
+)
+
+// Purger deletes the archives past their retention period and those of deleted bookmarks.
+type Purger struct {
+	repo     domain.ArchiveRepository
+	storage  Storage
+	interval time.Duration
+	logger   *logging.Logger
+	runner   *schedule.Runner
+}
+
+func NewPurger(repo domain.ArchiveRepository, storage Storage, interval time.Duration,
+	logger *logging.Logger) *Purger {
+	if interval <= 0 {
+		interval = DefaultPurgeInterval
+	}
+	return &Purger{repo: repo, storage: storage, interval: interval, logger: logger}
+}
+
+// Start purges archives right away and then every interval until Stop is called.
+func (p *Purger) Start() {
+	p.runner = schedule.Every(p.interval, func(ctx context.Context) {
+		for {
+			n, err := p.RunOnce(ctx)
+			if err != nil {
+				p.logger.Errorf("Error while purging archives: %v", err)
+			}
+			if n < purgeBatchSize || err != nil {
+				return
+			}
+		}
+	})
+}
+
+func (p *Purger) Stop(ctx context.Context) error {
+	return p.runner.Stop(ctx)
+}
+
+// RunOnce purges a batch of archives and returns how many were deleted.
+func (p *Purger) RunOnce(ctx context.Context) (int, error) {
+	archives, err := p.repo.FindPurgeable(ctx, time.Now(), purgeBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	for i, a := range archives {
+		// the stored copy goes first so that a failure leaves the row to retry with
+		if err = p.storage.Delete(ctx, a.StorageKey); err != nil {
+			return i, err
+		}
+		if err = p.repo.Delete(ctx, a.BookmarkID); err != nil {
+			return i, err
+		}
+	}
+	return len(archives), nil
+}
@@ -0,0 +1,34 @@
+package archive
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalStorage(t *testing.T) {
+	storage, err := NewLocalStorage(t.TempDir())
+	assert.Nil(t, err)
+	ctx := context.Background()
+
+	n, err := storage.Put(ctx, "1/2.html", strings.NewReader("<p>archived</p>"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(15), n)
+
+	r, err := storage.Open(ctx, "1/2.html")
+	assert.Nil(t, err)
+	content, _ := io.ReadAll(r)
+	_ = r.Close()
+	assert.Equal(t, "<p>archived</p>", string(content))
+
+	assert.Nil(t, storage.Delete(ctx, "1/2.html"))
+	assert.Nil(t, storage.Delete(ctx, "1/2.html"))
+	_, err = storage.Open(ctx, "1/2.html")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = storage.Put(ctx, "../escape.html", strings.NewReader(""))
+	assert.NotNil(t, err)
+}
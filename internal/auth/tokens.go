@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/config"
+)
+
+const defaultAccessTokenTTL = 15 * time.Minute
+
+// placeholderSecret is the example secret that earlier versions shipped in .env,
+// it is public and must never be used to sign tokens.
+const placeholderSecret = "change-me-in-production-to-a-long-random-secret"
+
+var ErrInvalidToken = errors.New("invalid token")
+
+// TokenIssuer issues and verifies the JWT access tokens used by the API.
+type TokenIssuer struct {
+	method    jwt.SigningMethod
+	signKey   any
+	verifyKey any
+	issuer    string
+	ttl       time.Duration
+}
+
+func NewTokenIssuer(cfg config.AppConfig) (*TokenIssuer, error) {
+	t := &TokenIssuer{issuer: cfg.JwtIssuer, ttl: cfg.JwtAccessTokenTTL}
+	if t.ttl <= 0 {
+		t.ttl = defaultAccessTokenTTL
+	}
+	switch cfg.JwtAlgorithm {
+	case "", "HS256":
+		if len(cfg.JwtSecret) < 32 {
+			return nil, errors.New("JWT_SECRET must be at least 32 characters long")
+		}
+		if cfg.JwtSecret == placeholderSecret {
+			return nil, errors.New("JWT_SECRET must be changed from the example value")
+		}
+		t.method = jwt.SigningMethodHS256
+		t.signKey = []byte(cfg.JwtSecret)
+		t.verifyKey = t.signKey
+	case "RS256":
+		t.method = jwt.SigningMethodRS256
+		pem, err := os.ReadFile(cfg.JwtPrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading JWT private key: %w", err)
+		}
+		if t.signKey, err = jwt.ParseRSAPrivateKeyFromPEM(pem); err != nil {
+			return nil, fmt.Errorf("parsing JWT private key: %w", err)
+		}
+		if pem, err = os.ReadFile(cfg.JwtPublicKeyFile); err != nil {
+			return nil, fmt.Errorf("reading JWT public key: %w", err)
+		}
+		if t.verifyKey, err = jwt.ParseRSAPublicKeyFromPEM(pem); err != nil {
+			return nil, fmt.Errorf("parsing JWT public key: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported JWT_ALGORITHM %q", cfg.JwtAlgorithm)
+	}
+	return t, nil
+}
+
+// Issue returns a signed access token with the user id as its subject.
+func (t *TokenIssuer) Issue(userID int) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(t.ttl)
+	claims := jwt.RegisteredClaims{
+		Subject:   strconv.Itoa(userID),
+		Issuer:    t.issuer,
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+	}
+	token, err := jwt.NewWithClaims(t.method, claims).SignedString(t.signKey)
+	return token, expiresAt, err
+}
+
+// Verify validates the signature and expiry of an access token and returns its subject.
+func (t *TokenIssuer) Verify(token string) (int, error) {
+	var claims jwt.RegisteredClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (any, error) {
+		return t.verifyKey, nil
+	},
+		jwt.WithValidMethods([]string{t.method.Alg()}),
+		jwt.WithIssuer(t.issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return 0, ErrInvalidToken
+	}
+	return userID, nil
+}
+
+// NewRefreshToken returns a random opaque token together with the hash to store.
+func NewRefreshToken() (token string, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err = rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, HashToken(token), nil
+}
+
+// HashToken hashes opaque tokens so that they are never stored in plain text.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+type contextKey struct{}
+
+// WithUserID returns a copy of ctx carrying the authenticated user id.
+func WithUserID(ctx context.Context, userID int) context.Context {
+	return context.WithValue(ctx, contextKey{}, userID)
+}
+
+// UserID returns the authenticated user id stored in ctx, or 0 if there is none.
+func UserID(ctx context.Context) int {
+	id, _ := ctx.Value(contextKey{}).(int)
+	return id
+}
+
+const apiKeyPrefix = "bk_"
+
+// NewAPIKey returns a random API key, the prefix identifying it in listings
+// and the hash to store.
+func NewAPIKey() (key string, prefix string, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err = rand.Read(b); err != nil {
+		return "", "", "", err
+	}
+	key = apiKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return key, key[:len(apiKeyPrefix)+8], HashToken(key), nil
+}
@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSecret = "a-test-secret-that-is-long-enough!"
+
+func TestIssueAndVerifyHS256(t *testing.T) {
+	issuer, err := NewTokenIssuer(config.AppConfig{JwtSecret: testSecret, JwtIssuer: "test"})
+	assert.Nil(t, err)
+
+	token, expiresAt, err := issuer.Issue(42)
+	assert.Nil(t, err)
+	assert.WithinDuration(t, time.Now().Add(defaultAccessTokenTTL), expiresAt, time.Second)
+
+	userID, err := issuer.Verify(token)
+	assert.Nil(t, err)
+	assert.Equal(t, 42, userID)
+
+	_, err = issuer.Verify(token + "x")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestVerifyRejectsExpiredAndForeignTokens(t *testing.T) {
+	issuer, _ := NewTokenIssuer(config.AppConfig{JwtSecret: testSecret, JwtIssuer: "test"})
+	expired, _ := NewTokenIssuer(config.AppConfig{JwtSecret: testSecret, JwtIssuer: "test"})
+	expired.ttl = -time.Minute
+	token, _, _ := expired.Issue(1)
+
+	_, err := issuer.Verify(token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	other, _ := NewTokenIssuer(config.AppConfig{JwtSecret: testSecret, JwtIssuer: "someone-else"})
+	token, _, _ = other.Issue(1)
+	_, err = issuer.Verify(token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestIssueAndVerifyRS256(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	dir := t.TempDir()
+	privateKeyFile := filepath.Join(dir, "private.pem")
+	publicKeyFile := filepath.Join(dir, "public.pem")
+	publicKey, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	writePEM(t, privateKeyFile, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key))
+	writePEM(t, publicKeyFile, "PUBLIC KEY", publicKey)
+
+	issuer, err := NewTokenIssuer(config.AppConfig{
+		JwtAlgorithm:      "RS256",
+		JwtPrivateKeyFile: privateKeyFile,
+		JwtPublicKeyFile:  publicKeyFile,
+	})
+	assert.Nil(t, err)
+
+	token, _, err := issuer.Issue(7)
+	assert.Nil(t, err)
+	userID, err := issuer.Verify(token)
+	assert.Nil(t, err)
+	assert.Equal(t, 7, userID)
+}
+
+func TestNewTokenIssuerValidatesConfig(t *testing.T) {
+	_, err := NewTokenIssuer(config.AppConfig{JwtSecret: "short"})
+	assert.NotNil(t, err)
+
+	_, err = NewTokenIssuer(config.AppConfig{JwtSecret: placeholderSecret})
+	assert.NotNil(t, err)
+
+	_, err = NewTokenIssuer(config.AppConfig{JwtAlgorithm: "none"})
+	assert.NotNil(t, err)
+}
+
+func writePEM(t *testing.T, file, blockType string, der []byte) {
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(file, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewAPIKey(t *testing.T) {
+	key, prefix, hash, err := NewAPIKey()
+
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(key, prefix))
+	assert.Len(t, prefix, 11)
+	assert.Equal(t, HashToken(key), hash)
+	assert.NotContains(t, hash, key)
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"log"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -16,6 +17,71 @@ type AppConfig struct {
 	DbDatabase           string `mapstructure:"DB_NAME"`
 	DbRunMigrations      bool   `mapstructure:"DB_RUN_MIGRATIONS"`
 	DbMigrationsLocation string `mapstructure:"DB_MIGRATIONS_LOCATION"`
+	// DbMaxConns bounds the connection pool, idle connections are closed after
+	// DbMaxConnIdleTime and checked for liveness every DbHealthCheckPeriod.
+	DbMaxConns          int32         `mapstructure:"DB_MAX_CONNS"`
+	DbMaxConnIdleTime   time.Duration `mapstructure:"DB_MAX_CONN_IDLE_TIME"`
+	DbHealthCheckPeriod time.Duration `mapstructure:"DB_HEALTH_CHECK_PERIOD"`
+	// StorageBackend is where data is stored, either postgres, sqlite or memory.
+	// The sqlite backend keeps everything in the SQLitePath file and suits
+	// single-user deployments. The memory backend keeps bookmarks in memory and
+	// everything else in Postgres, it loses all bookmarks on restart and is meant for demos.
+	StorageBackend string `mapstructure:"STORAGE_BACKEND"`
+	SQLitePath     string `mapstructure:"SQLITE_PATH"`
+	// SQLiteMigrationsLocation and DbMigrationsLocation override the migrations
+	// embedded in the binary with a source url such as file://migrations.
+	SQLiteMigrationsLocation string `mapstructure:"SQLITE_MIGRATIONS_LOCATION"`
+
+	// JwtAlgorithm is either HS256, signing with JwtSecret, or RS256, signing
+	// with the PEM encoded keys in JwtPrivateKeyFile and JwtPublicKeyFile.
+	JwtAlgorithm       string        `mapstructure:"JWT_ALGORITHM"`
+	JwtSecret          string        `mapstructure:"JWT_SECRET"`
+	JwtPrivateKeyFile  string        `mapstructure:"JWT_PRIVATE_KEY_FILE"`
+	JwtPublicKeyFile   string        `mapstructure:"JWT_PUBLIC_KEY_FILE"`
+	JwtIssuer          string        `mapstructure:"JWT_ISSUER"`
+	JwtAccessTokenTTL  time.Duration `mapstructure:"JWT_ACCESS_TOKEN_TTL"`
+	JwtRefreshTokenTTL time.Duration `mapstructure:"JWT_REFRESH_TOKEN_TTL"`
+
+	// FetchAllowPrivateNetworks allows fetching bookmarked pages from private
+	// and loopback addresses, which is refused by default.
+	FetchAllowPrivateNetworks bool          `mapstructure:"FETCH_ALLOW_PRIVATE_NETWORKS"`
+	MetadataFetchTimeout      time.Duration `mapstructure:"METADATA_FETCH_TIMEOUT"`
+	MetadataMaxBytes          int64         `mapstructure:"METADATA_MAX_BYTES"`
+
+	JobsWorkers      int           `mapstructure:"JOBS_WORKERS"`
+	JobsPollInterval time.Duration `mapstructure:"JOBS_POLL_INTERVAL"`
+	JobsMaxAttempts  int           `mapstructure:"JOBS_MAX_ATTEMPTS"`
+	// JobsBackoffBase is the delay before the first retry of a failed job,
+	// doubling with every attempt up to JobsBackoffMax.
+	JobsBackoffBase time.Duration `mapstructure:"JOBS_BACKOFF_BASE"`
+	JobsBackoffMax  time.Duration `mapstructure:"JOBS_BACKOFF_MAX"`
+
+	// LinkCheckInterval is how often bookmarks last checked more than
+	// LinkCheckRecheckAfter ago are checked again for dead links.
+	LinkCheckInterval     time.Duration `mapstructure:"LINK_CHECK_INTERVAL"`
+	LinkCheckRecheckAfter time.Duration `mapstructure:"LINK_CHECK_RECHECK_AFTER"`
+	LinkCheckBatchSize    int           `mapstructure:"LINK_CHECK_BATCH_SIZE"`
+	LinkCheckTimeout      time.Duration `mapstructure:"LINK_CHECK_TIMEOUT"`
+	LinkCheckConcurrency  int           `mapstructure:"LINK_CHECK_CONCURRENCY"`
+	// LinkCheckHostInterval is the minimum delay between two requests to the same host.
+	LinkCheckHostInterval time.Duration `mapstructure:"LINK_CHECK_HOST_INTERVAL"`
+
+	// ArchiveDir is the directory the offline copies of bookmarked pages are stored in.
+	ArchiveDir             string        `mapstructure:"ARCHIVE_DIR"`
+	ArchiveInlineResources bool          `mapstructure:"ARCHIVE_INLINE_RESOURCES"`
+	ArchiveMaxBytes        int64         `mapstructure:"ARCHIVE_MAX_BYTES"`
+	ArchiveFetchTimeout    time.Duration `mapstructure:"ARCHIVE_FETCH_TIMEOUT"`
+	// ArchiveRetention is how long archives are kept, zero keeps them forever.
+	ArchiveRetention     time.Duration `mapstructure:"ARCHIVE_RETENTION"`
+	ArchivePurgeInterval time.Duration `mapstructure:"ARCHIVE_PURGE_INTERVAL"`
+
+	// TrashRetention is how long deleted bookmarks are kept in the trash, zero keeps them forever.
+	TrashRetention     time.Duration `mapstructure:"TRASH_RETENTION"`
+	TrashPurgeInterval time.Duration `mapstructure:"TRASH_PURGE_INTERVAL"`
+
+	// TrustedProxies are the comma separated addresses or CIDR ranges of the reverse proxies
+	// whose X-Forwarded-For header is used for the client IP, none are trusted by default.
+	TrustedProxies []string `mapstructure:"TRUSTED_PROXIES"`
 }
 
 func GetConfig(configFilePath string) (AppConfig, error) {
@@ -2,41 +2,61 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 
-	"github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
-	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/sivaprasadreddy/bookmarks-go/internal/config"
 	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
+	_ "modernc.org/sqlite"
 )
 
-func GetDb(config config.AppConfig, logger *logging.Logger) *pgx.Conn {
+func GetDb(config config.AppConfig, logger *logging.Logger) *pgxpool.Pool {
 	connStr := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
 		config.DbHost, config.DbPort, config.DbUserName, config.DbPassword, config.DbDatabase)
-	conn, err := pgx.Connect(context.Background(), connStr)
+	poolConfig, err := pgxpool.ParseConfig(connStr)
 	if err != nil {
 		logger.Fatal(err)
 	}
+	// zero values keep the pgxpool defaults
+	if config.DbMaxConns > 0 {
+		poolConfig.MaxConns = config.DbMaxConns
+	}
+	if config.DbMaxConnIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = config.DbMaxConnIdleTime
+	}
+	if config.DbHealthCheckPeriod > 0 {
+		poolConfig.HealthCheckPeriod = config.DbHealthCheckPeriod
+	}
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	// the pool connects lazily, fail fast when the database is unreachable
+	if err = pool.Ping(context.Background()); err != nil {
+		logger.Fatal(err)
+	}
 	if config.DbRunMigrations {
-		runMigrations(config, logger)
+		runMigrations(config.DbMigrationsLocation, ".", postgresURL(config), logger)
 	}
-	return conn
+	return pool
 }
 
-func runMigrations(config config.AppConfig, logger *logging.Logger) {
-	sourceURL := config.DbMigrationsLocation
-	databaseURL := fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=disable",
-		config.DbUserName, config.DbPassword, config.DbHost, config.DbPort, config.DbDatabase)
-	logger.Infof("DB Migration sourceURL: %s\n", sourceURL)
-	logger.Infof("DB Migration URL: %s\n", databaseURL)
-	m, err := migrate.New(sourceURL, databaseURL)
+// GetSQLiteDb opens the SQLite database at config.SQLitePath, creating it when missing.
+func GetSQLiteDb(config config.AppConfig, logger *logging.Logger) *sql.DB {
+	// times are stored in a sortable format, and transactions take the write lock up
+	// front so that concurrent transactions wait for each other instead of failing
+	dsn := "file:" + config.SQLitePath + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)" +
+		"&_pragma=journal_mode(WAL)&_time_format=sqlite&_txlock=immediate"
+	db, err := sql.Open("sqlite", dsn)
 	if err != nil {
-		logger.Fatalf("Database migration error: %v", err)
+		logger.Fatal(err)
 	}
-	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
-		logger.Fatalf("Databse migrate.up() error: %v", err)
+	if err = db.Ping(); err != nil {
+		logger.Fatal(err)
+	}
+	if config.DbRunMigrations {
+		runMigrations(config.SQLiteMigrationsLocation, "sqlite", sqliteURL(config), logger)
 	}
-	logger.Infof("Database migration completed")
+	return db
 }
@@ -0,0 +1,109 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/golang-migrate/migrate/v4/source"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/config"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
+	"github.com/sivaprasadreddy/bookmarks-go/migrations"
+)
+
+// Migration is a schema migration of a storage backend.
+type Migration struct {
+	Version uint
+	Name    string
+}
+
+// NewMigrate returns a migrate instance for the database of the configured storage
+// backend, which must be closed after use.
+func NewMigrate(config config.AppConfig) (*migrate.Migrate, error) {
+	src, databaseURL, err := migrationSource(config)
+	if err != nil {
+		return nil, err
+	}
+	return migrate.NewWithSourceInstance("migrations", src, databaseURL)
+}
+
+// ListMigrations returns the migrations of the configured storage backend in version order.
+func ListMigrations(config config.AppConfig) ([]Migration, error) {
+	src, _, err := migrationSource(config)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+	var migrations []Migration
+	version, err := src.First()
+	for err == nil {
+		m := Migration{Version: version}
+		if r, name, err := src.ReadUp(version); err == nil {
+			_ = r.Close()
+			m.Name = name
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		migrations = append(migrations, m)
+		version, err = src.Next(version)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return migrations, nil
+}
+
+// migrationSource opens the migrations and returns the database url of the configured storage backend.
+func migrationSource(config config.AppConfig) (source.Driver, string, error) {
+	if config.StorageBackend == "sqlite" {
+		src, err := openMigrations(config.SQLiteMigrationsLocation, "sqlite")
+		return src, sqliteURL(config), err
+	}
+	src, err := openMigrations(config.DbMigrationsLocation, ".")
+	return src, postgresURL(config), err
+}
+
+// openMigrations opens the migrations at location, or the migrations embedded in the
+// binary under dir when location is empty.
+func openMigrations(location string, dir string) (source.Driver, error) {
+	if location == "" {
+		return iofs.New(migrations.FS, dir)
+	}
+	return source.Open(location)
+}
+
+func postgresURL(config config.AppConfig) string {
+	return fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=disable",
+		config.DbUserName, config.DbPassword, config.DbHost, config.DbPort, config.DbDatabase)
+}
+
+func sqliteURL(config config.AppConfig) string {
+	return "sqlite://" + config.SQLitePath
+}
+
+func runMigrations(location string, dir string, databaseURL string, logger *logging.Logger) {
+	if location == "" {
+		logger.Infof("DB Migration sourceURL: embedded %s\n", dir)
+	} else {
+		logger.Infof("DB Migration sourceURL: %s\n", location)
+	}
+	logger.Infof("DB Migration URL: %s\n", databaseURL)
+	src, err := openMigrations(location, dir)
+	if err != nil {
+		logger.Fatalf("Database migration error: %v", err)
+	}
+	m, err := migrate.NewWithSourceInstance("migrations", src, databaseURL)
+	if err != nil {
+		logger.Fatalf("Database migration error: %v", err)
+	}
+	defer m.Close()
+	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+		logger.Fatalf("Databse migrate.up() error: %v", err)
+	}
+	logger.Infof("Database migration completed")
+}
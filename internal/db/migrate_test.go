@@ -0,0 +1,23 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListMigrations(t *testing.T) {
+	embedded, err := ListMigrations(config.AppConfig{})
+	assert.Nil(t, err)
+	assert.Equal(t, Migration{Version: 1, Name: "init_schema"}, embedded[0])
+
+	overridden, err := ListMigrations(config.AppConfig{DbMigrationsLocation: "file://../../migrations"})
+	assert.Nil(t, err)
+	assert.Equal(t, embedded, overridden)
+
+	sqlite, err := ListMigrations(config.AppConfig{StorageBackend: "sqlite"})
+	assert.Nil(t, err)
+	assert.Equal(t, []Migration{{Version: 1, Name: "init_schema"}, {Version: 2, Name: "sample_data"},
+		{Version: 3, Name: "disable_demo_password"}}, sqlite)
+}
@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrArchiveNotFound = newError(ErrNotFound, "archive not found")
+
+type ArchiveRepository interface {
+	FindByBookmarkID(ctx context.Context, ownerID int, bookmarkID int) (Archive, error)
+	// Save records the archive of a bookmark, replacing the previous one.
+	Save(ctx context.Context, archive Archive) error
+	// FindPurgeable returns the archives expired before now or whose bookmark was deleted.
+	FindPurgeable(ctx context.Context, now time.Time, limit int) ([]Archive, error)
+	Delete(ctx context.Context, bookmarkID int) error
+}
+
+const archiveColumns = "a.bookmark_id, a.storage_key, a.size, a.content_type, a.created_at, a.expires_at"
+
+type archiveRepo struct {
+	db     DB
+	logger *logging.Logger
+}
+
+func NewArchiveRepo(db DB, logger *logging.Logger) ArchiveRepository {
+	return &archiveRepo{db: db, logger: logger}
+}
+
+func (repo *archiveRepo) FindByBookmarkID(ctx context.Context, ownerID int, bookmarkID int) (Archive, error) {
+	sql := "SELECT " + archiveColumns + ` FROM archives a JOIN bookmarks b ON b.id = a.bookmark_id
+			WHERE a.bookmark_id=$1 AND b.owner_id=$2 AND b.deleted_at IS NULL`
+	a, err := scanArchive(conn(ctx, repo.db).QueryRow(ctx, sql, bookmarkID, ownerID))
+	return a, noRows(err, ErrArchiveNotFound)
+}
+
+func (repo *archiveRepo) Save(ctx context.Context, a Archive) error {
+	sql := `insert into archives(bookmark_id, storage_key, size, content_type, created_at, expires_at)
+			values($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (bookmark_id) DO UPDATE SET storage_key=excluded.storage_key, size=excluded.size,
+				content_type=excluded.content_type, created_at=excluded.created_at, expires_at=excluded.expires_at`
+	_, err := conn(ctx, repo.db).Exec(ctx, sql, a.BookmarkID, a.StorageKey, a.Size, a.ContentType, a.CreatedDate, a.ExpiresDate)
+	if err != nil {
+		repo.logger.Errorf("Error while saving archive row: %v", err)
+	}
+	return err
+}
+
+func (repo *archiveRepo) FindPurgeable(ctx context.Context, now time.Time, limit int) ([]Archive, error) {
+	sql := "SELECT " + archiveColumns + ` FROM archives a
+			WHERE a.expires_at < $1 OR NOT EXISTS (SELECT 1 FROM bookmarks b WHERE b.id = a.bookmark_id)
+			ORDER BY a.bookmark_id LIMIT $2`
+	rows, err := conn(ctx, repo.db).Query(ctx, sql, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var archives []Archive
+	for rows.Next() {
+		a, err := scanArchive(rows)
+		if err != nil {
+			return nil, err
+		}
+		archives = append(archives, a)
+	}
+	return archives, rows.Err()
+}
+
+func (repo *archiveRepo) Delete(ctx context.Context, bookmarkID int) error {
+	_, err := conn(ctx, repo.db).Exec(ctx, "delete from archives where bookmark_id=$1", bookmarkID)
+	return err
+}
+
+func scanArchive(row pgx.Row) (Archive, error) {
+	var a Archive
+	err := row.Scan(&a.BookmarkID, &a.StorageKey, &a.Size, &a.ContentType, &a.CreatedDate, &a.ExpiresDate)
+	if err != nil {
+		return Archive{}, err
+	}
+	return a, nil
+}
@@ -0,0 +1,31 @@
+package domain
+
+import "reflect"
+
+// BookmarkChanges returns the audited fields of a bookmark that differ between before
+// and after, either of which is nil when the bookmark did not exist.
+func BookmarkChanges(before, after *Bookmark) map[string]AuditChange {
+	from, to := auditFields(before), auditFields(after)
+	changes := map[string]AuditChange{}
+	for _, field := range []string{"title", "url", "tags", "deleted"} {
+		if !reflect.DeepEqual(from[field], to[field]) {
+			changes[field] = AuditChange{From: from[field], To: to[field]}
+		}
+	}
+	return changes
+}
+
+func auditFields(b *Bookmark) map[string]any {
+	if b == nil {
+		return nil
+	}
+	tags := b.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	fields := map[string]any{"title": b.Title, "url": b.URL, "tags": tags}
+	if b.DeletedDate != nil {
+		fields["deleted"] = true
+	}
+	return fields
+}
@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// AuditRepository is the append-only log of bookmark changes.
+type AuditRepository interface {
+	// Record appends the entries to the audit log, entries are never changed or deleted.
+	Record(ctx context.Context, entries ...AuditEntry) error
+	// FindPage returns the entries matching the query, the most recent first, and whether
+	// there are more entries after them.
+	FindPage(ctx context.Context, query AuditQuery) ([]AuditEntry, bool, error)
+}
+
+const auditColumns = "id, owner_id, actor_id, api_key_id, bookmark_id, action, changes, client_ip, created_at"
+
+type auditRepo struct {
+	db     DB
+	logger *logging.Logger
+}
+
+func NewAuditRepo(db DB, logger *logging.Logger) AuditRepository {
+	return &auditRepo{db: db, logger: logger}
+}
+
+func (repo *auditRepo) Record(ctx context.Context, entries ...AuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	sql := `insert into audit_log(owner_id, actor_id, api_key_id, bookmark_id, action, changes, client_ip, created_at)
+			values($1, $2, $3, $4, $5, $6, $7, $8)`
+	batch := &pgx.Batch{}
+	for _, e := range entries {
+		batch.Queue(sql, e.OwnerID, e.ActorID, e.APIKeyID, e.BookmarkID, e.Action, e.Changes, e.ClientIP, e.CreatedDate)
+	}
+	err := conn(ctx, repo.db).SendBatch(ctx, batch).Close()
+	if err != nil {
+		repo.logger.Errorf("Error while recording audit log entries: %v", err)
+	}
+	return err
+}
+
+func (repo *auditRepo) FindPage(ctx context.Context, q AuditQuery) ([]AuditEntry, bool, error) {
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	where := []string{"owner_id = " + arg(q.OwnerID)}
+	if q.BookmarkID > 0 {
+		where = append(where, "bookmark_id = "+arg(q.BookmarkID))
+	}
+	if q.Action != "" {
+		where = append(where, "action = "+arg(q.Action))
+	}
+	if q.Since != nil {
+		where = append(where, "created_at >= "+arg(*q.Since))
+	}
+	if q.Until != nil {
+		where = append(where, "created_at < "+arg(*q.Until))
+	}
+	if q.After > 0 {
+		where = append(where, "id < "+arg(q.After))
+	}
+	// fetch one extra row to find out whether there are more entries
+	sql := "SELECT " + auditColumns + " FROM audit_log" + whereClause(where) + " ORDER BY id DESC LIMIT " + arg(q.Size+1)
+	rows, err := conn(ctx, repo.db).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+	entries := []AuditEntry{}
+	for rows.Next() {
+		var e AuditEntry
+		err = rows.Scan(&e.ID, &e.OwnerID, &e.ActorID, &e.APIKeyID, &e.BookmarkID, &e.Action, &e.Changes,
+			&e.ClientIP, &e.CreatedDate)
+		if err != nil {
+			return nil, false, err
+		}
+		entries = append(entries, e)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, false, err
+	}
+	if len(entries) > q.Size {
+		return entries[:q.Size], true, nil
+	}
+	return entries, false, nil
+}
@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBookmarkChanges(t *testing.T) {
+	now := time.Now()
+	before := Bookmark{ID: 1, Title: "Go", URL: "https://go.dev", Tags: []string{"go"}}
+	retitled := before
+	retitled.Title = "The Go Programming Language"
+	retagged := before
+	retagged.Tags = nil
+	deleted := before
+	deleted.DeletedDate = &now
+
+	tests := []struct {
+		name          string
+		before, after *Bookmark
+		want          map[string]AuditChange
+	}{
+		{"create", nil, &before, map[string]AuditChange{
+			"title": {To: "Go"},
+			"url":   {To: "https://go.dev"},
+			"tags":  {To: []string{"go"}},
+		}},
+		{"title changed", &before, &retitled, map[string]AuditChange{
+			"title": {From: "Go", To: "The Go Programming Language"},
+		}},
+		{"tags removed", &before, &retagged, map[string]AuditChange{
+			"tags": {From: []string{"go"}, To: []string{}},
+		}},
+		{"deleted", &before, &deleted, map[string]AuditChange{
+			"deleted": {To: true},
+		}},
+		{"restored", &deleted, &before, map[string]AuditChange{
+			"deleted": {From: true},
+		}},
+		{"unchanged", &before, &before, map[string]AuditChange{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, BookmarkChanges(tt.before, tt.after))
+		})
+	}
+}
@@ -0,0 +1,390 @@
+package domain
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrCollectionNotFound      = newError(ErrNotFound, "collection not found")
+	ErrBookmarkNotInCollection = newError(ErrNotFound, "bookmark is not in the collection")
+	ErrBookmarkInCollection    = newError(ErrConflict, "bookmark is already in the collection")
+	ErrCollectionCycle         = newError(ErrConflict, "collection can not be nested in itself")
+)
+
+// DeleteMode decides what happens to the children of a deleted collection.
+type DeleteMode string
+
+const (
+	// DeleteCascade deletes the sub-collections too. Bookmarks are only removed from the deleted collections.
+	DeleteCascade DeleteMode = "cascade"
+	// DeleteReparent moves the sub-collections and bookmarks to the parent of the deleted collection.
+	// Bookmarks of a deleted top level collection are only removed from it.
+	DeleteReparent DeleteMode = "reparent"
+)
+
+type CollectionRepository interface {
+	FindAll(ctx context.Context, ownerID int) ([]Collection, error)
+	FindByID(ctx context.Context, ownerID int, id int) (Collection, error)
+	Create(ctx context.Context, collection Collection) (Collection, error)
+	// Update renames the collection and changes its parent, refusing to nest it in its own subtree.
+	Update(ctx context.Context, collection Collection) (Collection, error)
+	Delete(ctx context.Context, ownerID int, id int, mode DeleteMode) error
+	// FindBookmarks returns the bookmarks of the collection in their manual order.
+	FindBookmarks(ctx context.Context, ownerID int, id int) ([]Bookmark, error)
+	// AddBookmark inserts the bookmark at position, or appends it when position is nil.
+	AddBookmark(ctx context.Context, ownerID int, id int, bookmarkID int, position *int) error
+	RemoveBookmark(ctx context.Context, ownerID int, id int, bookmarkID int) error
+	// MoveBookmark removes the bookmark from the collection and inserts it in the target
+	// collection, which may be the same collection to reorder it.
+	MoveBookmark(ctx context.Context, ownerID int, id int, bookmarkID int, targetID int, position *int) error
+	// CopyBookmark adds a bookmark of the collection to the target collection as well.
+	CopyBookmark(ctx context.Context, ownerID int, id int, bookmarkID int, targetID int, position *int) error
+	// MoveBookmarkToCollection takes the bookmark out of all its collections and inserts it in the target collection.
+	MoveBookmarkToCollection(ctx context.Context, ownerID int, bookmarkID int, targetID int, position *int) error
+}
+
+const collectionColumns = "c.id, c.owner_id, c.parent_id, c.name, c.created_at, c.updated_at"
+
+type collectionRepo struct {
+	db     DB
+	logger *logging.Logger
+}
+
+func NewCollectionRepo(db DB, logger *logging.Logger) CollectionRepository {
+	return &collectionRepo{db: db, logger: logger}
+}
+
+func (repo *collectionRepo) FindAll(ctx context.Context, ownerID int) ([]Collection, error) {
+	sql := "SELECT " + collectionColumns + " FROM collections c WHERE c.owner_id=$1 ORDER BY c.name, c.id"
+	rows, err := conn(ctx, repo.db).Query(ctx, sql, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	collections := []Collection{}
+	for rows.Next() {
+		c, err := scanCollection(rows)
+		if err != nil {
+			return nil, err
+		}
+		collections = append(collections, c)
+	}
+	return collections, rows.Err()
+}
+
+func (repo *collectionRepo) FindByID(ctx context.Context, ownerID int, id int) (Collection, error) {
+	sql := "SELECT " + collectionColumns + " FROM collections c WHERE c.id=$1 AND c.owner_id=$2"
+	c, err := scanCollection(conn(ctx, repo.db).QueryRow(ctx, sql, id, ownerID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Collection{}, ErrCollectionNotFound
+	}
+	return c, err
+}
+
+func (repo *collectionRepo) Create(ctx context.Context, c Collection) (Collection, error) {
+	err := pgx.BeginFunc(ctx, conn(ctx, repo.db), func(tx pgx.Tx) error {
+		if c.ParentID != nil {
+			if err := lockCollection(ctx, tx, c.OwnerID, *c.ParentID); err != nil {
+				return err
+			}
+		}
+		sql := "insert into collections(owner_id, parent_id, name, created_at) values($1, $2, $3, $4) RETURNING id"
+		return tx.QueryRow(ctx, sql, c.OwnerID, c.ParentID, c.Name, c.CreatedDate).Scan(&c.ID)
+	})
+	if err != nil {
+		return Collection{}, err
+	}
+	return c, nil
+}
+
+func (repo *collectionRepo) Update(ctx context.Context, c Collection) (Collection, error) {
+	err := pgx.BeginFunc(ctx, conn(ctx, repo.db), func(tx pgx.Tx) error {
+		if err := lockCollection(ctx, tx, c.OwnerID, c.ID); err != nil {
+			return err
+		}
+		if c.ParentID != nil {
+			if err := lockCollection(ctx, tx, c.OwnerID, *c.ParentID); err != nil {
+				return err
+			}
+			// the new parent must not be the collection or one of its descendants
+			sql := `WITH RECURSIVE ancestors(id, parent_id) AS (
+						SELECT id, parent_id FROM collections WHERE id = $1
+						UNION ALL
+						SELECT c.id, c.parent_id FROM collections c JOIN ancestors a ON c.id = a.parent_id
+					)
+					SELECT EXISTS (SELECT 1 FROM ancestors WHERE id = $2)`
+			var cycle bool
+			if err := tx.QueryRow(ctx, sql, *c.ParentID, c.ID).Scan(&cycle); err != nil {
+				return err
+			}
+			if cycle {
+				return ErrCollectionCycle
+			}
+		}
+		sql := "update collections set name=$1, parent_id=$2, updated_at=$3 where id=$4 and owner_id=$5"
+		_, err := tx.Exec(ctx, sql, c.Name, c.ParentID, c.UpdatedDate, c.ID, c.OwnerID)
+		return err
+	})
+	if err != nil {
+		return Collection{}, err
+	}
+	return repo.FindByID(ctx, c.OwnerID, c.ID)
+}
+
+func (repo *collectionRepo) Delete(ctx context.Context, ownerID int, id int, mode DeleteMode) error {
+	return pgx.BeginFunc(ctx, conn(ctx, repo.db), func(tx pgx.Tx) error {
+		var parentID *int
+		sql := "SELECT parent_id FROM collections WHERE id=$1 AND owner_id=$2 FOR UPDATE"
+		err := tx.QueryRow(ctx, sql, id, ownerID).Scan(&parentID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrCollectionNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if mode == DeleteCascade {
+			sql = `WITH RECURSIVE subtree(id) AS (
+						SELECT id FROM collections WHERE id = $1
+						UNION ALL
+						SELECT c.id FROM collections c JOIN subtree s ON c.parent_id = s.id
+					)
+					delete from collections where id IN (SELECT id FROM subtree)`
+			_, err = tx.Exec(ctx, sql, id)
+			return err
+		}
+
+		if parentID != nil {
+			if err = lockCollection(ctx, tx, ownerID, *parentID); err != nil {
+				return err
+			}
+			// append the bookmarks not yet in the parent after its own, keeping their order
+			sql = `insert into collection_bookmarks(collection_id, bookmark_id, position)
+					SELECT $1, cb.bookmark_id,
+						(SELECT coalesce(max(position) + 1, 0) FROM collection_bookmarks WHERE collection_id = $1) +
+						row_number() OVER (ORDER BY cb.position) - 1
+					FROM collection_bookmarks cb
+					WHERE cb.collection_id = $2 AND NOT EXISTS (
+						SELECT 1 FROM collection_bookmarks p WHERE p.collection_id = $1 AND p.bookmark_id = cb.bookmark_id)`
+			if _, err = tx.Exec(ctx, sql, *parentID, id); err != nil {
+				return err
+			}
+		}
+		_, err = tx.Exec(ctx, "update collections set parent_id=$1 where parent_id=$2", parentID, id)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, "delete from collections where id=$1", id)
+		return err
+	})
+}
+
+func (repo *collectionRepo) FindBookmarks(ctx context.Context, ownerID int, id int) ([]Bookmark, error) {
+	if _, err := repo.FindByID(ctx, ownerID, id); err != nil {
+		return nil, err
+	}
+	sql := "SELECT " + bookmarkColumns + ` FROM collection_bookmarks cb JOIN bookmarks b ON b.id = cb.bookmark_id
+			WHERE cb.collection_id = $1 AND b.deleted_at IS NULL ORDER BY cb.position`
+	rows, err := conn(ctx, repo.db).Query(ctx, sql, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	bookmarks := []Bookmark{}
+	for rows.Next() {
+		b, err := scanBookmark(rows)
+		if err != nil {
+			return nil, err
+		}
+		bookmarks = append(bookmarks, b)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if err = loadTags(ctx, conn(ctx, repo.db), bookmarks); err != nil {
+		return nil, err
+	}
+	return bookmarks, nil
+}
+
+func (repo *collectionRepo) AddBookmark(ctx context.Context, ownerID int, id int, bookmarkID int, position *int) error {
+	return pgx.BeginFunc(ctx, conn(ctx, repo.db), func(tx pgx.Tx) error {
+		if err := lockCollection(ctx, tx, ownerID, id); err != nil {
+			return err
+		}
+		var exists bool
+		sql := "SELECT EXISTS (SELECT 1 FROM bookmarks WHERE id=$1 AND owner_id=$2 AND deleted_at IS NULL)"
+		if err := tx.QueryRow(ctx, sql, bookmarkID, ownerID).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return ErrBookmarkNotFound
+		}
+		return insertCollectionBookmark(ctx, tx, id, bookmarkID, position)
+	})
+}
+
+func (repo *collectionRepo) RemoveBookmark(ctx context.Context, ownerID int, id int, bookmarkID int) error {
+	return pgx.BeginFunc(ctx, conn(ctx, repo.db), func(tx pgx.Tx) error {
+		if err := lockCollection(ctx, tx, ownerID, id); err != nil {
+			return err
+		}
+		return deleteCollectionBookmark(ctx, tx, id, bookmarkID)
+	})
+}
+
+func (repo *collectionRepo) MoveBookmark(ctx context.Context, ownerID int, id int, bookmarkID int, targetID int,
+	position *int) error {
+	return pgx.BeginFunc(ctx, conn(ctx, repo.db), func(tx pgx.Tx) error {
+		if err := lockCollections(ctx, tx, ownerID, id, targetID); err != nil {
+			return err
+		}
+		if err := deleteCollectionBookmark(ctx, tx, id, bookmarkID); err != nil {
+			return err
+		}
+		return insertCollectionBookmark(ctx, tx, targetID, bookmarkID, position)
+	})
+}
+
+func (repo *collectionRepo) CopyBookmark(ctx context.Context, ownerID int, id int, bookmarkID int, targetID int,
+	position *int) error {
+	return pgx.BeginFunc(ctx, conn(ctx, repo.db), func(tx pgx.Tx) error {
+		if err := lockCollections(ctx, tx, ownerID, id, targetID); err != nil {
+			return err
+		}
+		var exists bool
+		sql := "SELECT EXISTS (SELECT 1 FROM collection_bookmarks WHERE collection_id=$1 AND bookmark_id=$2)"
+		if err := tx.QueryRow(ctx, sql, id, bookmarkID).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return ErrBookmarkNotInCollection
+		}
+		return insertCollectionBookmark(ctx, tx, targetID, bookmarkID, position)
+	})
+}
+
+func (repo *collectionRepo) MoveBookmarkToCollection(ctx context.Context, ownerID int, bookmarkID int, targetID int,
+	position *int) error {
+	return pgx.BeginFunc(ctx, conn(ctx, repo.db), func(tx pgx.Tx) error {
+		var exists bool
+		sql := "SELECT EXISTS (SELECT 1 FROM bookmarks WHERE id=$1 AND owner_id=$2 AND deleted_at IS NULL)"
+		if err := tx.QueryRow(ctx, sql, bookmarkID, ownerID).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return ErrBookmarkNotFound
+		}
+		sql = `SELECT collection_id FROM collection_bookmarks WHERE bookmark_id=$1
+			   UNION SELECT $2::bigint ORDER BY 1`
+		rows, err := tx.Query(ctx, sql, bookmarkID, targetID)
+		if err != nil {
+			return err
+		}
+		ids, err := pgx.CollectRows(rows, pgx.RowTo[int])
+		if err != nil {
+			return err
+		}
+		// locked in id order so that concurrent moves can not deadlock
+		for _, id := range ids {
+			if err = lockCollection(ctx, tx, ownerID, id); err != nil {
+				return err
+			}
+		}
+		for _, id := range ids {
+			err = deleteCollectionBookmark(ctx, tx, id, bookmarkID)
+			if err != nil && !errors.Is(err, ErrBookmarkNotInCollection) {
+				return err
+			}
+		}
+		return insertCollectionBookmark(ctx, tx, targetID, bookmarkID, position)
+	})
+}
+
+// lockCollection locks the collection of the owner for the rest of the transaction,
+// serializing the changes to the positions of its bookmarks.
+func lockCollection(ctx context.Context, tx pgx.Tx, ownerID int, id int) error {
+	var locked int
+	err := tx.QueryRow(ctx, "SELECT id FROM collections WHERE id=$1 AND owner_id=$2 FOR UPDATE", id, ownerID).
+		Scan(&locked)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrCollectionNotFound
+	}
+	return err
+}
+
+// lockCollections locks two collections in id order so that concurrent moves can not deadlock.
+func lockCollections(ctx context.Context, tx pgx.Tx, ownerID int, id int, otherID int) error {
+	if otherID < id {
+		id, otherID = otherID, id
+	}
+	if err := lockCollection(ctx, tx, ownerID, id); err != nil || otherID == id {
+		return err
+	}
+	return lockCollection(ctx, tx, ownerID, otherID)
+}
+
+// insertCollectionBookmark inserts the bookmark at position, shifting the following bookmarks down.
+// Positions may have gaps left by deleted bookmarks, so position is an index into the ordered bookmarks.
+func insertCollectionBookmark(ctx context.Context, tx pgx.Tx, id int, bookmarkID int, position *int) error {
+	var exists bool
+	sql := "SELECT EXISTS (SELECT 1 FROM collection_bookmarks WHERE collection_id=$1 AND bookmark_id=$2)"
+	if err := tx.QueryRow(ctx, sql, id, bookmarkID).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return ErrBookmarkInCollection
+	}
+	var at int
+	if position == nil {
+		sql = "SELECT coalesce(max(position) + 1, 0) FROM collection_bookmarks WHERE collection_id=$1"
+		if err := tx.QueryRow(ctx, sql, id).Scan(&at); err != nil {
+			return err
+		}
+	} else {
+		sql = `SELECT coalesce(
+					(SELECT position FROM collection_bookmarks WHERE collection_id=$1 ORDER BY position OFFSET $2 LIMIT 1),
+					(SELECT max(position) + 1 FROM collection_bookmarks WHERE collection_id=$1),
+					0)`
+		if err := tx.QueryRow(ctx, sql, id, *position).Scan(&at); err != nil {
+			return err
+		}
+		sql = "update collection_bookmarks set position = position + 1 where collection_id=$1 and position >= $2"
+		if _, err := tx.Exec(ctx, sql, id, at); err != nil {
+			return err
+		}
+	}
+	sql = "insert into collection_bookmarks(collection_id, bookmark_id, position) values($1, $2, $3)"
+	_, err := tx.Exec(ctx, sql, id, bookmarkID, at)
+	return err
+}
+
+// deleteCollectionBookmark removes the bookmark, shifting the following bookmarks up.
+func deleteCollectionBookmark(ctx context.Context, tx pgx.Tx, id int, bookmarkID int) error {
+	var position int
+	sql := "delete from collection_bookmarks where collection_id=$1 and bookmark_id=$2 RETURNING position"
+	err := tx.QueryRow(ctx, sql, id, bookmarkID).Scan(&position)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrBookmarkNotInCollection
+	}
+	if err != nil {
+		return err
+	}
+	sql = "update collection_bookmarks set position = position - 1 where collection_id=$1 and position > $2"
+	_, err = tx.Exec(ctx, sql, id, position)
+	return err
+}
+
+func scanCollection(row pgx.Row) (Collection, error) {
+	var c Collection
+	err := row.Scan(&c.ID, &c.OwnerID, &c.ParentID, &c.Name, &c.CreatedDate, &c.UpdatedDate)
+	if err != nil {
+		return Collection{}, err
+	}
+	return c, nil
+}
@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// DB is satisfied by both *pgxpool.Pool and pgx.Tx.
+type DB interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+}
+
+type txKey struct{}
+
+// inTx runs fn in a transaction which is committed when fn returns nil and rolled
+// back otherwise. Repositories called with the context passed to fn run their
+// statements in the transaction, nested transactions become savepoints.
+func inTx(ctx context.Context, db DB, fn func(ctx context.Context) error) error {
+	return pgx.BeginFunc(ctx, conn(ctx, db), func(tx pgx.Tx) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// noRows replaces pgx.ErrNoRows with notFound, so that callers check for domain errors
+// instead of the errors of the database driver.
+func noRows(err error, notFound error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return notFound
+	}
+	return err
+}
+
+// conn returns the transaction started by inTx for ctx, or db outside of transactions.
+func conn(ctx context.Context, db DB) DB {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return db
+}
@@ -0,0 +1,40 @@
+package domain
+
+import "errors"
+
+// The kinds of errors returned by the repositories, errors.Is(err, ErrNotFound)
+// holds for every error of the kind. The API responds to them with 404, 409, 422 and 412.
+var (
+	ErrNotFound           = errors.New("not found")
+	ErrConflict           = errors.New("conflict")
+	ErrValidation         = errors.New("validation failed")
+	ErrPreconditionFailed = errors.New("precondition failed")
+)
+
+// kindError is an error of one of the kinds above, optionally wrapping its cause.
+type kindError struct {
+	kind error
+	msg  string
+	err  error
+}
+
+func newError(kind error, msg string) error {
+	return &kindError{kind: kind, msg: msg}
+}
+
+// ValidationError marks err, for example a failed binding of a request body, as an ErrValidation.
+func ValidationError(err error) error {
+	return &kindError{kind: ErrValidation, msg: err.Error(), err: err}
+}
+
+func (e *kindError) Error() string {
+	return e.msg
+}
+
+func (e *kindError) Is(target error) bool {
+	return target == e.kind
+}
+
+func (e *kindError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,145 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrJobNotFound = newError(ErrNotFound, "job not found")
+	// ErrNoJobDue is returned by Claim when no job is runnable.
+	ErrNoJobDue = errors.New("no job is due")
+	// ErrJobLeaseLost is returned when the outcome of a job is recorded after the job was
+	// considered abandoned and claimed again.
+	ErrJobLeaseLost = errors.New("job was claimed again")
+)
+
+// JobRepository is a Postgres backed queue of background jobs.
+type JobRepository interface {
+	FindAll(ctx context.Context, userID int, status string, limit int) ([]Job, error)
+	FindByID(ctx context.Context, userID int, jobID int) (Job, error)
+	Enqueue(ctx context.Context, job Job) (Job, error)
+	// Claim marks the next runnable job as running and returns it, or ErrNoJobDue
+	// when there is none. Jobs still running but locked before staleBefore are
+	// considered abandoned by a crashed worker and are claimed again. Rows locked
+	// by concurrent claims are skipped, so several workers never get the same job.
+	Claim(ctx context.Context, now time.Time, staleBefore time.Time) (Job, error)
+	// Complete, Retry and DeadLetter record the outcome of a claimed job. They fail with
+	// ErrJobLeaseLost unless the job is still locked by the claim that returned it.
+	Complete(ctx context.Context, job Job, now time.Time) error
+	// Retry puts the job back in the queue to run again at runAt.
+	Retry(ctx context.Context, job Job, lastError string, runAt time.Time, now time.Time) error
+	// DeadLetter marks the job as dead, it is kept for inspection but never run again.
+	DeadLetter(ctx context.Context, job Job, lastError string, now time.Time) error
+}
+
+const jobColumns = "id, user_id, kind, payload, status, attempts, max_attempts, last_error, run_at, created_at, " +
+	"updated_at, locked_at"
+
+type jobRepo struct {
+	db     DB
+	logger *logging.Logger
+}
+
+func NewJobRepo(db DB, logger *logging.Logger) JobRepository {
+	return &jobRepo{db: db, logger: logger}
+}
+
+func (repo *jobRepo) FindAll(ctx context.Context, userID int, status string, limit int) ([]Job, error) {
+	sql := "SELECT " + jobColumns + " FROM jobs WHERE user_id=$1 AND ($2 = '' OR status=$2) ORDER BY id DESC LIMIT $3"
+	rows, err := conn(ctx, repo.db).Query(ctx, sql, userID, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var jobs []Job
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+func (repo *jobRepo) FindByID(ctx context.Context, userID int, id int) (Job, error) {
+	sql := "SELECT " + jobColumns + " FROM jobs WHERE id=$1 AND user_id=$2"
+	j, err := scanJob(conn(ctx, repo.db).QueryRow(ctx, sql, id, userID))
+	return j, noRows(err, ErrJobNotFound)
+}
+
+func (repo *jobRepo) Enqueue(ctx context.Context, j Job) (Job, error) {
+	if j.Payload == nil {
+		j.Payload = []byte("{}")
+	}
+	j.Status = JobPending
+	sql := `insert into jobs(user_id, kind, payload, status, max_attempts, run_at, created_at)
+			values($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+	err := conn(ctx, repo.db).QueryRow(ctx, sql, j.UserID, j.Kind, j.Payload, j.Status, j.MaxAttempts, j.RunAt, j.CreatedDate).
+		Scan(&j.ID)
+	if err != nil {
+		repo.logger.Errorf("Error while inserting job row: %v", err)
+		return Job{}, err
+	}
+	return j, nil
+}
+
+func (repo *jobRepo) Claim(ctx context.Context, now time.Time, staleBefore time.Time) (Job, error) {
+	sql := `UPDATE jobs SET status='running', attempts=attempts+1, locked_at=$1, updated_at=$1
+			WHERE id = (
+				SELECT id FROM jobs
+				WHERE (status='pending' AND run_at <= $1) OR (status='running' AND locked_at < $2)
+				ORDER BY run_at, id
+				LIMIT 1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING ` + jobColumns
+	j, err := scanJob(conn(ctx, repo.db).QueryRow(ctx, sql, now, staleBefore))
+	return j, noRows(err, ErrNoJobDue)
+}
+
+func (repo *jobRepo) Complete(ctx context.Context, j Job, now time.Time) error {
+	sql := `UPDATE jobs SET status='succeeded', last_error='', locked_at=NULL, updated_at=$3
+			WHERE id=$1 AND status='running' AND locked_at=$2`
+	return repo.release(ctx, sql, j.ID, j.LockedDate, now)
+}
+
+func (repo *jobRepo) Retry(ctx context.Context, j Job, lastError string, runAt time.Time, now time.Time) error {
+	sql := `UPDATE jobs SET status='pending', last_error=$3, run_at=$4, locked_at=NULL, updated_at=$5
+			WHERE id=$1 AND status='running' AND locked_at=$2`
+	return repo.release(ctx, sql, j.ID, j.LockedDate, lastError, runAt, now)
+}
+
+func (repo *jobRepo) DeadLetter(ctx context.Context, j Job, lastError string, now time.Time) error {
+	sql := `UPDATE jobs SET status='dead', last_error=$3, locked_at=NULL, updated_at=$4
+			WHERE id=$1 AND status='running' AND locked_at=$2`
+	return repo.release(ctx, sql, j.ID, j.LockedDate, lastError, now)
+}
+
+// release runs an update recording the outcome of a claimed job.
+func (repo *jobRepo) release(ctx context.Context, sql string, args ...any) error {
+	result, err := conn(ctx, repo.db).Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrJobLeaseLost
+	}
+	return nil
+}
+
+func scanJob(row pgx.Row) (Job, error) {
+	var j Job
+	err := row.Scan(&j.ID, &j.UserID, &j.Kind, &j.Payload, &j.Status, &j.Attempts, &j.MaxAttempts, &j.LastError,
+		&j.RunAt, &j.CreatedDate, &j.UpdatedDate, &j.LockedDate)
+	if err != nil {
+		return Job{}, err
+	}
+	return j, nil
+}
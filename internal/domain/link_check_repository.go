@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
+)
+
+type LinkCheck struct {
+	BookmarkID int
+	// URL is the checked url of the bookmark.
+	URL string
+	// StatusCode is nil when no response was received.
+	StatusCode  *int
+	FinalURL    string
+	CheckedDate time.Time
+	Failed      bool
+}
+
+// LinkCheckRepository tracks the dead link checks of the bookmarks of all users.
+type LinkCheckRepository interface {
+	// FindDue returns the bookmarks never checked or last checked before checkedBefore,
+	// least recently checked first. Only their ID, OwnerID and URL are populated.
+	FindDue(ctx context.Context, checkedBefore time.Time, limit int) ([]Bookmark, error)
+	// Record stores the outcome of a check, counting consecutive failures. The outcome is
+	// dropped when the url of the bookmark was changed in the meantime.
+	Record(ctx context.Context, check LinkCheck) error
+}
+
+type linkCheckRepo struct {
+	db     DB
+	logger *logging.Logger
+}
+
+func NewLinkCheckRepo(db DB, logger *logging.Logger) LinkCheckRepository {
+	return &linkCheckRepo{db: db, logger: logger}
+}
+
+func (repo *linkCheckRepo) FindDue(ctx context.Context, checkedBefore time.Time, limit int) ([]Bookmark, error) {
+	sql := `SELECT id, owner_id, url FROM bookmarks
+			WHERE deleted_at IS NULL AND (link_checked_at IS NULL OR link_checked_at < $1)
+			ORDER BY link_checked_at NULLS FIRST, id LIMIT $2`
+	rows, err := conn(ctx, repo.db).Query(ctx, sql, checkedBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var bookmarks []Bookmark
+	for rows.Next() {
+		var b = Bookmark{}
+		if err = rows.Scan(&b.ID, &b.OwnerID, &b.URL); err != nil {
+			return nil, err
+		}
+		bookmarks = append(bookmarks, b)
+	}
+	return bookmarks, rows.Err()
+}
+
+func (repo *linkCheckRepo) Record(ctx context.Context, check LinkCheck) error {
+	sql := `update bookmarks set link_status_code=$2, link_final_url=$3, link_checked_at=$4,
+			link_failures = CASE WHEN $5 THEN link_failures + 1 ELSE 0 END
+			where id=$1 and url=$6`
+	_, err := conn(ctx, repo.db).Exec(ctx, sql, check.BookmarkID, check.StatusCode, check.FinalURL, check.CheckedDate,
+		check.Failed, check.URL)
+	return err
+}
@@ -0,0 +1,488 @@
+package domain
+
+import (
+	"cmp"
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
+)
+
+// memoryBookmarkRepo keeps bookmarks in memory, for tests and demo mode. It behaves like
+// bookmarkRepo except that searches match words by prefix instead of by their English
+// stem and titles are sorted case-insensitively instead of by the database collation.
+type memoryBookmarkRepo struct {
+	mu        sync.Mutex
+	nextID    int
+	bookmarks map[int]*memoryBookmark
+	logger    *logging.Logger
+}
+
+type memoryBookmark struct {
+	Bookmark
+	normalizedURL string
+	content       string
+}
+
+type memoryTxKey struct{}
+
+func NewMemoryBookmarkRepo(logger *logging.Logger) BookmarkRepository {
+	return &memoryBookmarkRepo{bookmarks: map[int]*memoryBookmark{}, logger: logger}
+}
+
+// lock locks the repository, unless ctx belongs to one of its transactions which holds the lock already.
+func (repo *memoryBookmarkRepo) lock(ctx context.Context) func() {
+	if ctx.Value(memoryTxKey{}) == repo {
+		return func() {}
+	}
+	repo.mu.Lock()
+	return repo.mu.Unlock
+}
+
+// visible returns the bookmark of the owner unless it does not exist or is in the trash.
+func (repo *memoryBookmarkRepo) visible(ownerID int, id int) *memoryBookmark {
+	m := repo.bookmarks[id]
+	if m == nil || m.OwnerID != ownerID || m.DeletedDate != nil {
+		return nil
+	}
+	return m
+}
+
+// sorted returns the visible bookmarks of the owner in id order.
+func (repo *memoryBookmarkRepo) sorted(ownerID int) []*memoryBookmark {
+	var bookmarks []*memoryBookmark
+	for _, m := range repo.bookmarks {
+		if m.OwnerID == ownerID && m.DeletedDate == nil {
+			bookmarks = append(bookmarks, m)
+		}
+	}
+	sort.Slice(bookmarks, func(i, j int) bool { return bookmarks[i].ID < bookmarks[j].ID })
+	return bookmarks
+}
+
+// duplicateURLError returns a DuplicateURLError when another visible bookmark of the owner has the normalized url.
+func (repo *memoryBookmarkRepo) duplicateURLError(ownerID int, normalized string, id int) error {
+	for _, m := range repo.bookmarks {
+		if m.ID != id && m.OwnerID == ownerID && m.DeletedDate == nil && m.normalizedURL == normalized {
+			return &DuplicateURLError{ExistingID: m.ID}
+		}
+	}
+	return nil
+}
+
+func (m *memoryBookmark) bookmark() Bookmark {
+	b := m.Bookmark
+	b.Tags = append([]string{}, m.Tags...)
+	return b
+}
+
+func (repo *memoryBookmarkRepo) FindAll(ctx context.Context, ownerID int) ([]Bookmark, error) {
+	defer repo.lock(ctx)()
+	var bookmarks []Bookmark
+	for _, m := range repo.sorted(ownerID) {
+		bookmarks = append(bookmarks, m.bookmark())
+	}
+	return bookmarks, nil
+}
+
+func (repo *memoryBookmarkRepo) FindPage(ctx context.Context, q BookmarkQuery) (BookmarkPage, error) {
+	unlock := repo.lock(ctx)
+	terms := parseSearchTerms(q.Query)
+	var matches []Bookmark
+	for _, m := range repo.sorted(q.OwnerID) {
+		if !matchesLinkStatus(m.Bookmark, q.LinkStatus) || !matchesTags(m.Tags, q.Tags, q.AnyTag) {
+			continue
+		}
+		b := m.bookmark()
+		if q.Query != "" {
+			var ok bool
+			if b.Rank, ok = terms.rank(m); !ok {
+				continue
+			}
+			b.Snippet = terms.snippet(m)
+		}
+		matches = append(matches, b)
+	}
+	var cursor *Bookmark
+	if m := repo.bookmarks[q.After]; q.After > 0 && m != nil && m.OwnerID == q.OwnerID {
+		b := m.bookmark()
+		b.Rank, _ = terms.rank(m)
+		cursor = &b
+	}
+	unlock()
+
+	fields := withTieBreaker(q.Sort)
+	sort.Slice(matches, func(i, j int) bool { return compareBookmarks(matches[i], matches[j], fields) < 0 })
+	page := BookmarkPage{Total: int64(len(matches))}
+	switch {
+	case q.After > 0:
+		var after []Bookmark
+		for _, b := range matches {
+			if cursor != nil && compareBookmarks(*cursor, b, fields) < 0 {
+				after = append(after, b)
+			}
+		}
+		matches = after
+	case q.Page > 1:
+		matches = matches[min((q.Page-1)*q.Size, len(matches)):]
+	}
+	if len(matches) > q.Size {
+		matches = matches[:q.Size]
+		page.HasMore = true
+	}
+	page.Bookmarks = matches
+	return page, nil
+}
+
+func (repo *memoryBookmarkRepo) ForEach(ctx context.Context, ownerID int, fn func(Bookmark) error) error {
+	bookmarks, _ := repo.FindAll(ctx, ownerID)
+	for _, b := range bookmarks {
+		if err := fn(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (repo *memoryBookmarkRepo) FindByID(ctx context.Context, ownerID int, id int) (Bookmark, error) {
+	defer repo.lock(ctx)()
+	m := repo.visible(ownerID, id)
+	if m == nil {
+		return Bookmark{}, ErrBookmarkNotFound
+	}
+	return m.bookmark(), nil
+}
+
+func (repo *memoryBookmarkRepo) Create(ctx context.Context, b Bookmark) (Bookmark, error) {
+	created, err := repo.CreateAll(ctx, []Bookmark{b})
+	if err != nil {
+		return Bookmark{}, err
+	}
+	return created[0], nil
+}
+
+func (repo *memoryBookmarkRepo) CreateAll(ctx context.Context, bookmarks []Bookmark) ([]Bookmark, error) {
+	for _, b := range bookmarks {
+		if err := ValidateBookmark(b); err != nil {
+			return nil, err
+		}
+	}
+	err := repo.InTx(ctx, func(ctx context.Context) error {
+		for i := range bookmarks {
+			b := &bookmarks[i]
+			normalized := NormalizedURL(b.URL)
+			if err := repo.duplicateURLError(b.OwnerID, normalized, 0); err != nil {
+				return err
+			}
+			repo.nextID++
+			b.ID, b.Version = repo.nextID, 1
+			if b.Tags == nil {
+				b.Tags = []string{}
+			}
+			m := &memoryBookmark{Bookmark: *b, normalizedURL: normalized}
+			m.Tags = sortedTags(b.Tags)
+			repo.bookmarks[b.ID] = m
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return bookmarks, nil
+}
+
+func (repo *memoryBookmarkRepo) ExistingURLs(ctx context.Context, ownerID int, urls []string) ([]string, error) {
+	defer repo.lock(ctx)()
+	wanted := map[string]bool{}
+	for _, u := range urls {
+		wanted[u] = true
+	}
+	var existing []string
+	for _, m := range repo.sorted(ownerID) {
+		if wanted[m.normalizedURL] {
+			existing = append(existing, m.normalizedURL)
+			delete(wanted, m.normalizedURL)
+		}
+	}
+	return existing, nil
+}
+
+func (repo *memoryBookmarkRepo) Update(ctx context.Context, b Bookmark) (Bookmark, error) {
+	if err := ValidateBookmark(b); err != nil {
+		return Bookmark{}, err
+	}
+	defer repo.lock(ctx)()
+	m := repo.visible(b.OwnerID, b.ID)
+	if m == nil {
+		return Bookmark{}, ErrBookmarkNotFound
+	}
+	if b.Version != 0 && b.Version != m.Version {
+		return Bookmark{}, ErrStaleBookmark
+	}
+	normalized := NormalizedURL(b.URL)
+	if err := repo.duplicateURLError(b.OwnerID, normalized, b.ID); err != nil {
+		return Bookmark{}, err
+	}
+	// the outcome of the last link check no longer applies to a changed url
+	if m.URL != b.URL {
+		m.LinkStatusCode, m.LinkFinalURL, m.LinkCheckedDate, m.LinkFailures = nil, "", nil, 0
+	}
+	m.Title, m.URL, m.normalizedURL, m.UpdatedDate = b.Title, b.URL, normalized, b.UpdatedDate
+	m.Version++
+	if b.Tags != nil {
+		m.Tags = sortedTags(b.Tags)
+	}
+	return b, nil
+}
+
+func (repo *memoryBookmarkRepo) UpdateMetadata(ctx context.Context, b Bookmark) error {
+	defer repo.lock(ctx)()
+	m := repo.visible(b.OwnerID, b.ID)
+	if m == nil || m.Version != b.Version {
+		return ErrStaleBookmark
+	}
+	m.Title, m.Description, m.FaviconURL, m.CanonicalURL = b.Title, b.Description, b.FaviconURL, b.CanonicalURL
+	m.Version++
+	return nil
+}
+
+func (repo *memoryBookmarkRepo) UpdateContent(ctx context.Context, ownerID int, id int, content string) error {
+	defer repo.lock(ctx)()
+	if m := repo.visible(ownerID, id); m != nil {
+		m.content = content
+	}
+	return nil
+}
+
+func (repo *memoryBookmarkRepo) Delete(ctx context.Context, ownerID int, id int) error {
+	defer repo.lock(ctx)()
+	m := repo.visible(ownerID, id)
+	if m == nil {
+		return ErrBookmarkNotFound
+	}
+	now := time.Now()
+	m.DeletedDate = &now
+	return nil
+}
+
+func (repo *memoryBookmarkRepo) FindTrash(ctx context.Context, ownerID int) ([]Bookmark, error) {
+	defer repo.lock(ctx)()
+	bookmarks := []Bookmark{}
+	for _, m := range repo.bookmarks {
+		if m.OwnerID == ownerID && m.DeletedDate != nil {
+			bookmarks = append(bookmarks, m.bookmark())
+		}
+	}
+	sort.Slice(bookmarks, func(i, j int) bool {
+		a, b := bookmarks[i], bookmarks[j]
+		if !a.DeletedDate.Equal(*b.DeletedDate) {
+			return a.DeletedDate.After(*b.DeletedDate)
+		}
+		return a.ID < b.ID
+	})
+	return bookmarks, nil
+}
+
+func (repo *memoryBookmarkRepo) Restore(ctx context.Context, ownerID int, id int) (Bookmark, error) {
+	defer repo.lock(ctx)()
+	m := repo.bookmarks[id]
+	if m == nil || m.OwnerID != ownerID || m.DeletedDate == nil {
+		return Bookmark{}, ErrBookmarkNotFound
+	}
+	if err := repo.duplicateURLError(ownerID, m.normalizedURL, id); err != nil {
+		return Bookmark{}, err
+	}
+	m.DeletedDate = nil
+	return m.bookmark(), nil
+}
+
+func (repo *memoryBookmarkRepo) PurgeDeleted(ctx context.Context, deletedBefore time.Time, limit int) (int, error) {
+	defer repo.lock(ctx)()
+	var purgeable []*memoryBookmark
+	for _, m := range repo.bookmarks {
+		if m.DeletedDate != nil && m.DeletedDate.Before(deletedBefore) {
+			purgeable = append(purgeable, m)
+		}
+	}
+	sort.Slice(purgeable, func(i, j int) bool { return purgeable[i].DeletedDate.Before(*purgeable[j].DeletedDate) })
+	purgeable = purgeable[:min(limit, len(purgeable))]
+	for _, m := range purgeable {
+		delete(repo.bookmarks, m.ID)
+	}
+	return len(purgeable), nil
+}
+
+// InTx holds the lock of the repository while fn runs, so transactions are serialized.
+// When fn fails the bookmarks are restored as they were before, nested transactions
+// only roll back their own changes like savepoints.
+func (repo *memoryBookmarkRepo) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(memoryTxKey{}) != repo {
+		repo.mu.Lock()
+		defer repo.mu.Unlock()
+		ctx = context.WithValue(ctx, memoryTxKey{}, repo)
+	}
+	saved := make(map[int]*memoryBookmark, len(repo.bookmarks))
+	for id, m := range repo.bookmarks {
+		c := *m
+		c.Tags = append([]string{}, m.Tags...)
+		saved[id] = &c
+	}
+	if err := fn(ctx); err != nil {
+		repo.bookmarks = saved
+		return err
+	}
+	return nil
+}
+
+func sortedTags(tags []string) []string {
+	sorted := append([]string{}, tags...)
+	sort.Strings(sorted)
+	return sorted
+}
+
+func matchesLinkStatus(b Bookmark, status string) bool {
+	switch status {
+	case LinkBroken:
+		return b.LinkFailures > 0
+	case LinkOK:
+		return b.LinkCheckedDate != nil && b.LinkFailures == 0
+	case LinkUnchecked:
+		return b.LinkCheckedDate == nil
+	}
+	return true
+}
+
+func matchesTags(tags []string, wanted []string, anyTag bool) bool {
+	if len(wanted) == 0 {
+		return true
+	}
+	found := 0
+	for _, w := range wanted {
+		for _, t := range tags {
+			if t == w {
+				found++
+				break
+			}
+		}
+	}
+	if anyTag {
+		return found > 0
+	}
+	return found == len(wanted)
+}
+
+// compareBookmarks orders two bookmarks by the sort fields, like orderByClause.
+func compareBookmarks(a, b Bookmark, fields []SortField) int {
+	for _, f := range fields {
+		c := 0
+		switch f.Field {
+		case "id":
+			c = cmp.Compare(a.ID, b.ID)
+		case "title":
+			c = cmp.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title))
+		case "url":
+			c = cmp.Compare(a.URL, b.URL)
+		case "created_date":
+			c = a.CreatedDate.Compare(b.CreatedDate)
+		case "relevance":
+			c = cmp.Compare(a.Rank, b.Rank)
+		}
+		if f.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// searchTerms approximates websearch_to_tsquery: all words of the query must occur in the
+// title, url or page text of a bookmark, while words prefixed with '-' must not.
+type searchTerms struct {
+	include []string
+	exclude []string
+}
+
+func parseSearchTerms(query string) searchTerms {
+	var terms searchTerms
+	for _, field := range strings.Fields(query) {
+		words := searchWords(field)
+		if strings.HasPrefix(field, "-") {
+			terms.exclude = append(terms.exclude, words...)
+		} else {
+			terms.include = append(terms.include, words...)
+		}
+	}
+	return terms
+}
+
+// searchWords splits text into lower-cased words of letters and digits.
+func searchWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// rank reports whether the bookmark matches and weighs the matches like the search_vector
+// column, a match in the title counting more than one in the url or page text.
+func (terms searchTerms) rank(m *memoryBookmark) (float32, bool) {
+	weighted := []struct {
+		words  []string
+		weight float32
+	}{{searchWords(m.Title), 1}, {searchWords(m.URL), 0.4}, {searchWords(m.content), 0.2}}
+	for _, term := range terms.exclude {
+		for _, w := range weighted {
+			if containsWord(w.words, term) {
+				return 0, false
+			}
+		}
+	}
+	var rank float32
+	for _, term := range terms.include {
+		matched := false
+		for _, w := range weighted {
+			if containsWord(w.words, term) {
+				rank += w.weight
+				matched = true
+			}
+		}
+		if !matched {
+			return 0, false
+		}
+	}
+	return rank, len(terms.include) > 0
+}
+
+// snippet returns up to 35 words of the title, url and page text around the first match,
+// highlighting the matches like ts_headline.
+func (terms searchTerms) snippet(m *memoryBookmark) string {
+	words := strings.Fields(m.Title + " " + m.URL + " " + m.content)
+	first := -1
+	for i, word := range words {
+		for _, term := range terms.include {
+			if containsWord(searchWords(word), term) {
+				words[i] = matchStart + word + matchStop
+				if first < 0 {
+					first = i
+				}
+				break
+			}
+		}
+	}
+	start := max(first-5, 0)
+	return highlight(strings.Join(words[start:min(start+35, len(words))], " "))
+}
+
+func containsWord(words []string, prefix string) bool {
+	for _, w := range words {
+		if strings.HasPrefix(w, prefix) {
+			return true
+		}
+	}
+	return false
+}
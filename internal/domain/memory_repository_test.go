@@ -0,0 +1,20 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/domain"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
+	"github.com/sivaprasadreddy/bookmarks-go/testsupport"
+
+	"go.uber.org/zap"
+)
+
+func TestMemoryBookmarkRepository(t *testing.T) {
+	repo := domain.NewMemoryBookmarkRepo(&logging.Logger{SugaredLogger: zap.NewNop().Sugar()})
+	ownerID := 0
+	testsupport.RunBookmarkRepositoryContract(t, repo, func() int {
+		ownerID++
+		return ownerID
+	})
+}
@@ -1,23 +1,230 @@
 package domain
 
 import (
+	"encoding/json"
 	"time"
 )
 
 type Bookmark struct {
-	ID          int        `json:"id"`
-	Title       string     `json:"title"`
-	URL         string     `json:"url"`
+	ID      int    `json:"id"`
+	OwnerID int    `json:"-"`
+	Title   string `json:"title"`
+	URL     string `json:"url"`
+	// Description, FaviconURL and CanonicalURL are fetched from the page when the bookmark is created.
+	Description  string `json:"description"`
+	FaviconURL   string `json:"favicon_url"`
+	CanonicalURL string `json:"canonical_url"`
+	// The Link fields hold the outcome of the last dead link check, LinkFailures
+	// counts the consecutive failed checks.
+	LinkStatusCode  *int       `json:"link_status_code"`
+	LinkFinalURL    string     `json:"link_final_url"`
+	LinkCheckedDate *time.Time `json:"link_checked_date"`
+	LinkFailures    int        `json:"link_failures"`
+	// Version is incremented on every edit and sent as the ETag of the bookmark.
+	Version     int        `json:"version"`
 	CreatedDate time.Time  `json:"created_date"`
 	UpdatedDate *time.Time `json:"updated_date"`
+	// DeletedDate is set while the bookmark is in the trash.
+	DeletedDate *time.Time `json:"deleted_date,omitempty"`
+	Tags        []string   `json:"tags"`
+	// Rank and Snippet are only populated for full-text search results.
+	Rank    float32 `json:"rank,omitempty"`
+	Snippet string  `json:"snippet,omitempty"`
 }
 
 type CreateBookmarkModel struct {
-	Title string `json:"title" binding:"required"`
-	URL   string `json:"url" binding:"required,url"`
+	// Title defaults to the title of the page when omitted.
+	Title string   `json:"title"`
+	URL   string   `json:"url" binding:"required,url"`
+	Tags  []string `json:"tags" binding:"dive,max=50"`
 }
 
 type UpdateBookmarkModel struct {
 	Title string `json:"title" binding:"required"`
 	URL   string `json:"url" binding:"required,url"`
+	// Tags replaces the bookmark's tags, existing tags are kept when omitted.
+	Tags []string `json:"tags" binding:"dive,max=50"`
+}
+
+type Tag struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type User struct {
+	ID           int       `json:"id"`
+	Name         string    `json:"name"`
+	Email        string    `json:"email"`
+	PasswordHash string    `json:"-"`
+	CreatedDate  time.Time `json:"created_date"`
+}
+
+type RegisterUserModel struct {
+	Name     string `json:"name" binding:"required"`
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=8,max=72"`
+}
+
+type LoginModel struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type RefreshTokenModel struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+const (
+	ScopeRead      = "read"
+	ScopeReadWrite = "read_write"
+)
+
+type APIKey struct {
+	ID           int        `json:"id"`
+	UserID       int        `json:"-"`
+	Label        string     `json:"label"`
+	Scope        string     `json:"scope"`
+	Prefix       string     `json:"prefix"`
+	KeyHash      string     `json:"-"`
+	CreatedDate  time.Time  `json:"created_date"`
+	LastUsedDate *time.Time `json:"last_used_date"`
+}
+
+type CreateAPIKeyModel struct {
+	Label string `json:"label" binding:"required,max=100"`
+	Scope string `json:"scope" binding:"required,oneof=read read_write"`
+}
+
+const (
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	// JobDead is the status of jobs that failed MaxAttempts times, or with a
+	// permanent error, and will not be retried.
+	JobDead = "dead"
+)
+
+type Job struct {
+	ID          int             `json:"id"`
+	UserID      int             `json:"-"`
+	Kind        string          `json:"kind"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	LastError   string          `json:"last_error,omitempty"`
+	RunAt       time.Time       `json:"run_at"`
+	CreatedDate time.Time       `json:"created_date"`
+	UpdatedDate *time.Time      `json:"updated_date"`
+	// LockedDate is when the job was last claimed, a worker only records the outcome
+	// while the job is still locked by its claim.
+	LockedDate *time.Time `json:"-"`
+}
+
+// Archive describes the stored offline copy of a bookmarked page.
+type Archive struct {
+	BookmarkID  int        `json:"bookmark_id"`
+	StorageKey  string     `json:"-"`
+	Size        int64      `json:"size"`
+	ContentType string     `json:"content_type"`
+	CreatedDate time.Time  `json:"created_date"`
+	ExpiresDate *time.Time `json:"expires_date"`
+}
+
+// Collection is a folder of manually ordered bookmarks, collections without
+// a parent are at the top level. A bookmark may be in several collections.
+type Collection struct {
+	ID          int        `json:"id"`
+	OwnerID     int        `json:"-"`
+	ParentID    *int       `json:"parent_id"`
+	Name        string     `json:"name"`
+	CreatedDate time.Time  `json:"created_date"`
+	UpdatedDate *time.Time `json:"updated_date"`
+}
+
+type SaveCollectionModel struct {
+	Name     string `json:"name" binding:"required,max=100"`
+	ParentID *int   `json:"parent_id"`
+}
+
+type AddCollectionBookmarkModel struct {
+	BookmarkID int `json:"bookmark_id" binding:"required"`
+	// Position is the zero based index of the bookmark in the collection, it is appended when omitted.
+	Position *int `json:"position" binding:"omitempty,min=0"`
+}
+
+// TransferBookmarkModel moves or copies a bookmark to a collection, which
+// may be the same collection to change the position of the bookmark.
+type TransferBookmarkModel struct {
+	CollectionID int  `json:"collection_id" binding:"required"`
+	Position     *int `json:"position" binding:"omitempty,min=0"`
+}
+
+// Bulk operations applied by BulkModel.
+const (
+	BulkCreate           = "create"
+	BulkUpdate           = "update"
+	BulkDelete           = "delete"
+	BulkAddTag           = "add-tag"
+	BulkRemoveTag        = "remove-tag"
+	BulkMoveToCollection = "move-to-collection"
+)
+
+// BulkModel applies a list of operations to bookmarks, all of them in one transaction
+// in the default atomic mode or each one on its own in best-effort mode.
+type BulkModel struct {
+	Mode       string               `json:"mode" binding:"omitempty,oneof=atomic best-effort"`
+	Operations []BulkOperationModel `json:"operations" binding:"required,min=1,max=500,dive"`
+}
+
+// BulkOperationModel is one operation of a BulkModel. Bookmark holds the bookmark to
+// create or update, Tag the tag to add or remove and CollectionID the collection to
+// move the bookmark to, taking it out of all its other collections. When set, Version
+// must match the current version of the bookmark being edited.
+type BulkOperationModel struct {
+	Op           string               `json:"op" binding:"required,oneof=create update delete add-tag remove-tag move-to-collection"`
+	ID           int                  `json:"id" binding:"required_unless=Op create"`
+	Version      int                  `json:"version" binding:"min=0"`
+	Bookmark     *UpdateBookmarkModel `json:"bookmark" binding:"required_if=Op create,required_if=Op update"`
+	Tag          string               `json:"tag" binding:"required_if=Op add-tag,required_if=Op remove-tag,max=50"`
+	CollectionID int                  `json:"collection_id" binding:"required_if=Op move-to-collection"`
+	Position     *int                 `json:"position" binding:"omitempty,min=0"`
+}
+
+// Actions recorded in the audit log.
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+)
+
+// AuditEntry records a change of a bookmark, who made it and from where.
+type AuditEntry struct {
+	ID      int `json:"id"`
+	OwnerID int `json:"-"`
+	ActorID int `json:"actor_id"`
+	// APIKeyID is the API key the change was made with, if any.
+	APIKeyID    *int                   `json:"api_key_id,omitempty"`
+	BookmarkID  int                    `json:"bookmark_id"`
+	Action      string                 `json:"action"`
+	Changes     map[string]AuditChange `json:"changes"`
+	ClientIP    string                 `json:"client_ip"`
+	CreatedDate time.Time              `json:"created_date"`
+}
+
+// AuditChange is the value of a field before and after a change, nil when the field had no value.
+type AuditChange struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+type AuditQuery struct {
+	OwnerID    int
+	BookmarkID int
+	Action     string
+	Since      *time.Time
+	Until      *time.Time
+	Size       int
+	After      int
 }
@@ -0,0 +1,161 @@
+package domain
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+	DefaultSort     = "-created_date"
+)
+
+// sortExpressions maps the public sort field names onto SQL expressions,
+// where %[1]s is the alias of the bookmarks table being sorted.
+var sortExpressions = map[string]string{
+	"id":           "%[1]s.id",
+	"title":        "%[1]s.title",
+	"url":          "%[1]s.url",
+	"created_date": "%[1]s.created_at",
+	// only available for full-text searches, see bookmarkRepo.FindPage
+	"relevance": "ts_rank(%[1]s.search_vector, query)",
+}
+
+// Link statuses of bookmarks, a link is broken when its last check failed.
+const (
+	LinkBroken    = "broken"
+	LinkOK        = "ok"
+	LinkUnchecked = "unchecked"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+type BookmarkQuery struct {
+	OwnerID int
+	Query   string
+	// Tags restricts the result to bookmarks having all of the tags,
+	// or any of them when AnyTag is set.
+	Tags   []string
+	AnyTag bool
+	// LinkStatus is one of LinkBroken, LinkOK or LinkUnchecked, or empty for any.
+	LinkStatus string
+	Page       int
+	Size       int
+	After      int
+	Sort       []SortField
+}
+
+type BookmarkPage struct {
+	Bookmarks []Bookmark
+	Total     int64
+	HasMore   bool
+}
+
+// ParseSort parses a comma separated list of sort fields such as
+// "created_date,-title" where a leading '-' means descending order.
+func ParseSort(s string) ([]SortField, error) {
+	if strings.TrimSpace(s) == "" {
+		s = DefaultSort
+	}
+	var fields []SortField
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		f := SortField{Field: part}
+		if strings.HasPrefix(part, "-") {
+			f = SortField{Field: part[1:], Desc: true}
+		}
+		if _, ok := sortExpressions[f.Field]; !ok {
+			return nil, fmt.Errorf("unsupported sort field %q", f.Field)
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// EncodeCursor returns an opaque cursor pointing after the given bookmark.
+func EncodeCursor(bookmarkID int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(bookmarkID)))
+}
+
+func DecodeCursor(cursor string) (int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	id, err := strconv.Atoi(string(b))
+	if err != nil || id <= 0 {
+		return 0, ErrInvalidCursor
+	}
+	return id, nil
+}
+
+// keysetCondition builds the WHERE clause selecting rows that come after the
+// cursor row (aliased as c) for the given sort order, using the SQL expressions
+// of the sort fields such as sortExpressions. The id column is always used as
+// the final tie-breaker so that the ordering is total.
+func keysetCondition(sort []SortField, expressions map[string]string) string {
+	fields := withTieBreaker(sort)
+	var ors []string
+	for i, f := range fields {
+		var ands []string
+		for _, prev := range fields[:i] {
+			expr := expressions[prev.Field]
+			ands = append(ands, fmt.Sprintf(expr, "b")+" = "+fmt.Sprintf(expr, "c"))
+		}
+		op := " > "
+		if f.Desc {
+			op = " < "
+		}
+		expr := expressions[f.Field]
+		ands = append(ands, fmt.Sprintf(expr, "b")+op+fmt.Sprintf(expr, "c"))
+		ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+	}
+	return "(" + strings.Join(ors, " OR ") + ")"
+}
+
+func orderByClause(sort []SortField, expressions map[string]string) string {
+	var parts []string
+	for _, f := range withTieBreaker(sort) {
+		dir := "ASC"
+		if f.Desc {
+			dir = "DESC"
+		}
+		parts = append(parts, fmt.Sprintf(expressions[f.Field], "b")+" "+dir)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// HasSortField reports whether the given field is part of the sort order.
+func HasSortField(sort []SortField, field string) bool {
+	for _, f := range sort {
+		if f.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+func withTieBreaker(sort []SortField) []SortField {
+	if len(sort) == 0 {
+		sort, _ = ParseSort(DefaultSort)
+	}
+	var fields []SortField
+	for _, f := range sort {
+		fields = append(fields, f)
+		// ids are unique, so any field after it never affects the order.
+		if f.Field == "id" {
+			return fields
+		}
+	}
+	last := sort[len(sort)-1]
+	return append(fields, SortField{Field: "id", Desc: last.Desc})
+}
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSort(t *testing.T) {
+	fields, err := ParseSort("created_date,-title")
+
+	assert.Nil(t, err)
+	assert.Equal(t, []SortField{{Field: "created_date"}, {Field: "title", Desc: true}}, fields)
+
+	fields, err = ParseSort("")
+	assert.Nil(t, err)
+	assert.Equal(t, []SortField{{Field: "created_date", Desc: true}}, fields)
+
+	_, err = ParseSort("title,password")
+	assert.NotNil(t, err)
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	id, err := DecodeCursor(EncodeCursor(42))
+
+	assert.Nil(t, err)
+	assert.Equal(t, 42, id)
+
+	_, err = DecodeCursor("not a cursor")
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestKeysetCondition(t *testing.T) {
+	sort := []SortField{{Field: "created_date"}, {Field: "title", Desc: true}}
+
+	assert.Equal(t,
+		"((b.created_at > c.created_at) OR "+
+			"(b.created_at = c.created_at AND b.title < c.title) OR "+
+			"(b.created_at = c.created_at AND b.title = c.title AND b.id < c.id))",
+		keysetCondition(sort, sortExpressions))
+	assert.Equal(t, "b.created_at ASC, b.title DESC, b.id DESC", orderByClause(sort, sortExpressions))
+	assert.Equal(t, "b.id ASC", orderByClause([]SortField{{Field: "id"}, {Field: "title"}}, sortExpressions))
+}
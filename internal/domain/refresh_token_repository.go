@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrInvalidRefreshToken = errors.New("invalid refresh token")
+
+type RefreshToken struct {
+	UserID      int
+	TokenHash   string
+	ExpiresAt   time.Time
+	CreatedDate time.Time
+}
+
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token RefreshToken) error
+	// Rotate revokes the still valid token with the given hash and stores next
+	// in its place for the same user, returning that user's id. Presenting an
+	// already revoked token revokes all tokens of its user since it has most
+	// likely been stolen.
+	Rotate(ctx context.Context, tokenHash string, next RefreshToken) (int, error)
+	Revoke(ctx context.Context, tokenHash string) error
+}
+
+type refreshTokenRepo struct {
+	db     DB
+	logger *logging.Logger
+}
+
+func NewRefreshTokenRepo(db DB, logger *logging.Logger) RefreshTokenRepository {
+	return &refreshTokenRepo{db: db, logger: logger}
+}
+
+func (repo *refreshTokenRepo) Create(ctx context.Context, t RefreshToken) error {
+	sql := "insert into refresh_tokens(user_id, token_hash, expires_at, created_at) values($1, $2, $3, $4)"
+	_, err := conn(ctx, repo.db).Exec(ctx, sql, t.UserID, t.TokenHash, t.ExpiresAt, t.CreatedDate)
+	return err
+}
+
+func (repo *refreshTokenRepo) Rotate(ctx context.Context, tokenHash string, next RefreshToken) (int, error) {
+	err := pgx.BeginFunc(ctx, conn(ctx, repo.db), func(tx pgx.Tx) error {
+		sql := `update refresh_tokens set revoked_at=$2
+				where token_hash=$1 and revoked_at is null and expires_at > $2 RETURNING user_id`
+		err := tx.QueryRow(ctx, sql, tokenHash, next.CreatedDate).Scan(&next.UserID)
+		if err != nil {
+			return err
+		}
+		sql = "insert into refresh_tokens(user_id, token_hash, expires_at, created_at) values($1, $2, $3, $4)"
+		_, err = tx.Exec(ctx, sql, next.UserID, next.TokenHash, next.ExpiresAt, next.CreatedDate)
+		return err
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, repo.handleInvalidToken(ctx, tokenHash)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return next.UserID, nil
+}
+
+func (repo *refreshTokenRepo) handleInvalidToken(ctx context.Context, tokenHash string) error {
+	var userID int
+	var revokedAt *time.Time
+	sql := "select user_id, revoked_at from refresh_tokens where token_hash=$1"
+	err := conn(ctx, repo.db).QueryRow(ctx, sql, tokenHash).Scan(&userID, &revokedAt)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && revokedAt == nil) {
+		// unknown or expired
+		return ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return err
+	}
+	repo.logger.Warnf("Revoked refresh token reused, revoking all tokens of user id=%d", userID)
+	sql = "update refresh_tokens set revoked_at=$2 where user_id=$1 and revoked_at is null"
+	if _, err = conn(ctx, repo.db).Exec(ctx, sql, userID, time.Now()); err != nil {
+		return err
+	}
+	return ErrInvalidRefreshToken
+}
+
+func (repo *refreshTokenRepo) Revoke(ctx context.Context, tokenHash string) error {
+	sql := "update refresh_tokens set revoked_at=$2 where token_hash=$1 and revoked_at is null"
+	_, err := conn(ctx, repo.db).Exec(ctx, sql, tokenHash, time.Now())
+	return err
+}
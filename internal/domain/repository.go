@@ -2,84 +2,509 @@ package domain
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
+// BookmarkRepository gives access to the bookmarks of a single owner, every
+// method is scoped to the ownerID passed in or the OwnerID of the bookmark.
 type BookmarkRepository interface {
-	FindAll(ctx context.Context) ([]Bookmark, error)
-	FindByID(ctx context.Context, bookmarkID int) (Bookmark, error)
+	FindAll(ctx context.Context, ownerID int) ([]Bookmark, error)
+	FindPage(ctx context.Context, query BookmarkQuery) (BookmarkPage, error)
+	// ForEach streams all bookmarks of the owner, including their tags, in
+	// id order without loading them all in memory. Iteration stops at the
+	// first error returned by fn.
+	ForEach(ctx context.Context, ownerID int, fn func(Bookmark) error) error
+	FindByID(ctx context.Context, ownerID int, bookmarkID int) (Bookmark, error)
 	Create(ctx context.Context, bookmark Bookmark) (Bookmark, error)
+	// CreateAll inserts the bookmarks and their tags in one transaction using batched statements.
+	CreateAll(ctx context.Context, bookmarks []Bookmark) ([]Bookmark, error)
+	// ExistingURLs returns those of the given normalized urls the owner already bookmarked.
+	ExistingURLs(ctx context.Context, ownerID int, urls []string) ([]string, error)
+	// Update replaces the title, url and, unless nil, the tags of the bookmark. When the
+	// Version of bookmark is set the update fails with ErrStaleBookmark unless it is current.
 	Update(ctx context.Context, bookmark Bookmark) (Bookmark, error)
-	Delete(ctx context.Context, bookmarkID int) error
+	// UpdateMetadata stores the title and the page metadata of the bookmark. It fails with
+	// ErrStaleBookmark unless the Version of bookmark is current, so that changes made while
+	// the page was fetched are kept.
+	UpdateMetadata(ctx context.Context, bookmark Bookmark) error
+	// UpdateContent stores the readable text of the bookmarked page, which is searched by FindPage.
+	UpdateContent(ctx context.Context, ownerID int, bookmarkID int, content string) error
+	// Delete moves the bookmark to the trash, where all other methods but FindTrash,
+	// Restore and PurgeDeleted leave it out.
+	Delete(ctx context.Context, ownerID int, bookmarkID int) error
+	// FindTrash returns the trashed bookmarks of the owner, the most recently deleted first.
+	FindTrash(ctx context.Context, ownerID int) ([]Bookmark, error)
+	// Restore moves the bookmark back out of the trash. It fails with a DuplicateURLError
+	// when the url was bookmarked again in the meantime.
+	Restore(ctx context.Context, ownerID int, bookmarkID int) (Bookmark, error)
+	// PurgeDeleted permanently deletes up to limit bookmarks of any owner trashed
+	// before deletedBefore and returns how many were deleted.
+	PurgeDeleted(ctx context.Context, deletedBefore time.Time, limit int) (int, error)
+	// InTx runs fn in a transaction, committed when fn returns nil. Repositories
+	// called with the context passed to fn take part in the transaction.
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// bookmarkColumns are the columns read by scanBookmark, the bookmarks table must be aliased as b.
+const bookmarkColumns = "b.id, b.owner_id, b.title, b.url, b.description, b.favicon_url, b.canonical_url, " +
+	"b.link_status_code, b.link_final_url, b.link_checked_at, b.link_failures, b.version, b.created_at, b.updated_at, " +
+	"b.deleted_at"
+
+const insertBookmarkSQL = "insert into bookmarks(owner_id, title, url, normalized_url, description, favicon_url, " +
+	"canonical_url, created_at, updated_at) values($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, version"
+
+var (
+	ErrBookmarkNotFound = newError(ErrNotFound, "bookmark not found")
+	ErrStaleBookmark    = newError(ErrPreconditionFailed, "bookmark was modified since it was read")
+)
+
+// ErrDuplicateURL is matched by a DuplicateURLError using errors.Is.
+var ErrDuplicateURL = newError(ErrConflict, "url is already bookmarked")
+
+// DuplicateURLError is returned when saving a bookmark whose normalized url
+// is the same as the one of another bookmark of the owner.
+type DuplicateURLError struct {
+	ExistingID int
+}
+
+func (e *DuplicateURLError) Error() string {
+	return fmt.Sprintf("url is already bookmarked by bookmark %d", e.ExistingID)
+}
+
+func (e *DuplicateURLError) Is(target error) bool {
+	return target == ErrDuplicateURL || target == ErrConflict
 }
 
 type bookmarkRepo struct {
-	db     *pgx.Conn
+	db     DB
 	logger *logging.Logger
 }
 
-func NewBookmarkRepo(db *pgx.Conn, logger *logging.Logger) BookmarkRepository {
+func NewBookmarkRepo(db DB, logger *logging.Logger) BookmarkRepository {
 	return &bookmarkRepo{db: db, logger: logger}
 }
 
-func (repo *bookmarkRepo) FindAll(ctx context.Context) ([]Bookmark, error) {
-	sql := "SELECT id, title, url, created_at, updated_at FROM bookmarks"
-	rows, err := repo.db.Query(ctx, sql)
+func (repo *bookmarkRepo) FindAll(ctx context.Context, ownerID int) ([]Bookmark, error) {
+	sql := "SELECT " + bookmarkColumns + " FROM bookmarks b WHERE b.owner_id=$1 AND b.deleted_at IS NULL"
+	rows, err := conn(ctx, repo.db).Query(ctx, sql, ownerID)
 	if err != nil {
 		return nil, err
 	}
 	var bookmarks []Bookmark
 	defer rows.Close()
 	for rows.Next() {
-		var b = Bookmark{}
-		err = rows.Scan(&b.ID, &b.Title, &b.URL, &b.CreatedDate, &b.UpdatedDate)
+		b, err := scanBookmark(rows)
 		if err != nil {
 			return nil, err
 		}
 		bookmarks = append(bookmarks, b)
 	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if err = loadTags(ctx, conn(ctx, repo.db), bookmarks); err != nil {
+		return nil, err
+	}
 	return bookmarks, nil
 }
 
-func (repo *bookmarkRepo) FindByID(ctx context.Context, id int) (Bookmark, error) {
+func (repo *bookmarkRepo) FindPage(ctx context.Context, q BookmarkQuery) (BookmarkPage, error) {
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	columns := bookmarkColumns
+	from := "bookmarks b"
+	where := []string{"b.owner_id = " + arg(q.OwnerID), "b.deleted_at IS NULL"}
+	if q.Query != "" {
+		from += " CROSS JOIN websearch_to_tsquery('english', " + arg(q.Query) + ") query"
+		where = append(where, "b.search_vector @@ query")
+		// the snippet shows the fragments of the title, url or page text that matched
+		columns += ", ts_rank(b.search_vector, query), ts_headline('english', b.title || ' ' || b.url || E'\\n' || " +
+			"left(b.content, 100000), query, " + arg(headlineOptions) + ")"
+	}
+	switch q.LinkStatus {
+	case LinkBroken:
+		where = append(where, "b.link_failures > 0")
+	case LinkOK:
+		where = append(where, "b.link_checked_at IS NOT NULL AND b.link_failures = 0")
+	case LinkUnchecked:
+		where = append(where, "b.link_checked_at IS NULL")
+	}
+	if len(q.Tags) > 0 {
+		tagged := "SELECT bt.bookmark_id FROM bookmark_tags bt JOIN tags t ON t.id = bt.tag_id WHERE t.name = ANY(" +
+			arg(q.Tags) + ")"
+		if !q.AnyTag {
+			tagged += " GROUP BY bt.bookmark_id HAVING count(*) = " + arg(len(q.Tags))
+		}
+		where = append(where, "b.id IN ("+tagged+")")
+	}
+
+	var page BookmarkPage
+	countSQL := "SELECT count(*) FROM " + from + whereClause(where)
+	err := conn(ctx, repo.db).QueryRow(ctx, countSQL, args...).Scan(&page.Total)
+	if err != nil {
+		return BookmarkPage{}, err
+	}
+
+	if q.After > 0 {
+		from += " JOIN bookmarks c ON c.id = " + arg(q.After) + " AND c.owner_id = b.owner_id"
+		where = append(where, keysetCondition(q.Sort, sortExpressions))
+	}
+	// fetch one extra row to find out whether there is a next page
+	sql := fmt.Sprintf("SELECT %s FROM %s%s ORDER BY %s LIMIT %s",
+		columns, from, whereClause(where), orderByClause(q.Sort, sortExpressions), arg(q.Size+1))
+	if q.After == 0 && q.Page > 1 {
+		sql += " OFFSET " + arg((q.Page-1)*q.Size)
+	}
+
+	rows, err := conn(ctx, repo.db).Query(ctx, sql, args...)
+	if err != nil {
+		return BookmarkPage{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var rank float32
+		var snippet string
+		var extra []any
+		if q.Query != "" {
+			extra = []any{&rank, &snippet}
+		}
+		b, err := scanBookmark(rows, extra...)
+		if err != nil {
+			return BookmarkPage{}, err
+		}
+		b.Rank, b.Snippet = rank, highlight(snippet)
+		page.Bookmarks = append(page.Bookmarks, b)
+	}
+	if err = rows.Err(); err != nil {
+		return BookmarkPage{}, err
+	}
+	if len(page.Bookmarks) > q.Size {
+		page.Bookmarks = page.Bookmarks[:q.Size]
+		page.HasMore = true
+	}
+	if err = loadTags(ctx, conn(ctx, repo.db), page.Bookmarks); err != nil {
+		return BookmarkPage{}, err
+	}
+	return page, nil
+}
+
+func (repo *bookmarkRepo) ForEach(ctx context.Context, ownerID int, fn func(Bookmark) error) error {
+	sql := `SELECT ` + bookmarkColumns + `,
+			coalesce(array_agg(t.name ORDER BY t.name) FILTER (WHERE t.name IS NOT NULL), '{}')
+			FROM bookmarks b
+			LEFT JOIN bookmark_tags bt ON bt.bookmark_id = b.id
+			LEFT JOIN tags t ON t.id = bt.tag_id
+			WHERE b.owner_id = $1 AND b.deleted_at IS NULL
+			GROUP BY b.id ORDER BY b.id`
+	rows, err := conn(ctx, repo.db).Query(ctx, sql, ownerID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var tags []string
+		b, err := scanBookmark(rows, &tags)
+		if err != nil {
+			return err
+		}
+		b.Tags = tags
+		if err = fn(b); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (repo *bookmarkRepo) FindByID(ctx context.Context, ownerID int, id int) (Bookmark, error) {
 	repo.logger.Infof("Fetching bookmark with id=%d", id)
-	var b = Bookmark{}
-	sql := "select id, title, url, created_at, updated_at FROM bookmarks where id=$1"
-	err := repo.db.QueryRow(ctx, sql, id).Scan(
-		&b.ID, &b.Title, &b.URL, &b.CreatedDate, &b.UpdatedDate)
+	sql := "select " + bookmarkColumns + " FROM bookmarks b where b.id=$1 and b.owner_id=$2 and b.deleted_at IS NULL"
+	b, err := scanBookmark(conn(ctx, repo.db).QueryRow(ctx, sql, id, ownerID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Bookmark{}, ErrBookmarkNotFound
+	}
 	if err != nil {
 		return Bookmark{}, err
 	}
-	return b, nil
+	bookmarks := []Bookmark{b}
+	if err = loadTags(ctx, conn(ctx, repo.db), bookmarks); err != nil {
+		return Bookmark{}, err
+	}
+	return bookmarks[0], nil
 }
 
 func (repo *bookmarkRepo) Create(ctx context.Context, b Bookmark) (Bookmark, error) {
-	var lastInsertID int
-	sql := "insert into bookmarks(title, url, created_at, updated_at) values($1, $2, $3, $4) RETURNING id"
-	err := repo.db.QueryRow(ctx, sql, b.Title, b.URL, b.CreatedDate, b.UpdatedDate).
-		Scan(&lastInsertID)
+	if err := ValidateBookmark(b); err != nil {
+		return Bookmark{}, err
+	}
+	err := pgx.BeginFunc(ctx, conn(ctx, repo.db), func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, insertBookmarkSQL, b.OwnerID, b.Title, b.URL, NormalizedURL(b.URL), b.Description,
+			b.FaviconURL, b.CanonicalURL, b.CreatedDate, b.UpdatedDate).Scan(&b.ID, &b.Version)
+		if err != nil {
+			return err
+		}
+		return saveTags(ctx, tx, b.ID, b.Tags)
+	})
 	if err != nil {
+		if isDuplicateURL(err) {
+			return Bookmark{}, repo.duplicateURLError(ctx, b)
+		}
 		repo.logger.Errorf("Error while inserting bookmark row: %v", err)
 		return Bookmark{}, err
 	}
-	b.ID = lastInsertID
+	if b.Tags == nil {
+		b.Tags = []string{}
+	}
 	return b, nil
 }
 
+func (repo *bookmarkRepo) CreateAll(ctx context.Context, bookmarks []Bookmark) ([]Bookmark, error) {
+	if len(bookmarks) == 0 {
+		return bookmarks, nil
+	}
+	for _, b := range bookmarks {
+		if err := ValidateBookmark(b); err != nil {
+			return nil, err
+		}
+	}
+	err := pgx.BeginFunc(ctx, conn(ctx, repo.db), func(tx pgx.Tx) error {
+		batch := &pgx.Batch{}
+		for i := range bookmarks {
+			b := &bookmarks[i]
+			batch.Queue(insertBookmarkSQL, b.OwnerID, b.Title, b.URL, NormalizedURL(b.URL), b.Description,
+				b.FaviconURL, b.CanonicalURL, b.CreatedDate, b.UpdatedDate).
+				QueryRow(func(row pgx.Row) error {
+					return row.Scan(&b.ID, &b.Version)
+				})
+		}
+		if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+			return err
+		}
+		return saveAllTags(ctx, tx, bookmarks)
+	})
+	if err != nil {
+		repo.logger.Errorf("Error while inserting bookmark rows: %v", err)
+		return nil, err
+	}
+	for i := range bookmarks {
+		if bookmarks[i].Tags == nil {
+			bookmarks[i].Tags = []string{}
+		}
+	}
+	return bookmarks, nil
+}
+
+func (repo *bookmarkRepo) ExistingURLs(ctx context.Context, ownerID int, urls []string) ([]string, error) {
+	// bookmarks saved before urls were normalized are matched by their url
+	sql := "SELECT DISTINCT coalesce(normalized_url, url) FROM bookmarks " +
+		"WHERE owner_id=$1 AND deleted_at IS NULL AND coalesce(normalized_url, url) = ANY($2)"
+	rows, err := conn(ctx, repo.db).Query(ctx, sql, ownerID, urls)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
 func (repo *bookmarkRepo) Update(ctx context.Context, b Bookmark) (Bookmark, error) {
-	sql := "update bookmarks set title = $1, url=$2, updated_at=$3 where id=$4"
-	_, err := repo.db.Exec(ctx, sql, b.Title, b.URL, b.UpdatedDate, b.ID)
+	if err := ValidateBookmark(b); err != nil {
+		return Bookmark{}, err
+	}
+	err := pgx.BeginFunc(ctx, conn(ctx, repo.db), func(tx pgx.Tx) error {
+		// the outcome of the last link check no longer applies to a changed url
+		sql := `update bookmarks set title = $1, url=$2, normalized_url=$6, updated_at=$3, version = version + 1,
+				link_status_code = CASE WHEN url = $2 THEN link_status_code END,
+				link_final_url = CASE WHEN url = $2 THEN link_final_url ELSE '' END,
+				link_checked_at = CASE WHEN url = $2 THEN link_checked_at END,
+				link_failures = CASE WHEN url = $2 THEN link_failures ELSE 0 END
+				where id=$4 and owner_id=$5 and deleted_at IS NULL and ($7 = 0 or version = $7)`
+		result, err := tx.Exec(ctx, sql, b.Title, b.URL, b.UpdatedDate, b.ID, b.OwnerID, NormalizedURL(b.URL),
+			b.Version)
+		if err != nil {
+			return err
+		}
+		if result.RowsAffected() == 0 {
+			var exists bool
+			sql = "SELECT EXISTS (SELECT 1 FROM bookmarks WHERE id=$1 AND owner_id=$2 AND deleted_at IS NULL)"
+			if err = tx.QueryRow(ctx, sql, b.ID, b.OwnerID).Scan(&exists); err != nil {
+				return err
+			}
+			if exists {
+				return ErrStaleBookmark
+			}
+			return ErrBookmarkNotFound
+		}
+		if b.Tags == nil {
+			return nil
+		}
+		return saveTags(ctx, tx, b.ID, b.Tags)
+	})
+	if isDuplicateURL(err) {
+		return Bookmark{}, repo.duplicateURLError(ctx, b)
+	}
 	if err != nil {
 		return Bookmark{}, err
 	}
 	return b, nil
 }
 
-func (repo *bookmarkRepo) Delete(ctx context.Context, id int) error {
-	sql := "delete from bookmarks where id=$1"
-	_, err := repo.db.Exec(ctx, sql, id)
+func (repo *bookmarkRepo) UpdateMetadata(ctx context.Context, b Bookmark) error {
+	sql := `update bookmarks set title=$1, description=$2, favicon_url=$3, canonical_url=$4, version = version + 1
+			where id=$5 and owner_id=$6 and deleted_at IS NULL and version=$7`
+	result, err := conn(ctx, repo.db).Exec(ctx, sql, b.Title, b.Description, b.FaviconURL, b.CanonicalURL, b.ID,
+		b.OwnerID, b.Version)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrStaleBookmark
+	}
+	return nil
+}
+
+func (repo *bookmarkRepo) UpdateContent(ctx context.Context, ownerID int, id int, content string) error {
+	sql := "update bookmarks set content=$1 where id=$2 and owner_id=$3 and deleted_at IS NULL"
+	_, err := conn(ctx, repo.db).Exec(ctx, sql, content, id, ownerID)
 	return err
 }
+
+func (repo *bookmarkRepo) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return inTx(ctx, repo.db, fn)
+}
+
+func (repo *bookmarkRepo) Delete(ctx context.Context, ownerID int, id int) error {
+	sql := "update bookmarks set deleted_at=$3 where id=$1 and owner_id=$2 and deleted_at IS NULL"
+	result, err := conn(ctx, repo.db).Exec(ctx, sql, id, ownerID, time.Now())
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrBookmarkNotFound
+	}
+	return nil
+}
+
+func (repo *bookmarkRepo) FindTrash(ctx context.Context, ownerID int) ([]Bookmark, error) {
+	sql := "SELECT " + bookmarkColumns + ` FROM bookmarks b WHERE b.owner_id=$1 AND b.deleted_at IS NOT NULL
+			ORDER BY b.deleted_at DESC, b.id`
+	rows, err := conn(ctx, repo.db).Query(ctx, sql, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	bookmarks := []Bookmark{}
+	for rows.Next() {
+		b, err := scanBookmark(rows)
+		if err != nil {
+			return nil, err
+		}
+		bookmarks = append(bookmarks, b)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if err = loadTags(ctx, conn(ctx, repo.db), bookmarks); err != nil {
+		return nil, err
+	}
+	return bookmarks, nil
+}
+
+func (repo *bookmarkRepo) Restore(ctx context.Context, ownerID int, id int) (Bookmark, error) {
+	b := Bookmark{ID: id, OwnerID: ownerID}
+	err := pgx.BeginFunc(ctx, conn(ctx, repo.db), func(tx pgx.Tx) error {
+		sql := "SELECT url FROM bookmarks WHERE id=$1 AND owner_id=$2 AND deleted_at IS NOT NULL FOR UPDATE"
+		err := tx.QueryRow(ctx, sql, id, ownerID).Scan(&b.URL)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrBookmarkNotFound
+		}
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, "update bookmarks set deleted_at=NULL where id=$1", id)
+		return err
+	})
+	if isDuplicateURL(err) {
+		return Bookmark{}, repo.duplicateURLError(ctx, b)
+	}
+	if err != nil {
+		return Bookmark{}, err
+	}
+	return repo.FindByID(ctx, ownerID, id)
+}
+
+func (repo *bookmarkRepo) PurgeDeleted(ctx context.Context, deletedBefore time.Time, limit int) (int, error) {
+	sql := `delete from bookmarks where id IN (
+				SELECT id FROM bookmarks WHERE deleted_at < $1 ORDER BY deleted_at LIMIT $2)`
+	result, err := conn(ctx, repo.db).Exec(ctx, sql, deletedBefore, limit)
+	if err != nil {
+		return 0, err
+	}
+	return int(result.RowsAffected()), nil
+}
+
+// MaxTagLength is the maximum number of characters of a tag.
+const MaxTagLength = 50
+
+// ValidateBookmark checks the bookmark has a title, an absolute http or https url and
+// no tag longer than MaxTagLength.
+func ValidateBookmark(b Bookmark) error {
+	if strings.TrimSpace(b.Title) == "" {
+		return newError(ErrValidation, "title must not be blank")
+	}
+	u, err := url.Parse(b.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return newError(ErrValidation, "url must be an absolute http or https url")
+	}
+	for _, tag := range b.Tags {
+		if utf8.RuneCountInString(tag) > MaxTagLength {
+			return newError(ErrValidation, fmt.Sprintf("tag %q is longer than %d characters", tag, MaxTagLength))
+		}
+	}
+	return nil
+}
+
+func isDuplicateURL(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "bookmarks_owner_normalized_url_key"
+}
+
+// duplicateURLError looks up the bookmark of the owner having the same normalized url as b.
+func (repo *bookmarkRepo) duplicateURLError(ctx context.Context, b Bookmark) error {
+	dup := &DuplicateURLError{}
+	sql := "SELECT id FROM bookmarks WHERE owner_id=$1 AND normalized_url=$2 AND deleted_at IS NULL"
+	if err := conn(ctx, repo.db).QueryRow(ctx, sql, b.OwnerID, NormalizedURL(b.URL)).Scan(&dup.ExistingID); err != nil {
+		return err
+	}
+	return dup
+}
+
+// scanBookmark scans a row selecting bookmarkColumns followed by the extra destinations.
+func scanBookmark(row pgx.Row, extra ...any) (Bookmark, error) {
+	var b Bookmark
+	dest := append([]any{&b.ID, &b.OwnerID, &b.Title, &b.URL, &b.Description, &b.FaviconURL, &b.CanonicalURL,
+		&b.LinkStatusCode, &b.LinkFinalURL, &b.LinkCheckedDate, &b.LinkFailures, &b.Version, &b.CreatedDate,
+		&b.UpdatedDate, &b.DeletedDate},
+		extra...)
+	if err := row.Scan(dest...); err != nil {
+		return Bookmark{}, err
+	}
+	return b, nil
+}
+
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
@@ -0,0 +1,22 @@
+package domain
+
+import (
+	"html"
+	"strings"
+)
+
+// Matches are delimited with control characters by ts_headline so that the
+// snippet can be HTML escaped before the <mark> tags are put in.
+const (
+	matchStart = "\x02"
+	matchStop  = "\x03"
+)
+
+var headlineOptions = "StartSel=" + matchStart + ", StopSel=" + matchStop +
+	`, MaxWords=35, MinWords=15, MaxFragments=2, FragmentDelimiter=" ... "`
+
+// highlight turns a ts_headline snippet into HTML, wrapping matches in <mark>.
+func highlight(snippet string) string {
+	return strings.NewReplacer(matchStart, "<mark>", matchStop, "</mark>").
+		Replace(html.EscapeString(snippet))
+}
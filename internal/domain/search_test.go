@@ -0,0 +1,13 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHighlight(t *testing.T) {
+	snippet := highlight("Go <generics> " + matchStart + "tutorial" + matchStop + " & tips")
+
+	assert.Equal(t, "Go &lt;generics&gt; <mark>tutorial</mark> &amp; tips", snippet)
+}
@@ -0,0 +1,146 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// The sqlite*Repo types store everything in a SQLite database instead of Postgres, for
+// single-user deployments. They behave like their Postgres counterparts except that
+// searches match the Porter stems of words instead of their English stems.
+
+// sqliteQuerier is satisfied by both *sql.DB and *sql.Tx.
+type sqliteQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// sqliteDB runs statements on a database or a transaction. Times are stored as text, so
+// they are passed in UTC to compare in chronological order.
+type sqliteDB struct {
+	q sqliteQuerier
+}
+
+func (db sqliteDB) Exec(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return db.q.ExecContext(ctx, query, utcArgs(args)...)
+}
+
+func (db sqliteDB) Query(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return db.q.QueryContext(ctx, query, utcArgs(args)...)
+}
+
+func (db sqliteDB) QueryRow(ctx context.Context, query string, args ...any) *sql.Row {
+	return db.q.QueryRowContext(ctx, query, utcArgs(args)...)
+}
+
+func utcArgs(args []any) []any {
+	converted := make([]any, len(args))
+	for i, arg := range args {
+		switch t := arg.(type) {
+		case time.Time:
+			converted[i] = t.UTC()
+		case *time.Time:
+			if t != nil {
+				converted[i] = t.UTC()
+			}
+		default:
+			converted[i] = arg
+		}
+	}
+	return converted
+}
+
+type sqliteTxKey struct{}
+
+// sqliteTx is the transaction started by sqliteInTx, depth counts the nested transactions.
+type sqliteTx struct {
+	tx    *sql.Tx
+	depth int
+}
+
+// sqliteInTx runs fn in a transaction which is committed when fn returns nil and rolled
+// back otherwise. Repositories called with the context passed to fn run their statements
+// in the transaction, nested transactions become savepoints.
+func sqliteInTx(ctx context.Context, db *sql.DB, fn func(ctx context.Context) error) error {
+	if outer, ok := ctx.Value(sqliteTxKey{}).(sqliteTx); ok {
+		nested := sqliteTx{tx: outer.tx, depth: outer.depth + 1}
+		savepoint := fmt.Sprintf("sp%d", nested.depth)
+		if _, err := outer.tx.ExecContext(ctx, "SAVEPOINT "+savepoint); err != nil {
+			return err
+		}
+		if err := fn(context.WithValue(ctx, sqliteTxKey{}, nested)); err != nil {
+			if _, rollbackErr := outer.tx.ExecContext(ctx, "ROLLBACK TO "+savepoint); rollbackErr != nil {
+				return rollbackErr
+			}
+			_, _ = outer.tx.ExecContext(ctx, "RELEASE "+savepoint)
+			return err
+		}
+		_, err := outer.tx.ExecContext(ctx, "RELEASE "+savepoint)
+		return err
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if err = fn(context.WithValue(ctx, sqliteTxKey{}, sqliteTx{tx: tx})); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// sqliteConn returns the transaction started by sqliteInTx for ctx, or db outside of transactions.
+func sqliteConn(ctx context.Context, db *sql.DB) sqliteDB {
+	if tx, ok := ctx.Value(sqliteTxKey{}).(sqliteTx); ok {
+		return sqliteDB{q: tx.tx}
+	}
+	return sqliteDB{q: db}
+}
+
+// sqliteRowsAffected returns the number of rows changed by a statement, which the
+// SQLite driver always knows.
+func sqliteRowsAffected(result sql.Result) int64 {
+	n, _ := result.RowsAffected()
+	return n
+}
+
+// sqliteNoRows replaces sql.ErrNoRows with notFound, like noRows does for the Postgres repositories.
+func sqliteNoRows(err error, notFound error) error {
+	if isSQLiteNoRows(err) {
+		return notFound
+	}
+	return err
+}
+
+// isSQLiteNoRows reports whether err is sql.ErrNoRows, for the repositories whose
+// local sql variables shadow the package.
+func isSQLiteNoRows(err error) bool {
+	return errors.Is(err, sql.ErrNoRows)
+}
+
+// isSQLiteUniqueViolation reports whether err violates a unique constraint on the given
+// column, such as "users.email".
+func isSQLiteUniqueViolation(err error, column string) bool {
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE &&
+		strings.Contains(sqliteErr.Error(), column)
+}
+
+// jsonArray encodes values as a JSON array, which SQLite statements expand with json_each
+// where Postgres statements take an array.
+func jsonArray[T any](values []T) string {
+	if values == nil {
+		return "[]"
+	}
+	b, _ := json.Marshal(values)
+	return string(b)
+}
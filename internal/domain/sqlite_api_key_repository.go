@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
+)
+
+type sqliteAPIKeyRepo struct {
+	db     *sql.DB
+	logger *logging.Logger
+}
+
+func NewSQLiteAPIKeyRepo(db *sql.DB, logger *logging.Logger) APIKeyRepository {
+	return &sqliteAPIKeyRepo{db: db, logger: logger}
+}
+
+func (repo *sqliteAPIKeyRepo) FindAll(ctx context.Context, userID int) ([]APIKey, error) {
+	sql := `SELECT id, user_id, label, scope, prefix, created_at, last_used_at FROM api_keys
+			WHERE user_id=$1 AND revoked_at IS NULL ORDER BY id`
+	rows, err := sqliteConn(ctx, repo.db).Query(ctx, sql, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var keys []APIKey
+	for rows.Next() {
+		var k = APIKey{}
+		err = rows.Scan(&k.ID, &k.UserID, &k.Label, &k.Scope, &k.Prefix, &k.CreatedDate, &k.LastUsedDate)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+func (repo *sqliteAPIKeyRepo) Create(ctx context.Context, k APIKey) (APIKey, error) {
+	var lastInsertID int
+	sql := `insert into api_keys(user_id, label, scope, prefix, key_hash, created_at)
+			values($1, $2, $3, $4, $5, $6) RETURNING id`
+	err := sqliteConn(ctx, repo.db).QueryRow(ctx, sql, k.UserID, k.Label, k.Scope, k.Prefix, k.KeyHash,
+		k.CreatedDate).Scan(&lastInsertID)
+	if err != nil {
+		repo.logger.Errorf("Error while inserting api key row: %v", err)
+		return APIKey{}, err
+	}
+	k.ID = lastInsertID
+	return k, nil
+}
+
+func (repo *sqliteAPIKeyRepo) Revoke(ctx context.Context, userID int, id int) error {
+	sql := "update api_keys set revoked_at=$3 where id=$1 and user_id=$2 and revoked_at is null"
+	result, err := sqliteConn(ctx, repo.db).Exec(ctx, sql, id, userID, time.Now())
+	if err != nil {
+		return err
+	}
+	if sqliteRowsAffected(result) == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func (repo *sqliteAPIKeyRepo) Authenticate(ctx context.Context, keyHash string, usedAt time.Time) (APIKey, error) {
+	var k = APIKey{}
+	sql := `update api_keys set last_used_at=$2 where key_hash=$1 and revoked_at is null
+			RETURNING id, user_id, label, scope, prefix, created_at, last_used_at`
+	err := sqliteConn(ctx, repo.db).QueryRow(ctx, sql, keyHash, usedAt).
+		Scan(&k.ID, &k.UserID, &k.Label, &k.Scope, &k.Prefix, &k.CreatedDate, &k.LastUsedDate)
+	if err != nil {
+		return APIKey{}, sqliteNoRows(err, ErrAPIKeyNotFound)
+	}
+	return k, nil
+}
@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
+)
+
+type sqliteArchiveRepo struct {
+	db     *sql.DB
+	logger *logging.Logger
+}
+
+func NewSQLiteArchiveRepo(db *sql.DB, logger *logging.Logger) ArchiveRepository {
+	return &sqliteArchiveRepo{db: db, logger: logger}
+}
+
+func (repo *sqliteArchiveRepo) FindByBookmarkID(ctx context.Context, ownerID int, bookmarkID int) (Archive, error) {
+	sql := "SELECT " + archiveColumns + ` FROM archives a JOIN bookmarks b ON b.id = a.bookmark_id
+			WHERE a.bookmark_id=$1 AND b.owner_id=$2 AND b.deleted_at IS NULL`
+	a, err := scanArchive(sqliteConn(ctx, repo.db).QueryRow(ctx, sql, bookmarkID, ownerID))
+	return a, sqliteNoRows(err, ErrArchiveNotFound)
+}
+
+func (repo *sqliteArchiveRepo) Save(ctx context.Context, a Archive) error {
+	sql := `insert into archives(bookmark_id, storage_key, size, content_type, created_at, expires_at)
+			values($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (bookmark_id) DO UPDATE SET storage_key=excluded.storage_key, size=excluded.size,
+				content_type=excluded.content_type, created_at=excluded.created_at, expires_at=excluded.expires_at`
+	_, err := sqliteConn(ctx, repo.db).Exec(ctx, sql, a.BookmarkID, a.StorageKey, a.Size, a.ContentType,
+		a.CreatedDate, a.ExpiresDate)
+	if err != nil {
+		repo.logger.Errorf("Error while saving archive row: %v", err)
+	}
+	return err
+}
+
+func (repo *sqliteArchiveRepo) FindPurgeable(ctx context.Context, now time.Time, limit int) ([]Archive, error) {
+	sql := "SELECT " + archiveColumns + ` FROM archives a
+			WHERE a.expires_at < $1 OR NOT EXISTS (SELECT 1 FROM bookmarks b WHERE b.id = a.bookmark_id)
+			ORDER BY a.bookmark_id LIMIT $2`
+	rows, err := sqliteConn(ctx, repo.db).Query(ctx, sql, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var archives []Archive
+	for rows.Next() {
+		a, err := scanArchive(rows)
+		if err != nil {
+			return nil, err
+		}
+		archives = append(archives, a)
+	}
+	return archives, rows.Err()
+}
+
+func (repo *sqliteArchiveRepo) Delete(ctx context.Context, bookmarkID int) error {
+	_, err := sqliteConn(ctx, repo.db).Exec(ctx, "delete from archives where bookmark_id=$1", bookmarkID)
+	return err
+}
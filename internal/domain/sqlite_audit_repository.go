@@ -0,0 +1,97 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
+)
+
+type sqliteAuditRepo struct {
+	db     *sql.DB
+	logger *logging.Logger
+}
+
+func NewSQLiteAuditRepo(db *sql.DB, logger *logging.Logger) AuditRepository {
+	return &sqliteAuditRepo{db: db, logger: logger}
+}
+
+func (repo *sqliteAuditRepo) Record(ctx context.Context, entries ...AuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	err := sqliteInTx(ctx, repo.db, func(ctx context.Context) error {
+		sql := `insert into audit_log(owner_id, actor_id, api_key_id, bookmark_id, action, changes, client_ip, created_at)
+				values($1, $2, $3, $4, $5, $6, $7, $8)`
+		for _, e := range entries {
+			changes, err := json.Marshal(e.Changes)
+			if err != nil {
+				return err
+			}
+			_, err = sqliteConn(ctx, repo.db).Exec(ctx, sql, e.OwnerID, e.ActorID, e.APIKeyID, e.BookmarkID, e.Action,
+				string(changes), e.ClientIP, e.CreatedDate)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		repo.logger.Errorf("Error while recording audit log entries: %v", err)
+	}
+	return err
+}
+
+func (repo *sqliteAuditRepo) FindPage(ctx context.Context, q AuditQuery) ([]AuditEntry, bool, error) {
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	where := []string{"owner_id = " + arg(q.OwnerID)}
+	if q.BookmarkID > 0 {
+		where = append(where, "bookmark_id = "+arg(q.BookmarkID))
+	}
+	if q.Action != "" {
+		where = append(where, "action = "+arg(q.Action))
+	}
+	if q.Since != nil {
+		where = append(where, "created_at >= "+arg(*q.Since))
+	}
+	if q.Until != nil {
+		where = append(where, "created_at < "+arg(*q.Until))
+	}
+	if q.After > 0 {
+		where = append(where, "id < "+arg(q.After))
+	}
+	// fetch one extra row to find out whether there are more entries
+	sql := "SELECT " + auditColumns + " FROM audit_log" + whereClause(where) + " ORDER BY id DESC LIMIT " + arg(q.Size+1)
+	rows, err := sqliteConn(ctx, repo.db).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+	entries := []AuditEntry{}
+	for rows.Next() {
+		var e AuditEntry
+		var changes []byte
+		err = rows.Scan(&e.ID, &e.OwnerID, &e.ActorID, &e.APIKeyID, &e.BookmarkID, &e.Action, &changes,
+			&e.ClientIP, &e.CreatedDate)
+		if err != nil {
+			return nil, false, err
+		}
+		if err = json.Unmarshal(changes, &e.Changes); err != nil {
+			return nil, false, err
+		}
+		entries = append(entries, e)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, false, err
+	}
+	if len(entries) > q.Size {
+		return entries[:q.Size], true, nil
+	}
+	return entries, false, nil
+}
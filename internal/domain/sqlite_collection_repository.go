@@ -0,0 +1,348 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
+)
+
+type sqliteCollectionRepo struct {
+	db     *sql.DB
+	logger *logging.Logger
+}
+
+// NewSQLiteCollectionRepo returns a CollectionRepository that needs no row locks, as
+// SQLite runs one writing transaction at a time.
+func NewSQLiteCollectionRepo(db *sql.DB, logger *logging.Logger) CollectionRepository {
+	return &sqliteCollectionRepo{db: db, logger: logger}
+}
+
+func (repo *sqliteCollectionRepo) FindAll(ctx context.Context, ownerID int) ([]Collection, error) {
+	sql := "SELECT " + collectionColumns + " FROM collections c WHERE c.owner_id=$1 ORDER BY c.name, c.id"
+	rows, err := sqliteConn(ctx, repo.db).Query(ctx, sql, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	collections := []Collection{}
+	for rows.Next() {
+		c, err := scanCollection(rows)
+		if err != nil {
+			return nil, err
+		}
+		collections = append(collections, c)
+	}
+	return collections, rows.Err()
+}
+
+func (repo *sqliteCollectionRepo) FindByID(ctx context.Context, ownerID int, id int) (Collection, error) {
+	sql := "SELECT " + collectionColumns + " FROM collections c WHERE c.id=$1 AND c.owner_id=$2"
+	c, err := scanCollection(sqliteConn(ctx, repo.db).QueryRow(ctx, sql, id, ownerID))
+	if isSQLiteNoRows(err) {
+		return Collection{}, ErrCollectionNotFound
+	}
+	return c, err
+}
+
+func (repo *sqliteCollectionRepo) Create(ctx context.Context, c Collection) (Collection, error) {
+	err := sqliteInTx(ctx, repo.db, func(ctx context.Context) error {
+		db := sqliteConn(ctx, repo.db)
+		if c.ParentID != nil {
+			if err := sqliteCheckCollection(ctx, db, c.OwnerID, *c.ParentID); err != nil {
+				return err
+			}
+		}
+		sql := "insert into collections(owner_id, parent_id, name, created_at) values($1, $2, $3, $4) RETURNING id"
+		return db.QueryRow(ctx, sql, c.OwnerID, c.ParentID, c.Name, c.CreatedDate).Scan(&c.ID)
+	})
+	if err != nil {
+		return Collection{}, err
+	}
+	return c, nil
+}
+
+func (repo *sqliteCollectionRepo) Update(ctx context.Context, c Collection) (Collection, error) {
+	err := sqliteInTx(ctx, repo.db, func(ctx context.Context) error {
+		db := sqliteConn(ctx, repo.db)
+		if err := sqliteCheckCollection(ctx, db, c.OwnerID, c.ID); err != nil {
+			return err
+		}
+		if c.ParentID != nil {
+			if err := sqliteCheckCollection(ctx, db, c.OwnerID, *c.ParentID); err != nil {
+				return err
+			}
+			// the new parent must not be the collection or one of its descendants
+			sql := `WITH RECURSIVE ancestors(id, parent_id) AS (
+						SELECT id, parent_id FROM collections WHERE id = $1
+						UNION ALL
+						SELECT c.id, c.parent_id FROM collections c JOIN ancestors a ON c.id = a.parent_id
+					)
+					SELECT EXISTS (SELECT 1 FROM ancestors WHERE id = $2)`
+			var cycle bool
+			if err := db.QueryRow(ctx, sql, *c.ParentID, c.ID).Scan(&cycle); err != nil {
+				return err
+			}
+			if cycle {
+				return ErrCollectionCycle
+			}
+		}
+		sql := "update collections set name=$1, parent_id=$2, updated_at=$3 where id=$4 and owner_id=$5"
+		_, err := db.Exec(ctx, sql, c.Name, c.ParentID, c.UpdatedDate, c.ID, c.OwnerID)
+		return err
+	})
+	if err != nil {
+		return Collection{}, err
+	}
+	return repo.FindByID(ctx, c.OwnerID, c.ID)
+}
+
+func (repo *sqliteCollectionRepo) Delete(ctx context.Context, ownerID int, id int, mode DeleteMode) error {
+	return sqliteInTx(ctx, repo.db, func(ctx context.Context) error {
+		db := sqliteConn(ctx, repo.db)
+		var parentID *int
+		sql := "SELECT parent_id FROM collections WHERE id=$1 AND owner_id=$2"
+		err := db.QueryRow(ctx, sql, id, ownerID).Scan(&parentID)
+		if isSQLiteNoRows(err) {
+			return ErrCollectionNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if mode == DeleteCascade {
+			sql = `WITH RECURSIVE subtree(id) AS (
+						SELECT id FROM collections WHERE id = $1
+						UNION ALL
+						SELECT c.id FROM collections c JOIN subtree s ON c.parent_id = s.id
+					)
+					delete from collections where id IN (SELECT id FROM subtree)`
+			_, err = db.Exec(ctx, sql, id)
+			return err
+		}
+
+		if parentID != nil {
+			// append the bookmarks not yet in the parent after its own, keeping their order
+			sql = `insert into collection_bookmarks(collection_id, bookmark_id, position)
+					SELECT $1, cb.bookmark_id,
+						(SELECT coalesce(max(position) + 1, 0) FROM collection_bookmarks WHERE collection_id = $1) +
+						row_number() OVER (ORDER BY cb.position) - 1
+					FROM collection_bookmarks cb
+					WHERE cb.collection_id = $2 AND NOT EXISTS (
+						SELECT 1 FROM collection_bookmarks p WHERE p.collection_id = $1 AND p.bookmark_id = cb.bookmark_id)`
+			if _, err = db.Exec(ctx, sql, *parentID, id); err != nil {
+				return err
+			}
+		}
+		_, err = db.Exec(ctx, "update collections set parent_id=$1 where parent_id=$2", parentID, id)
+		if err != nil {
+			return err
+		}
+		_, err = db.Exec(ctx, "delete from collections where id=$1", id)
+		return err
+	})
+}
+
+func (repo *sqliteCollectionRepo) FindBookmarks(ctx context.Context, ownerID int, id int) ([]Bookmark, error) {
+	if _, err := repo.FindByID(ctx, ownerID, id); err != nil {
+		return nil, err
+	}
+	sql := "SELECT " + bookmarkColumns + ` FROM collection_bookmarks cb JOIN bookmarks b ON b.id = cb.bookmark_id
+			WHERE cb.collection_id = $1 AND b.deleted_at IS NULL ORDER BY cb.position`
+	rows, err := sqliteConn(ctx, repo.db).Query(ctx, sql, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	bookmarks := []Bookmark{}
+	for rows.Next() {
+		b, err := scanBookmark(rows)
+		if err != nil {
+			return nil, err
+		}
+		bookmarks = append(bookmarks, b)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if err = sqliteLoadTags(ctx, sqliteConn(ctx, repo.db), bookmarks); err != nil {
+		return nil, err
+	}
+	return bookmarks, nil
+}
+
+func (repo *sqliteCollectionRepo) AddBookmark(ctx context.Context, ownerID int, id int, bookmarkID int,
+	position *int) error {
+	return sqliteInTx(ctx, repo.db, func(ctx context.Context) error {
+		db := sqliteConn(ctx, repo.db)
+		if err := sqliteCheckCollection(ctx, db, ownerID, id); err != nil {
+			return err
+		}
+		if err := sqliteCheckBookmark(ctx, db, ownerID, bookmarkID); err != nil {
+			return err
+		}
+		return sqliteInsertCollectionBookmark(ctx, db, id, bookmarkID, position)
+	})
+}
+
+func (repo *sqliteCollectionRepo) RemoveBookmark(ctx context.Context, ownerID int, id int, bookmarkID int) error {
+	return sqliteInTx(ctx, repo.db, func(ctx context.Context) error {
+		db := sqliteConn(ctx, repo.db)
+		if err := sqliteCheckCollection(ctx, db, ownerID, id); err != nil {
+			return err
+		}
+		return sqliteDeleteCollectionBookmark(ctx, db, id, bookmarkID)
+	})
+}
+
+func (repo *sqliteCollectionRepo) MoveBookmark(ctx context.Context, ownerID int, id int, bookmarkID int, targetID int,
+	position *int) error {
+	return sqliteInTx(ctx, repo.db, func(ctx context.Context) error {
+		db := sqliteConn(ctx, repo.db)
+		if err := sqliteCheckCollections(ctx, db, ownerID, id, targetID); err != nil {
+			return err
+		}
+		if err := sqliteDeleteCollectionBookmark(ctx, db, id, bookmarkID); err != nil {
+			return err
+		}
+		return sqliteInsertCollectionBookmark(ctx, db, targetID, bookmarkID, position)
+	})
+}
+
+func (repo *sqliteCollectionRepo) CopyBookmark(ctx context.Context, ownerID int, id int, bookmarkID int, targetID int,
+	position *int) error {
+	return sqliteInTx(ctx, repo.db, func(ctx context.Context) error {
+		db := sqliteConn(ctx, repo.db)
+		if err := sqliteCheckCollections(ctx, db, ownerID, id, targetID); err != nil {
+			return err
+		}
+		var exists bool
+		sql := "SELECT EXISTS (SELECT 1 FROM collection_bookmarks WHERE collection_id=$1 AND bookmark_id=$2)"
+		if err := db.QueryRow(ctx, sql, id, bookmarkID).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return ErrBookmarkNotInCollection
+		}
+		return sqliteInsertCollectionBookmark(ctx, db, targetID, bookmarkID, position)
+	})
+}
+
+func (repo *sqliteCollectionRepo) MoveBookmarkToCollection(ctx context.Context, ownerID int, bookmarkID int,
+	targetID int, position *int) error {
+	return sqliteInTx(ctx, repo.db, func(ctx context.Context) error {
+		db := sqliteConn(ctx, repo.db)
+		if err := sqliteCheckBookmark(ctx, db, ownerID, bookmarkID); err != nil {
+			return err
+		}
+		if err := sqliteCheckCollection(ctx, db, ownerID, targetID); err != nil {
+			return err
+		}
+		rows, err := db.Query(ctx, "SELECT collection_id FROM collection_bookmarks WHERE bookmark_id=$1", bookmarkID)
+		if err != nil {
+			return err
+		}
+		var ids []int
+		for rows.Next() {
+			var id int
+			if err = rows.Scan(&id); err != nil {
+				rows.Close()
+				return err
+			}
+			ids = append(ids, id)
+		}
+		rows.Close()
+		if err = rows.Err(); err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if err = sqliteDeleteCollectionBookmark(ctx, db, id, bookmarkID); err != nil {
+				return err
+			}
+		}
+		return sqliteInsertCollectionBookmark(ctx, db, targetID, bookmarkID, position)
+	})
+}
+
+// sqliteCheckCollection returns ErrCollectionNotFound unless the owner has the collection.
+func sqliteCheckCollection(ctx context.Context, db sqliteDB, ownerID int, id int) error {
+	var exists bool
+	sql := "SELECT EXISTS (SELECT 1 FROM collections WHERE id=$1 AND owner_id=$2)"
+	if err := db.QueryRow(ctx, sql, id, ownerID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrCollectionNotFound
+	}
+	return nil
+}
+
+func sqliteCheckCollections(ctx context.Context, db sqliteDB, ownerID int, id int, otherID int) error {
+	if err := sqliteCheckCollection(ctx, db, ownerID, id); err != nil || otherID == id {
+		return err
+	}
+	return sqliteCheckCollection(ctx, db, ownerID, otherID)
+}
+
+// sqliteCheckBookmark returns ErrBookmarkNotFound unless the owner has the bookmark outside of the trash.
+func sqliteCheckBookmark(ctx context.Context, db sqliteDB, ownerID int, bookmarkID int) error {
+	var exists bool
+	sql := "SELECT EXISTS (SELECT 1 FROM bookmarks WHERE id=$1 AND owner_id=$2 AND deleted_at IS NULL)"
+	if err := db.QueryRow(ctx, sql, bookmarkID, ownerID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrBookmarkNotFound
+	}
+	return nil
+}
+
+// sqliteInsertCollectionBookmark inserts the bookmark at position like insertCollectionBookmark.
+func sqliteInsertCollectionBookmark(ctx context.Context, db sqliteDB, id int, bookmarkID int, position *int) error {
+	var exists bool
+	sql := "SELECT EXISTS (SELECT 1 FROM collection_bookmarks WHERE collection_id=$1 AND bookmark_id=$2)"
+	if err := db.QueryRow(ctx, sql, id, bookmarkID).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return ErrBookmarkInCollection
+	}
+	var at int
+	if position == nil {
+		sql = "SELECT coalesce(max(position) + 1, 0) FROM collection_bookmarks WHERE collection_id=$1"
+		if err := db.QueryRow(ctx, sql, id).Scan(&at); err != nil {
+			return err
+		}
+	} else {
+		sql = `SELECT coalesce(
+					(SELECT position FROM collection_bookmarks WHERE collection_id=$1 ORDER BY position LIMIT 1 OFFSET $2),
+					(SELECT max(position) + 1 FROM collection_bookmarks WHERE collection_id=$1),
+					0)`
+		if err := db.QueryRow(ctx, sql, id, *position).Scan(&at); err != nil {
+			return err
+		}
+		sql = "update collection_bookmarks set position = position + 1 where collection_id=$1 and position >= $2"
+		if _, err := db.Exec(ctx, sql, id, at); err != nil {
+			return err
+		}
+	}
+	sql = "insert into collection_bookmarks(collection_id, bookmark_id, position) values($1, $2, $3)"
+	_, err := db.Exec(ctx, sql, id, bookmarkID, at)
+	return err
+}
+
+// sqliteDeleteCollectionBookmark removes the bookmark, shifting the following bookmarks up.
+func sqliteDeleteCollectionBookmark(ctx context.Context, db sqliteDB, id int, bookmarkID int) error {
+	var position int
+	sql := "delete from collection_bookmarks where collection_id=$1 and bookmark_id=$2 RETURNING position"
+	err := db.QueryRow(ctx, sql, id, bookmarkID).Scan(&position)
+	if isSQLiteNoRows(err) {
+		return ErrBookmarkNotInCollection
+	}
+	if err != nil {
+		return err
+	}
+	sql = "update collection_bookmarks set position = position - 1 where collection_id=$1 and position > $2"
+	_, err = db.Exec(ctx, sql, id, position)
+	return err
+}
@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
+)
+
+type sqliteJobRepo struct {
+	db     *sql.DB
+	logger *logging.Logger
+}
+
+// NewSQLiteJobRepo returns a JobRepository for a single worker, SQLite serializes the
+// transactions claiming jobs instead of skipping locked rows.
+func NewSQLiteJobRepo(db *sql.DB, logger *logging.Logger) JobRepository {
+	return &sqliteJobRepo{db: db, logger: logger}
+}
+
+func (repo *sqliteJobRepo) FindAll(ctx context.Context, userID int, status string, limit int) ([]Job, error) {
+	sql := "SELECT " + jobColumns + " FROM jobs WHERE user_id=$1 AND ($2 = '' OR status=$2) ORDER BY id DESC LIMIT $3"
+	rows, err := sqliteConn(ctx, repo.db).Query(ctx, sql, userID, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var jobs []Job
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+func (repo *sqliteJobRepo) FindByID(ctx context.Context, userID int, id int) (Job, error) {
+	sql := "SELECT " + jobColumns + " FROM jobs WHERE id=$1 AND user_id=$2"
+	j, err := scanJob(sqliteConn(ctx, repo.db).QueryRow(ctx, sql, id, userID))
+	return j, sqliteNoRows(err, ErrJobNotFound)
+}
+
+func (repo *sqliteJobRepo) Enqueue(ctx context.Context, j Job) (Job, error) {
+	if j.Payload == nil {
+		j.Payload = []byte("{}")
+	}
+	j.Status = JobPending
+	sql := `insert into jobs(user_id, kind, payload, status, max_attempts, run_at, created_at)
+			values($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+	err := sqliteConn(ctx, repo.db).QueryRow(ctx, sql, j.UserID, j.Kind, []byte(j.Payload), j.Status, j.MaxAttempts,
+		j.RunAt, j.CreatedDate).Scan(&j.ID)
+	if err != nil {
+		repo.logger.Errorf("Error while inserting job row: %v", err)
+		return Job{}, err
+	}
+	return j, nil
+}
+
+func (repo *sqliteJobRepo) Claim(ctx context.Context, now time.Time, staleBefore time.Time) (Job, error) {
+	sql := `UPDATE jobs SET status='running', attempts=attempts+1, locked_at=$1, updated_at=$1
+			WHERE id = (
+				SELECT id FROM jobs
+				WHERE (status='pending' AND run_at <= $1) OR (status='running' AND locked_at < $2)
+				ORDER BY run_at, id
+				LIMIT 1
+			)
+			RETURNING ` + jobColumns
+	j, err := scanJob(sqliteConn(ctx, repo.db).QueryRow(ctx, sql, now, staleBefore))
+	return j, sqliteNoRows(err, ErrNoJobDue)
+}
+
+func (repo *sqliteJobRepo) Complete(ctx context.Context, j Job, now time.Time) error {
+	sql := `UPDATE jobs SET status='succeeded', last_error='', locked_at=NULL, updated_at=$3
+			WHERE id=$1 AND status='running' AND locked_at=$2`
+	return repo.release(ctx, sql, j.ID, j.LockedDate, now)
+}
+
+func (repo *sqliteJobRepo) Retry(ctx context.Context, j Job, lastError string, runAt time.Time, now time.Time) error {
+	sql := `UPDATE jobs SET status='pending', last_error=$3, run_at=$4, locked_at=NULL, updated_at=$5
+			WHERE id=$1 AND status='running' AND locked_at=$2`
+	return repo.release(ctx, sql, j.ID, j.LockedDate, lastError, runAt, now)
+}
+
+func (repo *sqliteJobRepo) DeadLetter(ctx context.Context, j Job, lastError string, now time.Time) error {
+	sql := `UPDATE jobs SET status='dead', last_error=$3, locked_at=NULL, updated_at=$4
+			WHERE id=$1 AND status='running' AND locked_at=$2`
+	return repo.release(ctx, sql, j.ID, j.LockedDate, lastError, now)
+}
+
+// release runs an update recording the outcome of a claimed job.
+func (repo *sqliteJobRepo) release(ctx context.Context, sql string, args ...any) error {
+	result, err := sqliteConn(ctx, repo.db).Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+	if sqliteRowsAffected(result) == 0 {
+		return ErrJobLeaseLost
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
+)
+
+type sqliteLinkCheckRepo struct {
+	db     *sql.DB
+	logger *logging.Logger
+}
+
+func NewSQLiteLinkCheckRepo(db *sql.DB, logger *logging.Logger) LinkCheckRepository {
+	return &sqliteLinkCheckRepo{db: db, logger: logger}
+}
+
+func (repo *sqliteLinkCheckRepo) FindDue(ctx context.Context, checkedBefore time.Time, limit int) ([]Bookmark, error) {
+	sql := `SELECT id, owner_id, url FROM bookmarks
+			WHERE deleted_at IS NULL AND (link_checked_at IS NULL OR link_checked_at < $1)
+			ORDER BY link_checked_at NULLS FIRST, id LIMIT $2`
+	rows, err := sqliteConn(ctx, repo.db).Query(ctx, sql, checkedBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var bookmarks []Bookmark
+	for rows.Next() {
+		var b = Bookmark{}
+		if err = rows.Scan(&b.ID, &b.OwnerID, &b.URL); err != nil {
+			return nil, err
+		}
+		bookmarks = append(bookmarks, b)
+	}
+	return bookmarks, rows.Err()
+}
+
+func (repo *sqliteLinkCheckRepo) Record(ctx context.Context, check LinkCheck) error {
+	sql := `update bookmarks set link_status_code=$2, link_final_url=$3, link_checked_at=$4,
+			link_failures = CASE WHEN $5 THEN link_failures + 1 ELSE 0 END
+			where id=$1 and url=$6`
+	_, err := sqliteConn(ctx, repo.db).Exec(ctx, sql, check.BookmarkID, check.StatusCode, check.FinalURL,
+		check.CheckedDate, check.Failed, check.URL)
+	return err
+}
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
+)
+
+type sqliteRefreshTokenRepo struct {
+	db     *sql.DB
+	logger *logging.Logger
+}
+
+func NewSQLiteRefreshTokenRepo(db *sql.DB, logger *logging.Logger) RefreshTokenRepository {
+	return &sqliteRefreshTokenRepo{db: db, logger: logger}
+}
+
+func (repo *sqliteRefreshTokenRepo) Create(ctx context.Context, t RefreshToken) error {
+	sql := "insert into refresh_tokens(user_id, token_hash, expires_at, created_at) values($1, $2, $3, $4)"
+	_, err := sqliteConn(ctx, repo.db).Exec(ctx, sql, t.UserID, t.TokenHash, t.ExpiresAt, t.CreatedDate)
+	return err
+}
+
+func (repo *sqliteRefreshTokenRepo) Rotate(ctx context.Context, tokenHash string, next RefreshToken) (int, error) {
+	err := sqliteInTx(ctx, repo.db, func(ctx context.Context) error {
+		db := sqliteConn(ctx, repo.db)
+		sql := `update refresh_tokens set revoked_at=$2
+				where token_hash=$1 and revoked_at is null and expires_at > $2 RETURNING user_id`
+		err := db.QueryRow(ctx, sql, tokenHash, next.CreatedDate).Scan(&next.UserID)
+		if err != nil {
+			return err
+		}
+		sql = "insert into refresh_tokens(user_id, token_hash, expires_at, created_at) values($1, $2, $3, $4)"
+		_, err = db.Exec(ctx, sql, next.UserID, next.TokenHash, next.ExpiresAt, next.CreatedDate)
+		return err
+	})
+	if isSQLiteNoRows(err) {
+		return 0, repo.handleInvalidToken(ctx, tokenHash)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return next.UserID, nil
+}
+
+func (repo *sqliteRefreshTokenRepo) handleInvalidToken(ctx context.Context, tokenHash string) error {
+	var userID int
+	var revokedAt *time.Time
+	sql := "select user_id, revoked_at from refresh_tokens where token_hash=$1"
+	err := sqliteConn(ctx, repo.db).QueryRow(ctx, sql, tokenHash).Scan(&userID, &revokedAt)
+	if isSQLiteNoRows(err) || (err == nil && revokedAt == nil) {
+		// unknown or expired
+		return ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return err
+	}
+	repo.logger.Warnf("Revoked refresh token reused, revoking all tokens of user id=%d", userID)
+	sql = "update refresh_tokens set revoked_at=$2 where user_id=$1 and revoked_at is null"
+	if _, err = sqliteConn(ctx, repo.db).Exec(ctx, sql, userID, time.Now()); err != nil {
+		return err
+	}
+	return ErrInvalidRefreshToken
+}
+
+func (repo *sqliteRefreshTokenRepo) Revoke(ctx context.Context, tokenHash string) error {
+	sql := "update refresh_tokens set revoked_at=$2 where token_hash=$1 and revoked_at is null"
+	_, err := sqliteConn(ctx, repo.db).Exec(ctx, sql, tokenHash, time.Now())
+	return err
+}
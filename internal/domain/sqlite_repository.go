@@ -0,0 +1,429 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
+)
+
+// sqliteSortExpressions are the sortExpressions of SQLite, where the relevance of a
+// bookmark is looked up in the search results of sqliteBookmarkRepo.FindPage.
+var sqliteSortExpressions = map[string]string{
+	"id":           "%[1]s.id",
+	"title":        "%[1]s.title COLLATE NOCASE",
+	"url":          "%[1]s.url",
+	"created_date": "%[1]s.created_at",
+	"relevance":    "(SELECT rank FROM search WHERE search.id = %[1]s.id)",
+}
+
+type sqliteBookmarkRepo struct {
+	db     *sql.DB
+	logger *logging.Logger
+}
+
+func NewSQLiteBookmarkRepo(db *sql.DB, logger *logging.Logger) BookmarkRepository {
+	return &sqliteBookmarkRepo{db: db, logger: logger}
+}
+
+func (repo *sqliteBookmarkRepo) FindAll(ctx context.Context, ownerID int) ([]Bookmark, error) {
+	sql := "SELECT " + bookmarkColumns + " FROM bookmarks b WHERE b.owner_id=$1 AND b.deleted_at IS NULL"
+	return repo.findBookmarks(ctx, sql, ownerID)
+}
+
+func (repo *sqliteBookmarkRepo) FindPage(ctx context.Context, q BookmarkQuery) (BookmarkPage, error) {
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	with := ""
+	columns := bookmarkColumns
+	from := "bookmarks b"
+	where := []string{"b.owner_id = " + arg(q.OwnerID), "b.deleted_at IS NULL"}
+	if q.Query != "" {
+		terms := parseSearchTerms(q.Query)
+		if len(terms.include) == 0 {
+			return BookmarkPage{}, nil
+		}
+		// the snippet shows the fragments of the title, url or page text that matched best
+		with = "WITH search(id, rank, snippet) AS MATERIALIZED (SELECT rowid, -bm25(bookmarks_fts, 1.0, 0.4, 0.2), " +
+			"snippet(bookmarks_fts, -1, " + arg(matchStart) + ", " + arg(matchStop) + ", ' ... ', 35) " +
+			"FROM bookmarks_fts WHERE bookmarks_fts MATCH " + arg(terms.ftsQuery()) + ") "
+		from += " JOIN search s ON s.id = b.id"
+		columns += ", s.rank, s.snippet"
+	}
+	switch q.LinkStatus {
+	case LinkBroken:
+		where = append(where, "b.link_failures > 0")
+	case LinkOK:
+		where = append(where, "b.link_checked_at IS NOT NULL AND b.link_failures = 0")
+	case LinkUnchecked:
+		where = append(where, "b.link_checked_at IS NULL")
+	}
+	if len(q.Tags) > 0 {
+		tagged := "SELECT bt.bookmark_id FROM bookmark_tags bt JOIN tags t ON t.id = bt.tag_id " +
+			"WHERE t.name IN (SELECT value FROM json_each(" + arg(jsonArray(q.Tags)) + "))"
+		if !q.AnyTag {
+			tagged += " GROUP BY bt.bookmark_id HAVING count(*) = " + arg(len(q.Tags))
+		}
+		where = append(where, "b.id IN ("+tagged+")")
+	}
+
+	var page BookmarkPage
+	countSQL := with + "SELECT count(*) FROM " + from + whereClause(where)
+	err := sqliteConn(ctx, repo.db).QueryRow(ctx, countSQL, args...).Scan(&page.Total)
+	if err != nil {
+		return BookmarkPage{}, err
+	}
+
+	if q.After > 0 {
+		from += " JOIN bookmarks c ON c.id = " + arg(q.After) + " AND c.owner_id = b.owner_id"
+		where = append(where, keysetCondition(q.Sort, sqliteSortExpressions))
+	}
+	// fetch one extra row to find out whether there is a next page
+	sql := fmt.Sprintf("%sSELECT %s FROM %s%s ORDER BY %s LIMIT %s",
+		with, columns, from, whereClause(where), orderByClause(q.Sort, sqliteSortExpressions), arg(q.Size+1))
+	if q.After == 0 && q.Page > 1 {
+		sql += " OFFSET " + arg((q.Page-1)*q.Size)
+	}
+
+	rows, err := sqliteConn(ctx, repo.db).Query(ctx, sql, args...)
+	if err != nil {
+		return BookmarkPage{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var rank float32
+		var snippet string
+		var extra []any
+		if q.Query != "" {
+			extra = []any{&rank, &snippet}
+		}
+		b, err := scanBookmark(rows, extra...)
+		if err != nil {
+			return BookmarkPage{}, err
+		}
+		b.Rank, b.Snippet = rank, highlight(snippet)
+		page.Bookmarks = append(page.Bookmarks, b)
+	}
+	if err = rows.Err(); err != nil {
+		return BookmarkPage{}, err
+	}
+	if len(page.Bookmarks) > q.Size {
+		page.Bookmarks = page.Bookmarks[:q.Size]
+		page.HasMore = true
+	}
+	if err = sqliteLoadTags(ctx, sqliteConn(ctx, repo.db), page.Bookmarks); err != nil {
+		return BookmarkPage{}, err
+	}
+	return page, nil
+}
+
+func (repo *sqliteBookmarkRepo) ForEach(ctx context.Context, ownerID int, fn func(Bookmark) error) error {
+	sql := `SELECT ` + bookmarkColumns + `,
+			json_group_array(t.name ORDER BY t.name) FILTER (WHERE t.name IS NOT NULL)
+			FROM bookmarks b
+			LEFT JOIN bookmark_tags bt ON bt.bookmark_id = b.id
+			LEFT JOIN tags t ON t.id = bt.tag_id
+			WHERE b.owner_id = $1 AND b.deleted_at IS NULL
+			GROUP BY b.id ORDER BY b.id`
+	rows, err := sqliteConn(ctx, repo.db).Query(ctx, sql, ownerID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var tags []byte
+		b, err := scanBookmark(rows, &tags)
+		if err != nil {
+			return err
+		}
+		if err = json.Unmarshal(tags, &b.Tags); err != nil {
+			return err
+		}
+		if err = fn(b); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (repo *sqliteBookmarkRepo) FindByID(ctx context.Context, ownerID int, id int) (Bookmark, error) {
+	repo.logger.Infof("Fetching bookmark with id=%d", id)
+	sql := "select " + bookmarkColumns + " FROM bookmarks b where b.id=$1 and b.owner_id=$2 and b.deleted_at IS NULL"
+	b, err := scanBookmark(sqliteConn(ctx, repo.db).QueryRow(ctx, sql, id, ownerID))
+	if isSQLiteNoRows(err) {
+		return Bookmark{}, ErrBookmarkNotFound
+	}
+	if err != nil {
+		return Bookmark{}, err
+	}
+	bookmarks := []Bookmark{b}
+	if err = sqliteLoadTags(ctx, sqliteConn(ctx, repo.db), bookmarks); err != nil {
+		return Bookmark{}, err
+	}
+	return bookmarks[0], nil
+}
+
+func (repo *sqliteBookmarkRepo) Create(ctx context.Context, b Bookmark) (Bookmark, error) {
+	if err := ValidateBookmark(b); err != nil {
+		return Bookmark{}, err
+	}
+	err := sqliteInTx(ctx, repo.db, func(ctx context.Context) error {
+		return repo.insert(ctx, &b)
+	})
+	if err != nil {
+		if isSQLiteDuplicateURL(err) {
+			return Bookmark{}, repo.duplicateURLError(ctx, b)
+		}
+		repo.logger.Errorf("Error while inserting bookmark row: %v", err)
+		return Bookmark{}, err
+	}
+	if b.Tags == nil {
+		b.Tags = []string{}
+	}
+	return b, nil
+}
+
+func (repo *sqliteBookmarkRepo) CreateAll(ctx context.Context, bookmarks []Bookmark) ([]Bookmark, error) {
+	if len(bookmarks) == 0 {
+		return bookmarks, nil
+	}
+	for _, b := range bookmarks {
+		if err := ValidateBookmark(b); err != nil {
+			return nil, err
+		}
+	}
+	err := sqliteInTx(ctx, repo.db, func(ctx context.Context) error {
+		for i := range bookmarks {
+			if err := repo.insert(ctx, &bookmarks[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		repo.logger.Errorf("Error while inserting bookmark rows: %v", err)
+		return nil, err
+	}
+	for i := range bookmarks {
+		if bookmarks[i].Tags == nil {
+			bookmarks[i].Tags = []string{}
+		}
+	}
+	return bookmarks, nil
+}
+
+// insert inserts the bookmark and its tags, setting its ID and Version.
+func (repo *sqliteBookmarkRepo) insert(ctx context.Context, b *Bookmark) error {
+	db := sqliteConn(ctx, repo.db)
+	err := db.QueryRow(ctx, insertBookmarkSQL, b.OwnerID, b.Title, b.URL, NormalizedURL(b.URL), b.Description,
+		b.FaviconURL, b.CanonicalURL, b.CreatedDate, b.UpdatedDate).Scan(&b.ID, &b.Version)
+	if err != nil {
+		return err
+	}
+	return sqliteSaveTags(ctx, db, b.ID, b.Tags)
+}
+
+func (repo *sqliteBookmarkRepo) ExistingURLs(ctx context.Context, ownerID int, urls []string) ([]string, error) {
+	// bookmarks saved before urls were normalized are matched by their url
+	sql := "SELECT DISTINCT coalesce(normalized_url, url) FROM bookmarks WHERE owner_id=$1 AND deleted_at IS NULL " +
+		"AND coalesce(normalized_url, url) IN (SELECT value FROM json_each($2))"
+	rows, err := sqliteConn(ctx, repo.db).Query(ctx, sql, ownerID, jsonArray(urls))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	existing := []string{}
+	for rows.Next() {
+		var u string
+		if err = rows.Scan(&u); err != nil {
+			return nil, err
+		}
+		existing = append(existing, u)
+	}
+	return existing, rows.Err()
+}
+
+func (repo *sqliteBookmarkRepo) Update(ctx context.Context, b Bookmark) (Bookmark, error) {
+	if err := ValidateBookmark(b); err != nil {
+		return Bookmark{}, err
+	}
+	err := sqliteInTx(ctx, repo.db, func(ctx context.Context) error {
+		db := sqliteConn(ctx, repo.db)
+		// the outcome of the last link check no longer applies to a changed url
+		sql := `update bookmarks set title = $1, url=$2, normalized_url=$6, updated_at=$3, version = version + 1,
+				link_status_code = CASE WHEN url = $2 THEN link_status_code END,
+				link_final_url = CASE WHEN url = $2 THEN link_final_url ELSE '' END,
+				link_checked_at = CASE WHEN url = $2 THEN link_checked_at END,
+				link_failures = CASE WHEN url = $2 THEN link_failures ELSE 0 END
+				where id=$4 and owner_id=$5 and deleted_at IS NULL and ($7 = 0 or version = $7)`
+		result, err := db.Exec(ctx, sql, b.Title, b.URL, b.UpdatedDate, b.ID, b.OwnerID, NormalizedURL(b.URL), b.Version)
+		if err != nil {
+			return err
+		}
+		if sqliteRowsAffected(result) == 0 {
+			var exists bool
+			sql = "SELECT EXISTS (SELECT 1 FROM bookmarks WHERE id=$1 AND owner_id=$2 AND deleted_at IS NULL)"
+			if err = db.QueryRow(ctx, sql, b.ID, b.OwnerID).Scan(&exists); err != nil {
+				return err
+			}
+			if exists {
+				return ErrStaleBookmark
+			}
+			return ErrBookmarkNotFound
+		}
+		if b.Tags == nil {
+			return nil
+		}
+		return sqliteSaveTags(ctx, db, b.ID, b.Tags)
+	})
+	if isSQLiteDuplicateURL(err) {
+		return Bookmark{}, repo.duplicateURLError(ctx, b)
+	}
+	if err != nil {
+		return Bookmark{}, err
+	}
+	return b, nil
+}
+
+func (repo *sqliteBookmarkRepo) UpdateMetadata(ctx context.Context, b Bookmark) error {
+	sql := `update bookmarks set title=$1, description=$2, favicon_url=$3, canonical_url=$4, version = version + 1
+			where id=$5 and owner_id=$6 and deleted_at IS NULL and version=$7`
+	result, err := sqliteConn(ctx, repo.db).Exec(ctx, sql, b.Title, b.Description, b.FaviconURL, b.CanonicalURL, b.ID,
+		b.OwnerID, b.Version)
+	if err != nil {
+		return err
+	}
+	if sqliteRowsAffected(result) == 0 {
+		return ErrStaleBookmark
+	}
+	return nil
+}
+
+func (repo *sqliteBookmarkRepo) UpdateContent(ctx context.Context, ownerID int, id int, content string) error {
+	sql := "update bookmarks set content=$1 where id=$2 and owner_id=$3 and deleted_at IS NULL"
+	_, err := sqliteConn(ctx, repo.db).Exec(ctx, sql, content, id, ownerID)
+	return err
+}
+
+func (repo *sqliteBookmarkRepo) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return sqliteInTx(ctx, repo.db, fn)
+}
+
+func (repo *sqliteBookmarkRepo) Delete(ctx context.Context, ownerID int, id int) error {
+	sql := "update bookmarks set deleted_at=$3 where id=$1 and owner_id=$2 and deleted_at IS NULL"
+	result, err := sqliteConn(ctx, repo.db).Exec(ctx, sql, id, ownerID, time.Now())
+	if err != nil {
+		return err
+	}
+	if sqliteRowsAffected(result) == 0 {
+		return ErrBookmarkNotFound
+	}
+	return nil
+}
+
+func (repo *sqliteBookmarkRepo) FindTrash(ctx context.Context, ownerID int) ([]Bookmark, error) {
+	sql := "SELECT " + bookmarkColumns + ` FROM bookmarks b WHERE b.owner_id=$1 AND b.deleted_at IS NOT NULL
+			ORDER BY b.deleted_at DESC, b.id`
+	bookmarks, err := repo.findBookmarks(ctx, sql, ownerID)
+	if bookmarks == nil && err == nil {
+		bookmarks = []Bookmark{}
+	}
+	return bookmarks, err
+}
+
+func (repo *sqliteBookmarkRepo) Restore(ctx context.Context, ownerID int, id int) (Bookmark, error) {
+	b := Bookmark{ID: id, OwnerID: ownerID}
+	err := sqliteInTx(ctx, repo.db, func(ctx context.Context) error {
+		db := sqliteConn(ctx, repo.db)
+		sql := "SELECT url FROM bookmarks WHERE id=$1 AND owner_id=$2 AND deleted_at IS NOT NULL"
+		err := db.QueryRow(ctx, sql, id, ownerID).Scan(&b.URL)
+		if isSQLiteNoRows(err) {
+			return ErrBookmarkNotFound
+		}
+		if err != nil {
+			return err
+		}
+		_, err = db.Exec(ctx, "update bookmarks set deleted_at=NULL where id=$1", id)
+		return err
+	})
+	if isSQLiteDuplicateURL(err) {
+		return Bookmark{}, repo.duplicateURLError(ctx, b)
+	}
+	if err != nil {
+		return Bookmark{}, err
+	}
+	return repo.FindByID(ctx, ownerID, id)
+}
+
+func (repo *sqliteBookmarkRepo) PurgeDeleted(ctx context.Context, deletedBefore time.Time, limit int) (int, error) {
+	sql := `delete from bookmarks where id IN (
+				SELECT id FROM bookmarks WHERE deleted_at < $1 ORDER BY deleted_at LIMIT $2)`
+	result, err := sqliteConn(ctx, repo.db).Exec(ctx, sql, deletedBefore, limit)
+	if err != nil {
+		return 0, err
+	}
+	return int(sqliteRowsAffected(result)), nil
+}
+
+// findBookmarks returns the bookmarks selected by sql, including their tags.
+func (repo *sqliteBookmarkRepo) findBookmarks(ctx context.Context, sql string, args ...any) ([]Bookmark, error) {
+	rows, err := sqliteConn(ctx, repo.db).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	var bookmarks []Bookmark
+	defer rows.Close()
+	for rows.Next() {
+		b, err := scanBookmark(rows)
+		if err != nil {
+			return nil, err
+		}
+		bookmarks = append(bookmarks, b)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if err = sqliteLoadTags(ctx, sqliteConn(ctx, repo.db), bookmarks); err != nil {
+		return nil, err
+	}
+	return bookmarks, nil
+}
+
+func isSQLiteDuplicateURL(err error) bool {
+	return isSQLiteUniqueViolation(err, "bookmarks.normalized_url")
+}
+
+// duplicateURLError looks up the bookmark of the owner having the same normalized url as b.
+func (repo *sqliteBookmarkRepo) duplicateURLError(ctx context.Context, b Bookmark) error {
+	dup := &DuplicateURLError{}
+	sql := "SELECT id FROM bookmarks WHERE owner_id=$1 AND normalized_url=$2 AND deleted_at IS NULL"
+	err := sqliteConn(ctx, repo.db).QueryRow(ctx, sql, b.OwnerID, NormalizedURL(b.URL)).Scan(&dup.ExistingID)
+	if err != nil {
+		return err
+	}
+	return dup
+}
+
+// ftsQuery turns the terms into an FTS5 query. Every word is quoted so that it is not
+// taken for an operator, searchWords only keeps letters and digits.
+func (terms searchTerms) ftsQuery() string {
+	quote := func(words []string) []string {
+		quoted := make([]string, len(words))
+		for i, w := range words {
+			quoted[i] = `"` + w + `"`
+		}
+		return quoted
+	}
+	query := "(" + strings.Join(quote(terms.include), " ") + ")"
+	for _, w := range quote(terms.exclude) {
+		query += " NOT " + w
+	}
+	return query
+}
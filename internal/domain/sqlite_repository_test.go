@@ -0,0 +1,38 @@
+package domain_test
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/config"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/db"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/domain"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
+	"github.com/sivaprasadreddy/bookmarks-go/testsupport"
+
+	"go.uber.org/zap"
+)
+
+func TestSQLiteBookmarkRepository(t *testing.T) {
+	logger := &logging.Logger{SugaredLogger: zap.NewNop().Sugar()}
+	sqliteDb := db.GetSQLiteDb(config.AppConfig{
+		DbRunMigrations: true,
+		SQLitePath:      path.Join(t.TempDir(), "bookmarks.db"),
+	}, logger)
+	defer sqliteDb.Close()
+
+	users := domain.NewSQLiteUserRepo(sqliteDb, logger)
+	owners := 0
+	testsupport.RunBookmarkRepositoryContract(t, domain.NewSQLiteBookmarkRepo(sqliteDb, logger), func() int {
+		owners++
+		user, err := users.Create(context.Background(), domain.User{Name: "Owner",
+			Email: fmt.Sprintf("owner-%d@example.com", owners), PasswordHash: "-", CreatedDate: time.Now()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return user.ID
+	})
+}
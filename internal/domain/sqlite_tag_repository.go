@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
+)
+
+type sqliteTagRepo struct {
+	db     *sql.DB
+	logger *logging.Logger
+}
+
+func NewSQLiteTagRepo(db *sql.DB, logger *logging.Logger) TagRepository {
+	return &sqliteTagRepo{db: db, logger: logger}
+}
+
+func (repo *sqliteTagRepo) FindAll(ctx context.Context, ownerID int) ([]Tag, error) {
+	sql := `SELECT t.name, count(*) FROM tags t
+			JOIN bookmark_tags bt ON bt.tag_id = t.id
+			JOIN bookmarks b ON b.id = bt.bookmark_id
+			WHERE b.owner_id = $1 AND b.deleted_at IS NULL
+			GROUP BY t.name ORDER BY count(*) DESC, t.name`
+	rows, err := sqliteConn(ctx, repo.db).Query(ctx, sql, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var tags []Tag
+	for rows.Next() {
+		var t = Tag{}
+		if err = rows.Scan(&t.Name, &t.Count); err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+	return tags, rows.Err()
+}
+
+// sqliteLoadTags fetches the tags of all given bookmarks with a single query.
+func sqliteLoadTags(ctx context.Context, db sqliteDB, bookmarks []Bookmark) error {
+	if len(bookmarks) == 0 {
+		return nil
+	}
+	ids := make([]int, len(bookmarks))
+	index := map[int]int{}
+	for i := range bookmarks {
+		ids[i] = bookmarks[i].ID
+		index[bookmarks[i].ID] = i
+		bookmarks[i].Tags = []string{}
+	}
+	sql := `SELECT bt.bookmark_id, t.name FROM bookmark_tags bt JOIN tags t ON t.id = bt.tag_id
+			WHERE bt.bookmark_id IN (SELECT value FROM json_each($1)) ORDER BY t.name`
+	rows, err := db.Query(ctx, sql, jsonArray(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		var name string
+		if err = rows.Scan(&id, &name); err != nil {
+			return err
+		}
+		b := &bookmarks[index[id]]
+		b.Tags = append(b.Tags, name)
+	}
+	return rows.Err()
+}
+
+// sqliteSaveTags replaces the tags of the given bookmark, creating missing tags.
+func sqliteSaveTags(ctx context.Context, db sqliteDB, bookmarkID int, tags []string) error {
+	_, err := db.Exec(ctx, "DELETE FROM bookmark_tags WHERE bookmark_id = $1", bookmarkID)
+	if err != nil || len(tags) == 0 {
+		return err
+	}
+	_, err = db.Exec(ctx, "INSERT OR IGNORE INTO tags(name) SELECT value FROM json_each($1)", jsonArray(tags))
+	if err != nil {
+		return err
+	}
+	sql := "INSERT INTO bookmark_tags(bookmark_id, tag_id) SELECT $1, id FROM tags WHERE name IN (SELECT value FROM json_each($2))"
+	_, err = db.Exec(ctx, sql, bookmarkID, jsonArray(tags))
+	return err
+}
@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
+)
+
+type sqliteUserRepo struct {
+	db     *sql.DB
+	logger *logging.Logger
+}
+
+func NewSQLiteUserRepo(db *sql.DB, logger *logging.Logger) UserRepository {
+	return &sqliteUserRepo{db: db, logger: logger}
+}
+
+func (repo *sqliteUserRepo) FindByID(ctx context.Context, id int) (User, error) {
+	var u = User{}
+	sql := "select id, name, email, password_hash, created_at FROM users where id=$1"
+	err := sqliteConn(ctx, repo.db).QueryRow(ctx, sql, id).Scan(
+		&u.ID, &u.Name, &u.Email, &u.PasswordHash, &u.CreatedDate)
+	if err != nil {
+		return User{}, sqliteNoRows(err, ErrUserNotFound)
+	}
+	return u, nil
+}
+
+func (repo *sqliteUserRepo) FindByEmail(ctx context.Context, email string) (User, error) {
+	var u = User{}
+	sql := "select id, name, email, password_hash, created_at FROM users where email=$1"
+	err := sqliteConn(ctx, repo.db).QueryRow(ctx, sql, NormalizeEmail(email)).Scan(
+		&u.ID, &u.Name, &u.Email, &u.PasswordHash, &u.CreatedDate)
+	if err != nil {
+		return User{}, sqliteNoRows(err, ErrUserNotFound)
+	}
+	return u, nil
+}
+
+func (repo *sqliteUserRepo) Create(ctx context.Context, u User) (User, error) {
+	var lastInsertID int
+	u.Email = NormalizeEmail(u.Email)
+	sql := "insert into users(name, email, password_hash, created_at) values($1, $2, $3, $4) RETURNING id"
+	err := sqliteConn(ctx, repo.db).QueryRow(ctx, sql, u.Name, u.Email, u.PasswordHash, u.CreatedDate).
+		Scan(&lastInsertID)
+	if err != nil {
+		if isSQLiteUniqueViolation(err, "users.email") {
+			return User{}, ErrEmailTaken
+		}
+		repo.logger.Errorf("Error while inserting user row: %v", err)
+		return User{}, err
+	}
+	u.ID = lastInsertID
+	return u, nil
+}
+
+func (repo *sqliteUserRepo) UpdatePassword(ctx context.Context, email string, passwordHash string) error {
+	sql := "update users set password_hash=$1 where email=$2"
+	result, err := sqliteConn(ctx, repo.db).Exec(ctx, sql, passwordHash, NormalizeEmail(email))
+	if err != nil {
+		repo.logger.Errorf("Error while updating user password: %v", err)
+		return err
+	}
+	if sqliteRowsAffected(result) == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package domain
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type TagRepository interface {
+	FindAll(ctx context.Context, ownerID int) ([]Tag, error)
+}
+
+type tagRepo struct {
+	db     DB
+	logger *logging.Logger
+}
+
+func NewTagRepo(db DB, logger *logging.Logger) TagRepository {
+	return &tagRepo{db: db, logger: logger}
+}
+
+func (repo *tagRepo) FindAll(ctx context.Context, ownerID int) ([]Tag, error) {
+	sql := `SELECT t.name, count(*) FROM tags t
+			JOIN bookmark_tags bt ON bt.tag_id = t.id
+			JOIN bookmarks b ON b.id = bt.bookmark_id
+			WHERE b.owner_id = $1 AND b.deleted_at IS NULL
+			GROUP BY t.name ORDER BY count(*) DESC, t.name`
+	rows, err := conn(ctx, repo.db).Query(ctx, sql, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var tags []Tag
+	for rows.Next() {
+		var t = Tag{}
+		if err = rows.Scan(&t.Name, &t.Count); err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+	return tags, rows.Err()
+}
+
+// NormalizeTags lower-cases and trims the given tag names, dropping blanks and
+// duplicates. The result is sorted so that it can be compared and stored as is.
+func NormalizeTags(tags []string) []string {
+	seen := map[string]bool{}
+	normalized := []string{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+// loadTags fetches the tags of all given bookmarks with a single query.
+func loadTags(ctx context.Context, db DB, bookmarks []Bookmark) error {
+	if len(bookmarks) == 0 {
+		return nil
+	}
+	ids := make([]int, len(bookmarks))
+	index := map[int]int{}
+	for i := range bookmarks {
+		ids[i] = bookmarks[i].ID
+		index[bookmarks[i].ID] = i
+		bookmarks[i].Tags = []string{}
+	}
+	sql := `SELECT bt.bookmark_id, t.name FROM bookmark_tags bt JOIN tags t ON t.id = bt.tag_id
+			WHERE bt.bookmark_id = ANY($1) ORDER BY t.name`
+	rows, err := db.Query(ctx, sql, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		var name string
+		if err = rows.Scan(&id, &name); err != nil {
+			return err
+		}
+		b := &bookmarks[index[id]]
+		b.Tags = append(b.Tags, name)
+	}
+	return rows.Err()
+}
+
+// saveTags replaces the tags of the given bookmark, creating missing tags.
+func saveTags(ctx context.Context, tx pgx.Tx, bookmarkID int, tags []string) error {
+	_, err := tx.Exec(ctx, "DELETE FROM bookmark_tags WHERE bookmark_id = $1", bookmarkID)
+	if err != nil || len(tags) == 0 {
+		return err
+	}
+	_, err = tx.Exec(ctx, "INSERT INTO tags(name) SELECT unnest($1::varchar[]) ON CONFLICT (name) DO NOTHING", tags)
+	if err != nil {
+		return err
+	}
+	sql := "INSERT INTO bookmark_tags(bookmark_id, tag_id) SELECT $1, id FROM tags WHERE name = ANY($2)"
+	_, err = tx.Exec(ctx, sql, bookmarkID, tags)
+	return err
+}
+
+// saveAllTags adds the tags of newly inserted bookmarks, creating missing tags.
+func saveAllTags(ctx context.Context, tx pgx.Tx, bookmarks []Bookmark) error {
+	var ids []int
+	var names []string
+	for _, b := range bookmarks {
+		for _, tag := range b.Tags {
+			ids = append(ids, b.ID)
+			names = append(names, tag)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	_, err := tx.Exec(ctx, "INSERT INTO tags(name) SELECT DISTINCT unnest($1::varchar[]) ON CONFLICT (name) DO NOTHING", names)
+	if err != nil {
+		return err
+	}
+	sql := `INSERT INTO bookmark_tags(bookmark_id, tag_id)
+			SELECT x.bookmark_id, t.id FROM unnest($1::bigint[], $2::varchar[]) AS x(bookmark_id, name)
+			JOIN tags t ON t.name = x.name ON CONFLICT DO NOTHING`
+	_, err = tx.Exec(ctx, sql, ids, names)
+	return err
+}
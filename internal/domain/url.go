@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// trackingParams are query parameters that identify a campaign or a click,
+// not the page, and are left out of normalized URLs.
+var trackingParams = map[string]bool{
+	"fbclid": true, "gclid": true, "dclid": true, "msclkid": true, "mc_cid": true, "mc_eid": true,
+	"_ga": true, "yclid": true, "igshid": true,
+}
+
+// NormalizeURL returns the canonical form of a URL used to detect duplicate
+// bookmarks. Scheme and host are lowercased, http is treated as https,
+// default ports, fragments, trailing slashes and tracking parameters are
+// removed, percent-encoded unreserved characters are decoded and the
+// remaining query parameters are sorted.
+func NormalizeURL(raw string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", err
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme == "http" {
+		u.Scheme = "https"
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if port := u.Port(); port != "" && port != "80" && port != "443" {
+		host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	u.Host = host
+	u.User = nil
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	// keep the escaping of the path, a%2Fb and a/b are different paths
+	escaped := strings.TrimRight(decodeUnreserved(u.EscapedPath()), "/")
+	u.Path, _ = url.PathUnescape(escaped)
+	u.RawPath = escaped
+
+	query := u.Query()
+	for key := range query {
+		if strings.HasPrefix(strings.ToLower(key), "utm_") || trackingParams[strings.ToLower(key)] {
+			query.Del(key)
+		}
+	}
+	for _, values := range query {
+		sort.Strings(values)
+	}
+	// Encode sorts by key
+	u.RawQuery = query.Encode()
+	u.ForceQuery = false
+	return u.String(), nil
+}
+
+// decodeUnreserved decodes the percent-encoded letters, digits, '-', '.', '_' and '~' in an
+// escaped path, they mean the same whether they are encoded or not.
+func decodeUnreserved(escaped string) string {
+	var b strings.Builder
+	for i := 0; i < len(escaped); i++ {
+		if escaped[i] == '%' && i+2 < len(escaped) {
+			if c, err := strconv.ParseUint(escaped[i+1:i+3], 16, 8); err == nil && isUnreserved(byte(c)) {
+				b.WriteByte(byte(c))
+				i += 2
+				continue
+			}
+		}
+		b.WriteByte(escaped[i])
+	}
+	return b.String()
+}
+
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+// NormalizedURL returns the normalized form of a url, or the url itself when it can not be parsed.
+func NormalizedURL(u string) string {
+	if normalized, err := NormalizeURL(u); err == nil {
+		return normalized
+	}
+	return u
+}
@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	ErrUserNotFound = newError(ErrNotFound, "user not found")
+	ErrEmailTaken   = newError(ErrConflict, "email is already registered")
+)
+
+type UserRepository interface {
+	FindByID(ctx context.Context, userID int) (User, error)
+	FindByEmail(ctx context.Context, email string) (User, error)
+	Create(ctx context.Context, user User) (User, error)
+	UpdatePassword(ctx context.Context, email string, passwordHash string) error
+}
+
+type userRepo struct {
+	db     DB
+	logger *logging.Logger
+}
+
+func NewUserRepo(db DB, logger *logging.Logger) UserRepository {
+	return &userRepo{db: db, logger: logger}
+}
+
+func (repo *userRepo) FindByID(ctx context.Context, id int) (User, error) {
+	var u = User{}
+	sql := "select id, name, email, password_hash, created_at FROM users where id=$1"
+	err := conn(ctx, repo.db).QueryRow(ctx, sql, id).Scan(
+		&u.ID, &u.Name, &u.Email, &u.PasswordHash, &u.CreatedDate)
+	if err != nil {
+		return User{}, noRows(err, ErrUserNotFound)
+	}
+	return u, nil
+}
+
+func (repo *userRepo) FindByEmail(ctx context.Context, email string) (User, error) {
+	var u = User{}
+	sql := "select id, name, email, password_hash, created_at FROM users where email=$1"
+	err := conn(ctx, repo.db).QueryRow(ctx, sql, NormalizeEmail(email)).Scan(
+		&u.ID, &u.Name, &u.Email, &u.PasswordHash, &u.CreatedDate)
+	if err != nil {
+		return User{}, noRows(err, ErrUserNotFound)
+	}
+	return u, nil
+}
+
+func (repo *userRepo) Create(ctx context.Context, u User) (User, error) {
+	var lastInsertID int
+	u.Email = NormalizeEmail(u.Email)
+	sql := "insert into users(name, email, password_hash, created_at) values($1, $2, $3, $4) RETURNING id"
+	err := conn(ctx, repo.db).QueryRow(ctx, sql, u.Name, u.Email, u.PasswordHash, u.CreatedDate).
+		Scan(&lastInsertID)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return User{}, ErrEmailTaken
+		}
+		repo.logger.Errorf("Error while inserting user row: %v", err)
+		return User{}, err
+	}
+	u.ID = lastInsertID
+	return u, nil
+}
+
+func (repo *userRepo) UpdatePassword(ctx context.Context, email string, passwordHash string) error {
+	sql := "update users set password_hash=$1 where email=$2"
+	tag, err := conn(ctx, repo.db).Exec(ctx, sql, passwordHash, NormalizeEmail(email))
+	if err != nil {
+		repo.logger.Errorf("Error while updating user password: %v", err)
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
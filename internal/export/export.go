@@ -0,0 +1,223 @@
+// Package export writes bookmarks one at a time in the supported export formats.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/domain"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/netscape"
+)
+
+type Format struct {
+	Name        string
+	ContentType string
+	Extension   string
+	newWriter   func(w io.Writer) Writer
+}
+
+// Writer writes bookmarks to an export, Close completes the document.
+type Writer interface {
+	Write(b domain.Bookmark) error
+	Close() error
+}
+
+var formats = map[string]Format{
+	"html": {"html", "text/html; charset=utf-8", "html", newHTMLWriter},
+	"json": {"json", "application/json; charset=utf-8", "json", newJSONWriter},
+	"csv":  {"csv", "text/csv; charset=utf-8", "csv", newCSVWriter},
+	"atom": {"atom", "application/atom+xml; charset=utf-8", "atom", newAtomWriter},
+}
+
+func LookupFormat(name string) (Format, error) {
+	f, ok := formats[name]
+	if !ok {
+		return Format{}, fmt.Errorf("unsupported export format %q, use one of html, json, csv, atom", name)
+	}
+	return f, nil
+}
+
+func (f Format) NewWriter(w io.Writer) Writer {
+	return f.newWriter(w)
+}
+
+type htmlWriter struct {
+	w *netscape.Writer
+}
+
+func newHTMLWriter(w io.Writer) Writer {
+	return htmlWriter{netscape.NewWriter(w)}
+}
+
+func (h htmlWriter) Write(b domain.Bookmark) error {
+	return h.w.Write(netscape.Bookmark{Title: b.Title, URL: b.URL, AddDate: b.CreatedDate, Tags: b.Tags})
+}
+
+func (h htmlWriter) Close() error {
+	return h.w.Close()
+}
+
+// jsonWriter writes a JSON array element by element.
+type jsonWriter struct {
+	w     io.Writer
+	count int
+}
+
+func newJSONWriter(w io.Writer) Writer {
+	return &jsonWriter{w: w}
+}
+
+func (j *jsonWriter) Write(b domain.Bookmark) error {
+	data, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+	sep := ",\n"
+	if j.count == 0 {
+		sep = "[\n"
+	}
+	j.count++
+	if _, err = io.WriteString(j.w, sep); err != nil {
+		return err
+	}
+	_, err = j.w.Write(data)
+	return err
+}
+
+func (j *jsonWriter) Close() error {
+	end := "\n]\n"
+	if j.count == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(j.w, end)
+	return err
+}
+
+type csvWriter struct {
+	w             *csv.Writer
+	headerWritten bool
+}
+
+func newCSVWriter(w io.Writer) Writer {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) Write(b domain.Bookmark) error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	updated := ""
+	if b.UpdatedDate != nil {
+		updated = b.UpdatedDate.Format(time.RFC3339)
+	}
+	return c.w.Write([]string{strconv.Itoa(b.ID), csvText(b.Title), csvText(b.URL), csvText(strings.Join(b.Tags, ",")),
+		b.CreatedDate.Format(time.RFC3339), updated})
+}
+
+// csvText neutralises text that spreadsheets would evaluate as a formula by prefixing it with
+// a quote, which they show the text after.
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func (c *csvWriter) Close() error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) writeHeader() error {
+	if c.headerWritten {
+		return nil
+	}
+	c.headerWritten = true
+	return c.w.Write([]string{"id", "title", "url", "tags", "created_date", "updated_date"})
+}
+
+// atomWriter writes an Atom feed with an entry per bookmark.
+type atomWriter struct {
+	w             io.Writer
+	enc           *xml.Encoder
+	headerWritten bool
+}
+
+type atomEntry struct {
+	XMLName    xml.Name       `xml:"entry"`
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Link       atomLink       `xml:"link"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Categories []atomCategory `xml:"category"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+func newAtomWriter(w io.Writer) Writer {
+	return &atomWriter{w: w, enc: xml.NewEncoder(w)}
+}
+
+func (a *atomWriter) Write(b domain.Bookmark) error {
+	if err := a.writeHeader(); err != nil {
+		return err
+	}
+	updated := b.CreatedDate
+	if b.UpdatedDate != nil {
+		updated = *b.UpdatedDate
+	}
+	entry := atomEntry{
+		ID:        fmt.Sprintf("urn:bookmarks-go:bookmark:%d", b.ID),
+		Title:     b.Title,
+		Link:      atomLink{Href: b.URL},
+		Published: b.CreatedDate.Format(time.RFC3339),
+		Updated:   updated.Format(time.RFC3339),
+	}
+	for _, tag := range b.Tags {
+		entry.Categories = append(entry.Categories, atomCategory{Term: tag})
+	}
+	if err := a.enc.Encode(entry); err != nil {
+		return err
+	}
+	_, err := io.WriteString(a.w, "\n")
+	return err
+}
+
+func (a *atomWriter) Close() error {
+	if err := a.writeHeader(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(a.w, "</feed>\n")
+	return err
+}
+
+func (a *atomWriter) writeHeader() error {
+	if a.headerWritten {
+		return nil
+	}
+	a.headerWritten = true
+	_, err := fmt.Fprintf(a.w, `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+<title>Bookmarks</title>
+<id>urn:bookmarks-go:bookmarks</id>
+<author><name>bookmarks-go</name></author>
+<updated>%s</updated>
+`, time.Now().UTC().Format(time.RFC3339))
+	return err
+}
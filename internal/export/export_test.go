@@ -0,0 +1,82 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+var bookmarks = []domain.Bookmark{
+	{ID: 1, Title: "Go, the language", URL: "https://go.dev/", Tags: []string{"go"}, CreatedDate: time.Unix(1700000000, 0).UTC()},
+	{ID: 2, Title: "Example <b>", URL: "https://example.com/?a=1&b=2", Tags: []string{}, CreatedDate: time.Unix(1700000100, 0).UTC()},
+}
+
+func write(t *testing.T, format string, bookmarks []domain.Bookmark) []byte {
+	f, err := LookupFormat(format)
+	assert.Nil(t, err)
+	var buf bytes.Buffer
+	w := f.NewWriter(&buf)
+	for _, b := range bookmarks {
+		assert.Nil(t, w.Write(b))
+	}
+	assert.Nil(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestJSONExport(t *testing.T) {
+	var exported []domain.Bookmark
+	assert.Nil(t, json.Unmarshal(write(t, "json", bookmarks), &exported))
+	assert.Equal(t, bookmarks, exported)
+
+	assert.Nil(t, json.Unmarshal(write(t, "json", nil), &exported))
+	assert.Empty(t, exported)
+}
+
+func TestCSVExport(t *testing.T) {
+	records, err := csv.NewReader(bytes.NewReader(write(t, "csv", bookmarks))).ReadAll()
+
+	assert.Nil(t, err)
+	assert.Len(t, records, 3)
+	assert.Equal(t, []string{"id", "title", "url", "tags", "created_date", "updated_date"}, records[0])
+	assert.Equal(t, []string{"1", "Go, the language", "https://go.dev/", "go", "2023-11-14T22:13:20Z", ""}, records[1])
+}
+
+func TestCSVExportNeutralisesFormulas(t *testing.T) {
+	formulas := []domain.Bookmark{
+		{ID: 1, Title: "=HYPERLINK(\"https://evil.example\")", URL: "https://go.dev/", Tags: []string{"@cmd"}},
+		{ID: 2, Title: "+1", URL: "-2", Tags: []string{"go", "=x"}},
+		{ID: 3, Title: "\tTabbed", URL: "https://go.dev/", Tags: []string{}},
+	}
+	records, err := csv.NewReader(bytes.NewReader(write(t, "csv", formulas))).ReadAll()
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"'=HYPERLINK(\"https://evil.example\")", "https://go.dev/", "'@cmd"}, records[1][1:4])
+	assert.Equal(t, []string{"'+1", "'-2", "go,=x"}, records[2][1:4])
+	assert.Equal(t, "'\tTabbed", records[3][1])
+}
+
+func TestAtomExport(t *testing.T) {
+	var feed struct {
+		Entries []struct {
+			Title string `xml:"title"`
+			Link  struct {
+				Href string `xml:"href,attr"`
+			} `xml:"link"`
+		} `xml:"entry"`
+	}
+	assert.Nil(t, xml.Unmarshal(write(t, "atom", bookmarks), &feed))
+	assert.Len(t, feed.Entries, 2)
+	assert.Equal(t, "Example <b>", feed.Entries[1].Title)
+	assert.Equal(t, "https://example.com/?a=1&b=2", feed.Entries[1].Link.Href)
+}
+
+func TestUnknownFormat(t *testing.T) {
+	_, err := LookupFormat("pdf")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,57 @@
+// Package httpclient builds the HTTP clients used to fetch bookmarked pages.
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+const (
+	UserAgent    = "bookmarks-go/1.0 (+https://github.com/sivaprasadreddy/bookmarks-go)"
+	maxRedirects = 5
+)
+
+var ErrBlockedAddress = errors.New("address is not publicly routable")
+
+type Options struct {
+	Timeout time.Duration
+	// AllowPrivateNetworks permits connecting to loopback and private
+	// addresses, which are refused by default to prevent SSRF.
+	AllowPrivateNetworks bool
+}
+
+// New returns a client following at most 5 redirects which, unless allowed,
+// refuses to connect to addresses that are not publicly routable.
+func New(opts Options) *http.Client {
+	dialer := &net.Dialer{Timeout: opts.Timeout}
+	if !opts.AllowPrivateNetworks {
+		dialer.Control = denyPrivateAddresses
+	}
+	return &http.Client{
+		Timeout:   opts.Timeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: opts.Timeout},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			return nil
+		},
+	}
+}
+
+func denyPrivateAddresses(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, host)
+	}
+	return nil
+}
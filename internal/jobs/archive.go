@@ -0,0 +1,75 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/archive"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/domain"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/httpclient"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/readability"
+)
+
+const KindArchivePage = "archive_page"
+
+type ArchivePagePayload struct {
+	BookmarkID int `json:"bookmark_id"`
+}
+
+// ArchivePage returns the handler storing an offline copy of the page of a
+// bookmark, kept for retention or forever when it is zero. The readable text
+// of the page is stored with the bookmark to be searched.
+func ArchivePage(bookmarks domain.BookmarkRepository, archives domain.ArchiveRepository, archiver *archive.Archiver,
+	storage archive.Storage, retention time.Duration, logger *logging.Logger) Handler {
+	return func(ctx context.Context, job domain.Job) error {
+		var payload ArchivePagePayload
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return Permanent(err)
+		}
+		bookmark, err := bookmarks.FindByID(ctx, job.UserID, payload.BookmarkID)
+		if errors.Is(err, domain.ErrNotFound) {
+			logger.Infof("Bookmark id=%d no longer exists, skipping archive", payload.BookmarkID)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		page, err := archiver.Archive(ctx, bookmark.URL)
+		if errors.Is(err, archive.ErrNotHTML) || errors.Is(err, archive.ErrTooLarge) ||
+			errors.Is(err, httpclient.ErrBlockedAddress) {
+			return Permanent(err)
+		}
+		if err != nil {
+			return err
+		}
+		key := fmt.Sprintf("%d/%d.html", bookmark.OwnerID, bookmark.ID)
+		size, err := storage.Put(ctx, key, bytes.NewReader(page))
+		if err != nil {
+			return err
+		}
+		a := domain.Archive{
+			BookmarkID:  bookmark.ID,
+			StorageKey:  key,
+			Size:        size,
+			ContentType: "text/html; charset=utf-8",
+			CreatedDate: time.Now(),
+		}
+		if retention > 0 {
+			expires := a.CreatedDate.Add(retention)
+			a.ExpiresDate = &expires
+		}
+		if err = archives.Save(ctx, a); err != nil {
+			return err
+		}
+		text, err := readability.Extract(bytes.NewReader(page))
+		if err != nil {
+			return Permanent(err)
+		}
+		return bookmarks.UpdateContent(ctx, bookmark.OwnerID, bookmark.ID, text)
+	}
+}
@@ -0,0 +1,59 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/domain"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/metadata"
+)
+
+const KindFetchMetadata = "fetch_metadata"
+
+type FetchMetadataPayload struct {
+	BookmarkID int `json:"bookmark_id"`
+	// KeepTitle is set when the user chose the title, which then is not
+	// replaced by the title of the page.
+	KeepTitle bool `json:"keep_title"`
+}
+
+// FetchMetadata returns the handler fetching the page of a bookmark to fill
+// in its title, description, favicon and canonical URL.
+func FetchMetadata(repo domain.BookmarkRepository, fetcher metadata.Fetcher, logger *logging.Logger) Handler {
+	return func(ctx context.Context, job domain.Job) error {
+		var payload FetchMetadataPayload
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return Permanent(err)
+		}
+		bookmark, err := repo.FindByID(ctx, job.UserID, payload.BookmarkID)
+		if errors.Is(err, domain.ErrNotFound) {
+			logger.Infof("Bookmark id=%d no longer exists, skipping metadata", payload.BookmarkID)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		meta, err := fetcher.Fetch(ctx, bookmark.URL)
+		if errors.Is(err, metadata.ErrBlockedAddress) {
+			return Permanent(err)
+		}
+		if err != nil {
+			return err
+		}
+		if !payload.KeepTitle && meta.Title != "" {
+			bookmark.Title = meta.Title
+		}
+		bookmark.Description = meta.Description
+		bookmark.FaviconURL = meta.FaviconURL
+		bookmark.CanonicalURL = meta.CanonicalURL
+		err = repo.UpdateMetadata(ctx, bookmark)
+		if errors.Is(err, domain.ErrStaleBookmark) {
+			// the bookmark was changed in the meantime, the changes of the user win
+			logger.Infof("Bookmark id=%d changed while fetching its page, skipping metadata", payload.BookmarkID)
+			return nil
+		}
+		return err
+	}
+}
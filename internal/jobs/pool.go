@@ -0,0 +1,243 @@
+// Package jobs runs background work, such as enriching bookmarks, outside of
+// HTTP requests using the Postgres backed queue in domain.JobRepository.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/domain"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
+)
+
+const (
+	DefaultWorkers      = 4
+	DefaultPollInterval = time.Second
+	DefaultMaxAttempts  = 5
+	DefaultBackoffBase  = 10 * time.Second
+	DefaultBackoffMax   = time.Hour
+	DefaultLockTimeout  = 10 * time.Minute
+)
+
+// Handler processes a job of one kind. Returning an error schedules a retry,
+// unless the error is wrapped with Permanent.
+type Handler func(ctx context.Context, job domain.Job) error
+
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks an error as not worth retrying, the job is dead-lettered right away.
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+func IsPermanent(err error) bool {
+	var p permanentError
+	return errors.As(err, &p)
+}
+
+// Backoff returns the delay before the next run of a job that failed attempts
+// times, doubling from base up to max.
+func Backoff(attempts int, base, max time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		return max
+	}
+	return delay
+}
+
+type Options struct {
+	Workers      int
+	PollInterval time.Duration
+	BackoffBase  time.Duration
+	BackoffMax   time.Duration
+	// LockTimeout is how long a job may run before it is considered abandoned
+	// and handed to another worker.
+	LockTimeout time.Duration
+}
+
+// Pool is a set of workers polling the queue and running the handler
+// registered for the kind of each claimed job.
+type Pool struct {
+	repo     domain.JobRepository
+	handlers map[string]Handler
+	opts     Options
+	logger   *logging.Logger
+
+	stopping chan struct{}
+	abort    context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+func NewPool(repo domain.JobRepository, opts Options, logger *logging.Logger) *Pool {
+	if opts.Workers <= 0 {
+		opts.Workers = DefaultWorkers
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultPollInterval
+	}
+	if opts.BackoffBase <= 0 {
+		opts.BackoffBase = DefaultBackoffBase
+	}
+	if opts.BackoffMax <= 0 {
+		opts.BackoffMax = DefaultBackoffMax
+	}
+	if opts.LockTimeout <= 0 {
+		opts.LockTimeout = DefaultLockTimeout
+	}
+	return &Pool{repo: repo, handlers: map[string]Handler{}, opts: opts, logger: logger}
+}
+
+// Handle registers the handler for jobs of the given kind, it must be called before Start.
+func (p *Pool) Handle(kind string, h Handler) {
+	p.handlers[kind] = h
+}
+
+// Start launches the workers, they run until Stop is called.
+func (p *Pool) Start() {
+	ctx, abort := context.WithCancel(context.Background())
+	p.stopping = make(chan struct{})
+	p.abort = abort
+	p.logger.Infof("Starting %d job workers", p.opts.Workers)
+	for i := 0; i < p.opts.Workers; i++ {
+		p.wg.Add(1)
+		go p.work(ctx)
+	}
+}
+
+// Stop asks the workers to finish their current job and waits for them. Jobs
+// still running when ctx is done are cancelled and will be retried later.
+func (p *Pool) Stop(ctx context.Context) error {
+	close(p.stopping)
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		p.abort()
+		return nil
+	case <-ctx.Done():
+		p.abort()
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (p *Pool) work(ctx context.Context) {
+	defer p.wg.Done()
+	ticker := time.NewTicker(p.opts.PollInterval)
+	defer ticker.Stop()
+	for {
+		// drain the queue before waiting for the next poll
+		for {
+			select {
+			case <-p.stopping:
+				return
+			default:
+			}
+			ran, err := p.RunOnce(ctx)
+			if err != nil {
+				p.logger.Errorf("Error while running job: %v", err)
+			}
+			if !ran || err != nil {
+				break
+			}
+		}
+		select {
+		case <-p.stopping:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce claims and runs a single job, reporting whether there was one.
+func (p *Pool) RunOnce(ctx context.Context) (bool, error) {
+	now := time.Now()
+	job, err := p.repo.Claim(ctx, now, now.Add(-p.opts.LockTimeout))
+	if errors.Is(err, domain.ErrNoJobDue) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	p.logger.Infof("Running job id=%d kind=%s attempt=%d", job.ID, job.Kind, job.Attempts)
+	err = p.run(ctx, job)
+	now = time.Now()
+	// record the outcome even when the job was aborted by Stop
+	ctx = context.WithoutCancel(ctx)
+	switch {
+	case err == nil:
+		err = p.repo.Complete(ctx, job, now)
+	case IsPermanent(err) || job.Attempts >= job.MaxAttempts:
+		p.logger.Errorf("Job id=%d kind=%s failed permanently: %v", job.ID, job.Kind, err)
+		err = p.repo.DeadLetter(ctx, job, err.Error(), now)
+	default:
+		delay := Backoff(job.Attempts, p.opts.BackoffBase, p.opts.BackoffMax)
+		p.logger.Errorf("Job id=%d kind=%s failed, retrying in %s: %v", job.ID, job.Kind, delay, err)
+		err = p.repo.Retry(ctx, job, err.Error(), now.Add(delay), now)
+	}
+	if errors.Is(err, domain.ErrJobLeaseLost) {
+		// the job ran longer than LockTimeout, the worker that claimed it again records its outcome
+		p.logger.Warnf("Job id=%d kind=%s was claimed again while running, dropping its outcome", job.ID, job.Kind)
+		return true, nil
+	}
+	return true, err
+}
+
+func (p *Pool) run(ctx context.Context, job domain.Job) (err error) {
+	h, ok := p.handlers[job.Kind]
+	if !ok {
+		return Permanent(fmt.Errorf("no handler for job kind %q", job.Kind))
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return h(ctx, job)
+}
+
+// Queue enqueues jobs for the workers of a Pool.
+type Queue struct {
+	repo        domain.JobRepository
+	maxAttempts int
+}
+
+func NewQueue(repo domain.JobRepository, maxAttempts int) *Queue {
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	return &Queue{repo: repo, maxAttempts: maxAttempts}
+}
+
+// Enqueue adds a job of the given kind for the user to run as soon as possible,
+// the payload is stored as JSON.
+func (q *Queue) Enqueue(ctx context.Context, userID int, kind string, payload any) (domain.Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return domain.Job{}, err
+	}
+	now := time.Now()
+	return q.repo.Enqueue(ctx, domain.Job{
+		UserID:      userID,
+		Kind:        kind,
+		Payload:     data,
+		MaxAttempts: q.maxAttempts,
+		RunAt:       now,
+		CreatedDate: now,
+	})
+}
@@ -0,0 +1,211 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/domain"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// queueRepo keeps jobs in memory, claiming them in id order.
+type queueRepo struct {
+	jobs []domain.Job
+}
+
+// locked returns the job unless it was claimed again after job was claimed.
+func (r *queueRepo) locked(job domain.Job) (*domain.Job, error) {
+	j := &r.jobs[job.ID-1]
+	if j.Status != domain.JobRunning || !j.LockedDate.Equal(*job.LockedDate) {
+		return nil, domain.ErrJobLeaseLost
+	}
+	return j, nil
+}
+
+func (r *queueRepo) FindAll(context.Context, int, string, int) ([]domain.Job, error) {
+	return r.jobs, nil
+}
+
+func (r *queueRepo) FindByID(_ context.Context, _ int, id int) (domain.Job, error) {
+	return r.jobs[id-1], nil
+}
+
+func (r *queueRepo) Enqueue(_ context.Context, j domain.Job) (domain.Job, error) {
+	j.ID = len(r.jobs) + 1
+	j.Status = domain.JobPending
+	r.jobs = append(r.jobs, j)
+	return j, nil
+}
+
+func (r *queueRepo) Claim(_ context.Context, now time.Time, _ time.Time) (domain.Job, error) {
+	for i := range r.jobs {
+		j := &r.jobs[i]
+		if j.Status == domain.JobPending && !j.RunAt.After(now) {
+			j.Status = domain.JobRunning
+			j.Attempts++
+			j.LockedDate = &now
+			return *j, nil
+		}
+	}
+	return domain.Job{}, domain.ErrNoJobDue
+}
+
+func (r *queueRepo) Complete(_ context.Context, job domain.Job, _ time.Time) error {
+	j, err := r.locked(job)
+	if err != nil {
+		return err
+	}
+	j.Status = domain.JobSucceeded
+	return nil
+}
+
+func (r *queueRepo) Retry(_ context.Context, job domain.Job, lastError string, runAt time.Time, _ time.Time) error {
+	j, err := r.locked(job)
+	if err != nil {
+		return err
+	}
+	j.Status = domain.JobPending
+	j.LastError = lastError
+	j.RunAt = runAt
+	return nil
+}
+
+func (r *queueRepo) DeadLetter(_ context.Context, job domain.Job, lastError string, _ time.Time) error {
+	j, err := r.locked(job)
+	if err != nil {
+		return err
+	}
+	j.Status = domain.JobDead
+	j.LastError = lastError
+	return nil
+}
+
+func newTestPool(repo domain.JobRepository) *Pool {
+	logger := &logging.Logger{SugaredLogger: zap.NewNop().Sugar()}
+	return NewPool(repo, Options{BackoffBase: time.Second, BackoffMax: time.Minute}, logger)
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, time.Second, Backoff(1, time.Second, time.Minute))
+	assert.Equal(t, 2*time.Second, Backoff(2, time.Second, time.Minute))
+	assert.Equal(t, 16*time.Second, Backoff(5, time.Second, time.Minute))
+	assert.Equal(t, time.Minute, Backoff(10, time.Second, time.Minute))
+}
+
+func TestRunOnceCompletesJob(t *testing.T) {
+	repo := &queueRepo{}
+	pool := newTestPool(repo)
+	var payload []byte
+	pool.Handle("echo", func(ctx context.Context, job domain.Job) error {
+		payload = job.Payload
+		return nil
+	})
+	_, err := NewQueue(repo, 3).Enqueue(context.Background(), 1, "echo", map[string]int{"n": 1})
+	assert.Nil(t, err)
+
+	ran, err := pool.RunOnce(context.Background())
+
+	assert.True(t, ran)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"n": 1}`, string(payload))
+	assert.Equal(t, domain.JobSucceeded, repo.jobs[0].Status)
+
+	ran, err = pool.RunOnce(context.Background())
+	assert.False(t, ran)
+	assert.Nil(t, err)
+}
+
+func TestRunOnceRetriesAndDeadLetters(t *testing.T) {
+	repo := &queueRepo{}
+	pool := newTestPool(repo)
+	pool.Handle("flaky", func(ctx context.Context, job domain.Job) error {
+		return errors.New("unavailable")
+	})
+	_, _ = NewQueue(repo, 2).Enqueue(context.Background(), 1, "flaky", nil)
+
+	before := time.Now()
+	_, err := pool.RunOnce(context.Background())
+
+	assert.Nil(t, err)
+	job := repo.jobs[0]
+	assert.Equal(t, domain.JobPending, job.Status)
+	assert.Equal(t, "unavailable", job.LastError)
+	assert.False(t, job.RunAt.Before(before.Add(time.Second)))
+
+	repo.jobs[0].RunAt = time.Now()
+	_, err = pool.RunOnce(context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, domain.JobDead, repo.jobs[0].Status)
+	assert.Equal(t, 2, repo.jobs[0].Attempts)
+}
+
+func TestRunOnceDeadLettersPermanentFailures(t *testing.T) {
+	repo := &queueRepo{}
+	pool := newTestPool(repo)
+	pool.Handle("broken", func(ctx context.Context, job domain.Job) error {
+		return Permanent(errors.New("malformed payload"))
+	})
+	pool.Handle("panics", func(ctx context.Context, job domain.Job) error {
+		panic("boom")
+	})
+	queue := NewQueue(repo, 5)
+	_, _ = queue.Enqueue(context.Background(), 1, "broken", nil)
+	_, _ = queue.Enqueue(context.Background(), 1, "unknown", nil)
+	_, _ = queue.Enqueue(context.Background(), 1, "panics", nil)
+
+	for i := 0; i < 3; i++ {
+		_, err := pool.RunOnce(context.Background())
+		assert.Nil(t, err)
+	}
+
+	assert.Equal(t, domain.JobDead, repo.jobs[0].Status)
+	assert.Equal(t, domain.JobDead, repo.jobs[1].Status)
+	assert.Contains(t, repo.jobs[1].LastError, "no handler")
+	assert.Equal(t, domain.JobPending, repo.jobs[2].Status)
+	assert.Contains(t, repo.jobs[2].LastError, "boom")
+}
+
+func TestStartAndStop(t *testing.T) {
+	repo := &queueRepo{}
+	logger := &logging.Logger{SugaredLogger: zap.NewNop().Sugar()}
+	pool := NewPool(repo, Options{Workers: 1, PollInterval: time.Millisecond}, logger)
+	done := make(chan struct{})
+	pool.Handle("signal", func(ctx context.Context, job domain.Job) error {
+		close(done)
+		return nil
+	})
+	_, _ = NewQueue(repo, 1).Enqueue(context.Background(), 1, "signal", nil)
+
+	pool.Start()
+	<-done
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	assert.Nil(t, pool.Stop(ctx))
+	assert.Equal(t, domain.JobSucceeded, repo.jobs[0].Status)
+}
+
+func TestRunOnceDropsOutcomeOfReclaimedJob(t *testing.T) {
+	repo := &queueRepo{}
+	pool := newTestPool(repo)
+	pool.Handle("slow", func(ctx context.Context, job domain.Job) error {
+		// another worker considers the job abandoned and claims it again
+		reclaimed := job.LockedDate.Add(time.Minute)
+		repo.jobs[job.ID-1].LockedDate = &reclaimed
+		return errors.New("too late")
+	})
+	_, _ = NewQueue(repo, 3).Enqueue(context.Background(), 1, "slow", nil)
+
+	ran, err := pool.RunOnce(context.Background())
+
+	assert.True(t, ran)
+	assert.Nil(t, err)
+	assert.Equal(t, domain.JobRunning, repo.jobs[0].Status)
+	assert.Empty(t, repo.jobs[0].LastError)
+}
@@ -0,0 +1,166 @@
+// Package linkcheck periodically checks whether bookmarked pages still exist.
+package linkcheck
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/httpclient"
+)
+
+const (
+	DefaultTimeout      = 10 * time.Second
+	DefaultConcurrency  = 8
+	DefaultHostInterval = time.Second
+	// maxBodyBytes is read from GET responses so the connection can be reused.
+	maxBodyBytes = 64 << 10
+)
+
+type Result struct {
+	// StatusCode is 0 when no response was received.
+	StatusCode int
+	FinalURL   string
+	Err        error
+}
+
+// Broken reports whether the page could not be fetched.
+func (r Result) Broken() bool {
+	return r.Err != nil || r.StatusCode >= 400
+}
+
+type Options struct {
+	Timeout     time.Duration
+	Concurrency int
+	// HostInterval is the minimum delay between two requests to the same host.
+	HostInterval         time.Duration
+	AllowPrivateNetworks bool
+}
+
+type Checker struct {
+	client      *http.Client
+	hosts       *hostLimiter
+	concurrency int
+}
+
+func NewChecker(opts Options) *Checker {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultConcurrency
+	}
+	if opts.HostInterval <= 0 {
+		opts.HostInterval = DefaultHostInterval
+	}
+	client := httpclient.New(httpclient.Options{
+		Timeout:              opts.Timeout,
+		AllowPrivateNetworks: opts.AllowPrivateNetworks,
+	})
+	return &Checker{
+		client:      client,
+		hosts:       &hostLimiter{interval: opts.HostInterval, next: map[string]time.Time{}},
+		concurrency: opts.Concurrency,
+	}
+}
+
+// Check requests the page with HEAD, falling back to GET for servers
+// rejecting HEAD requests.
+func (c *Checker) Check(ctx context.Context, pageURL string) Result {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return Result{Err: err}
+	}
+	result := c.request(ctx, http.MethodHead, u)
+	if result.Broken() && ctx.Err() == nil {
+		result = c.request(ctx, http.MethodGet, u)
+	}
+	return result
+}
+
+// CheckAll checks the urls using at most Concurrency requests at once,
+// calling fn with the index of each checked url and its result.
+func (c *Checker) CheckAll(ctx context.Context, urls []string, fn func(i int, r Result)) {
+	sem := make(chan struct{}, c.concurrency)
+	var wg sync.WaitGroup
+	for i, u := range urls {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		}
+		wg.Add(1)
+		go func(i int, u string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			fn(i, c.Check(ctx, u))
+		}(i, u)
+	}
+	wg.Wait()
+}
+
+func (c *Checker) request(ctx context.Context, method string, u *url.URL) Result {
+	if err := c.hosts.Wait(ctx, u.Host); err != nil {
+		return Result{Err: err}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
+	if err != nil {
+		return Result{Err: err}
+	}
+	req.Header.Set("User-Agent", httpclient.UserAgent)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return Result{Err: err}
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxBodyBytes))
+	return Result{StatusCode: resp.StatusCode, FinalURL: resp.Request.URL.String()}
+}
+
+// hostLimiter spaces out requests to the same host.
+type hostLimiter struct {
+	interval time.Duration
+	mu       sync.Mutex
+	next     map[string]time.Time
+}
+
+// Wait blocks until a request to host is allowed.
+func (l *hostLimiter) Wait(ctx context.Context, host string) error {
+	if l.interval == 0 {
+		return nil
+	}
+	l.mu.Lock()
+	now := time.Now()
+	at := l.next[host]
+	if at.Before(now) {
+		at = now
+	}
+	l.next[host] = at.Add(l.interval)
+	if len(l.next) > 1024 {
+		for h, t := range l.next {
+			if t.Before(now) {
+				delete(l.next, h)
+			}
+		}
+	}
+	l.mu.Unlock()
+
+	delay := at.Sub(now)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,71 @@
+package linkcheck
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/moved":
+			http.Redirect(w, r, "/new-home", http.StatusMovedPermanently)
+		case "/new-home":
+			w.WriteHeader(http.StatusOK)
+		case "/no-head":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	checker := NewChecker(Options{HostInterval: time.Millisecond, AllowPrivateNetworks: true})
+
+	result := checker.Check(context.Background(), server.URL+"/moved")
+	assert.False(t, result.Broken())
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+	assert.Equal(t, server.URL+"/new-home", result.FinalURL)
+
+	result = checker.Check(context.Background(), server.URL+"/no-head")
+	assert.False(t, result.Broken())
+
+	result = checker.Check(context.Background(), server.URL+"/gone")
+	assert.True(t, result.Broken())
+	assert.Equal(t, http.StatusNotFound, result.StatusCode)
+
+	result = checker.Check(context.Background(), "http://127.0.0.1:1/")
+	assert.True(t, result.Broken())
+	assert.Zero(t, result.StatusCode)
+}
+
+func TestCheckAllLimitsRequestsPerHost(t *testing.T) {
+	var mu sync.Mutex
+	var times []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		times = append(times, time.Now())
+		mu.Unlock()
+	}))
+	defer server.Close()
+	checker := NewChecker(Options{Concurrency: 4, HostInterval: 50 * time.Millisecond, AllowPrivateNetworks: true})
+
+	results := make([]Result, 3)
+	checker.CheckAll(context.Background(), []string{server.URL + "/1", server.URL + "/2", server.URL + "/3"},
+		func(i int, r Result) { results[i] = r })
+
+	for _, r := range results {
+		assert.False(t, r.Broken())
+	}
+	assert.Len(t, times, 3)
+	assert.GreaterOrEqual(t, times[2].Sub(times[0]), 90*time.Millisecond)
+}
@@ -0,0 +1,114 @@
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/domain"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/schedule"
+)
+
+const (
+	DefaultInterval     = time.Hour
+	DefaultRecheckAfter = 24 * time.Hour
+	DefaultBatchSize    = 200
+)
+
+type SchedulerOptions struct {
+	// Interval is how often the scheduler looks for bookmarks due for a check.
+	Interval time.Duration
+	// RecheckAfter is how long the result of a check is trusted.
+	RecheckAfter time.Duration
+	BatchSize    int
+}
+
+// Scheduler re-validates the bookmarks of all users in the background.
+type Scheduler struct {
+	repo    domain.LinkCheckRepository
+	checker *Checker
+	opts    SchedulerOptions
+	logger  *logging.Logger
+	runner  *schedule.Runner
+}
+
+func NewScheduler(repo domain.LinkCheckRepository, checker *Checker, opts SchedulerOptions,
+	logger *logging.Logger) *Scheduler {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultInterval
+	}
+	if opts.RecheckAfter <= 0 {
+		opts.RecheckAfter = DefaultRecheckAfter
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	return &Scheduler{repo: repo, checker: checker, opts: opts, logger: logger}
+}
+
+// Start checks the links that are due right away and then every Interval until Stop is called.
+func (s *Scheduler) Start() {
+	s.runner = schedule.Every(s.opts.Interval, s.run)
+}
+
+// Stop waits for the batch being checked, cancelling it when ctx is done.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	return s.runner.Stop(ctx)
+}
+
+// run checks batches until no bookmark is due.
+func (s *Scheduler) run(ctx context.Context) {
+	for {
+		n, err := s.RunOnce(ctx)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			s.logger.Errorf("Error while checking links: %v", err)
+		}
+		if n < s.opts.BatchSize || err != nil {
+			return
+		}
+	}
+}
+
+// RunOnce checks a batch of bookmarks that are due and returns how many were checked.
+func (s *Scheduler) RunOnce(ctx context.Context) (int, error) {
+	bookmarks, err := s.repo.FindDue(ctx, time.Now().Add(-s.opts.RecheckAfter), s.opts.BatchSize)
+	if err != nil || len(bookmarks) == 0 {
+		return 0, err
+	}
+	s.logger.Infof("Checking %d links", len(bookmarks))
+	urls := make([]string, len(bookmarks))
+	for i, b := range bookmarks {
+		urls[i] = b.URL
+	}
+	results := make([]Result, len(bookmarks))
+	checked := make([]bool, len(bookmarks))
+	s.checker.CheckAll(ctx, urls, func(i int, r Result) {
+		results[i] = r
+		checked[i] = ctx.Err() == nil
+	})
+	n := 0
+	for i, r := range results {
+		if !checked[i] {
+			continue
+		}
+		check := domain.LinkCheck{
+			BookmarkID:  bookmarks[i].ID,
+			URL:         bookmarks[i].URL,
+			FinalURL:    r.FinalURL,
+			CheckedDate: time.Now(),
+			Failed:      r.Broken(),
+		}
+		if code := r.StatusCode; code != 0 {
+			check.StatusCode = &code
+		}
+		if r.Err != nil {
+			s.logger.Infof("Link check of bookmark id=%d failed: %v", bookmarks[i].ID, r.Err)
+		}
+		if err = s.repo.Record(ctx, check); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, ctx.Err()
+}
@@ -0,0 +1,197 @@
+// Package metadata fetches web pages and extracts the metadata describing them.
+package metadata
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/httpclient"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+const (
+	DefaultTimeout  = 10 * time.Second
+	DefaultMaxBytes = 2 << 20
+)
+
+var ErrBlockedAddress = httpclient.ErrBlockedAddress
+
+type Metadata struct {
+	Title        string
+	Description  string
+	FaviconURL   string
+	CanonicalURL string
+}
+
+// Fetcher fetches the metadata of the page at a URL.
+type Fetcher interface {
+	Fetch(ctx context.Context, pageURL string) (Metadata, error)
+}
+
+type HTTPFetcherOptions struct {
+	Timeout  time.Duration
+	MaxBytes int64
+	// AllowPrivateNetworks permits fetching from loopback and private
+	// addresses, which are refused by default to prevent SSRF.
+	AllowPrivateNetworks bool
+}
+
+type HTTPFetcher struct {
+	client   *http.Client
+	maxBytes int64
+}
+
+func NewHTTPFetcher(opts HTTPFetcherOptions) *HTTPFetcher {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = DefaultMaxBytes
+	}
+	client := httpclient.New(httpclient.Options{
+		Timeout:              opts.Timeout,
+		AllowPrivateNetworks: opts.AllowPrivateNetworks,
+	})
+	return &HTTPFetcher{client: client, maxBytes: opts.MaxBytes}
+}
+
+func (f *HTTPFetcher) Fetch(ctx context.Context, pageURL string) (Metadata, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return Metadata{}, err
+	}
+	req.Header.Set("User-Agent", httpclient.UserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.5")
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return Metadata{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return Metadata{}, fmt.Errorf("fetching %s: unexpected status %s", pageURL, resp.Status)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if contentType != "" && !strings.Contains(contentType, "html") {
+		return Metadata{}, nil
+	}
+	body, err := charset.NewReader(io.LimitReader(resp.Body, f.maxBytes), contentType)
+	if err != nil {
+		return Metadata{}, err
+	}
+	return Parse(body, resp.Request.URL)
+}
+
+// Parse extracts the metadata from the head of an HTML document, resolving
+// relative links against the document's URL.
+func Parse(r io.Reader, base *url.URL) (Metadata, error) {
+	var m Metadata
+	var ogTitle, ogDescription string
+	var icon, touchIcon string
+	z := html.NewTokenizer(r)
+	inTitle := false
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			if z.Err() != io.EOF {
+				return Metadata{}, z.Err()
+			}
+			return m.finish(base, ogTitle, ogDescription, icon, touchIcon), nil
+		case html.TextToken:
+			if inTitle && m.Title == "" {
+				m.Title = strings.Join(strings.Fields(string(z.Text())), " ")
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = false
+			case "head":
+				return m.finish(base, ogTitle, ogDescription, icon, touchIcon), nil
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, _ := z.TagName()
+			attrs := attributes(z)
+			switch string(name) {
+			case "title":
+				inTitle = tt == html.StartTagToken
+			case "body":
+				return m.finish(base, ogTitle, ogDescription, icon, touchIcon), nil
+			case "meta":
+				content := strings.TrimSpace(attrs["content"])
+				switch {
+				case attrs["property"] == "og:title":
+					ogTitle = content
+				case attrs["property"] == "og:description":
+					ogDescription = content
+				case strings.EqualFold(attrs["name"], "description"):
+					m.Description = content
+				}
+			case "link":
+				rel := strings.Fields(strings.ToLower(attrs["rel"]))
+				for _, value := range rel {
+					switch value {
+					case "canonical":
+						m.CanonicalURL = attrs["href"]
+					case "icon":
+						icon = attrs["href"]
+					case "apple-touch-icon":
+						if touchIcon == "" {
+							touchIcon = attrs["href"]
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func (m Metadata) finish(base *url.URL, ogTitle, ogDescription, icon, fallbackIcon string) Metadata {
+	if m.Title == "" {
+		m.Title = ogTitle
+	}
+	if ogDescription != "" {
+		m.Description = ogDescription
+	}
+	if icon == "" {
+		icon = fallbackIcon
+	}
+	if icon == "" {
+		icon = "/favicon.ico"
+	}
+	m.FaviconURL = resolve(base, icon)
+	m.CanonicalURL = resolve(base, m.CanonicalURL)
+	return m
+}
+
+func resolve(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || base == nil {
+		return ref
+	}
+	u, err := base.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	return u.String()
+}
+
+func attributes(z *html.Tokenizer) map[string]string {
+	attrs := map[string]string{}
+	for {
+		key, val, more := z.TagAttr()
+		if len(key) > 0 {
+			attrs[string(key)] = string(val)
+		}
+		if !more {
+			return attrs
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package metadata
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const page = `<!doctype html>
+<html>
+<head>
+  <title>
+    Go Concurrency   Patterns
+  </title>
+  <meta name="description" content="plain description">
+  <meta property="og:description" content="Open Graph description">
+  <link rel="canonical" href="/talks/concurrency">
+  <link rel="shortcut icon" href="/static/icon.png">
+</head>
+<body><title>not the title</title></body>
+</html>`
+
+func TestFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, "/talks/concurrency?utm_source=x", http.StatusMovedPermanently)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(page))
+	}))
+	defer server.Close()
+	fetcher := NewHTTPFetcher(HTTPFetcherOptions{AllowPrivateNetworks: true})
+
+	m, err := fetcher.Fetch(context.Background(), server.URL+"/old")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Go Concurrency Patterns", m.Title)
+	assert.Equal(t, "Open Graph description", m.Description)
+	assert.Equal(t, server.URL+"/static/icon.png", m.FaviconURL)
+	assert.Equal(t, server.URL+"/talks/concurrency", m.CanonicalURL)
+}
+
+func TestFetchLimitsSize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("<html><head>" + strings.Repeat("<meta name=x>", 1000) + "<title>Too late</title></head></html>"))
+	}))
+	defer server.Close()
+	fetcher := NewHTTPFetcher(HTTPFetcherOptions{MaxBytes: 1024, AllowPrivateNetworks: true})
+
+	m, err := fetcher.Fetch(context.Background(), server.URL)
+
+	assert.Nil(t, err)
+	assert.Empty(t, m.Title)
+	assert.Equal(t, server.URL+"/favicon.ico", m.FaviconURL)
+}
+
+func TestFetchDeniesPrivateNetworks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	_, err := NewHTTPFetcher(HTTPFetcherOptions{}).Fetch(context.Background(), server.URL)
+
+	assert.True(t, errors.Is(err, ErrBlockedAddress))
+}
+
+func TestFetchFailsOnErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	_, err := NewHTTPFetcher(HTTPFetcherOptions{AllowPrivateNetworks: true}).Fetch(context.Background(), server.URL)
+
+	assert.NotNil(t, err)
+}
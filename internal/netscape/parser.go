@@ -0,0 +1,128 @@
+// Package netscape reads and writes the Netscape bookmark file format, the
+// bookmarks.html file every browser can import and export.
+package netscape
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+type Bookmark struct {
+	Title   string
+	URL     string
+	AddDate time.Time
+	// Folders is the path of folders containing the bookmark, outermost first.
+	Folders []string
+	// Tags holds the comma separated TAGS attribute some browsers export.
+	Tags []string
+}
+
+// Parse reads all bookmarks from a Netscape bookmark file.
+func Parse(r io.Reader) ([]Bookmark, error) {
+	z := html.NewTokenizer(r)
+	var (
+		bookmarks []Bookmark
+		// folders has an entry for every open <DL>, the root list being ""
+		folders       []string
+		pendingFolder string
+		current       *Bookmark
+		inFolderTitle bool
+		text          strings.Builder
+	)
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if z.Err() == io.EOF {
+				return bookmarks, nil
+			}
+			return nil, z.Err()
+		case html.TextToken:
+			if current != nil || inFolderTitle {
+				text.Write(z.Text())
+			}
+		case html.StartTagToken:
+			name, hasAttr := z.TagName()
+			switch string(name) {
+			case "h3":
+				inFolderTitle = true
+				text.Reset()
+			case "dl":
+				folders = append(folders, pendingFolder)
+				pendingFolder = ""
+			case "a":
+				current = &Bookmark{Folders: folderPath(folders)}
+				text.Reset()
+				for hasAttr {
+					var key, val []byte
+					key, val, hasAttr = z.TagAttr()
+					switch string(key) {
+					case "href":
+						current.URL = strings.TrimSpace(string(val))
+					case "add_date":
+						current.AddDate = parseTimestamp(string(val))
+					case "tags":
+						current.Tags = splitTags(string(val))
+					}
+				}
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "h3":
+				inFolderTitle = false
+				pendingFolder = strings.TrimSpace(text.String())
+			case "dl":
+				if len(folders) > 0 {
+					folders = folders[:len(folders)-1]
+				}
+			case "a":
+				if current != nil {
+					current.Title = strings.TrimSpace(text.String())
+					bookmarks = append(bookmarks, *current)
+					current = nil
+				}
+			}
+		}
+	}
+}
+
+func folderPath(folders []string) []string {
+	var path []string
+	for _, f := range folders {
+		if f != "" {
+			path = append(path, f)
+		}
+	}
+	return path
+}
+
+// parseTimestamp parses ADD_DATE values, which are seconds since the epoch,
+// although some browsers write milli- or microseconds instead.
+func parseTimestamp(s string) time.Time {
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil || n <= 0 {
+		return time.Time{}
+	}
+	switch {
+	case n > 1e14:
+		return time.UnixMicro(n).UTC()
+	case n > 1e11:
+		return time.UnixMilli(n).UTC()
+	default:
+		return time.Unix(n, 0).UTC()
+	}
+}
+
+func splitTags(s string) []string {
+	var tags []string
+	for _, tag := range strings.Split(s, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
@@ -0,0 +1,47 @@
+package netscape
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const bookmarksHTML = `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Bookmarks</TITLE>
+<H1>Bookmarks</H1>
+<DL><p>
+    <DT><H3 ADD_DATE="1700000000" PERSONAL_TOOLBAR_FOLDER="true">Bookmarks bar</H3>
+    <DL><p>
+        <DT><A HREF="https://go.dev/" ADD_DATE="1700000100" ICON="data:image/png;base64,AAA">The Go Programming Language</A>
+        <DT><H3>Testing</H3>
+        <DL><p>
+            <DT><A HREF="https://testcontainers.com/" ADD_DATE="1700000200000" TAGS="docker,Integration">Testcontainers &amp; friends</A>
+        </DL><p>
+    </DL><p>
+    <DT><A HREF="https://example.com/">Example</A>
+</DL><p>
+`
+
+func TestParse(t *testing.T) {
+	bookmarks, err := Parse(strings.NewReader(bookmarksHTML))
+
+	assert.Nil(t, err)
+	assert.Len(t, bookmarks, 3)
+
+	assert.Equal(t, "The Go Programming Language", bookmarks[0].Title)
+	assert.Equal(t, "https://go.dev/", bookmarks[0].URL)
+	assert.Equal(t, []string{"Bookmarks bar"}, bookmarks[0].Folders)
+	assert.Equal(t, time.Unix(1700000100, 0).UTC(), bookmarks[0].AddDate)
+
+	assert.Equal(t, "Testcontainers & friends", bookmarks[1].Title)
+	assert.Equal(t, []string{"Bookmarks bar", "Testing"}, bookmarks[1].Folders)
+	assert.Equal(t, []string{"docker", "Integration"}, bookmarks[1].Tags)
+	assert.Equal(t, time.UnixMilli(1700000200000).UTC(), bookmarks[1].AddDate)
+
+	assert.Equal(t, "Example", bookmarks[2].Title)
+	assert.Empty(t, bookmarks[2].Folders)
+	assert.True(t, bookmarks[2].AddDate.IsZero())
+}
@@ -0,0 +1,63 @@
+package netscape
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+const header = `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<!-- This is an automatically generated file.
+     It will be read and overwritten.
+     DO NOT EDIT! -->
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Bookmarks</TITLE>
+<H1>Bookmarks</H1>
+<DL><p>
+`
+
+// Writer writes bookmarks as a flat Netscape bookmark file, folders are
+// not written but tags are kept in the TAGS attribute.
+type Writer struct {
+	w             io.Writer
+	headerWritten bool
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+func (w *Writer) Write(b Bookmark) error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	var attrs strings.Builder
+	fmt.Fprintf(&attrs, ` HREF="%s"`, html.EscapeString(b.URL))
+	if !b.AddDate.IsZero() {
+		fmt.Fprintf(&attrs, ` ADD_DATE="%d"`, b.AddDate.Unix())
+	}
+	if len(b.Tags) > 0 {
+		fmt.Fprintf(&attrs, ` TAGS="%s"`, html.EscapeString(strings.Join(b.Tags, ",")))
+	}
+	_, err := fmt.Fprintf(w.w, "    <DT><A%s>%s</A>\n", attrs.String(), html.EscapeString(b.Title))
+	return err
+}
+
+// Close writes the end of the file, it does not close the underlying writer.
+func (w *Writer) Close() error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w.w, "</DL><p>\n")
+	return err
+}
+
+func (w *Writer) writeHeader() error {
+	if w.headerWritten {
+		return nil
+	}
+	w.headerWritten = true
+	_, err := io.WriteString(w.w, header)
+	return err
+}
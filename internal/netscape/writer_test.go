@@ -0,0 +1,27 @@
+package netscape
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriterOutputCanBeParsed(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	bookmarks := []Bookmark{
+		{Title: `Go <"generics">`, URL: "https://go.dev/?a=1&b=2", AddDate: time.Unix(1700000000, 0).UTC(), Tags: []string{"go", "lang"}},
+		{Title: "Example", URL: "https://example.com/"},
+	}
+	for _, b := range bookmarks {
+		assert.Nil(t, w.Write(b))
+	}
+	assert.Nil(t, w.Close())
+
+	parsed, err := Parse(&buf)
+
+	assert.Nil(t, err)
+	assert.Equal(t, bookmarks, parsed)
+}
@@ -0,0 +1,246 @@
+// Package readability extracts the main text of an HTML page, leaving out
+// navigation, sidebars, comments and other boilerplate.
+package readability
+
+import (
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// MaxTextLength bounds the number of characters extracted from a page.
+const MaxTextLength = 100000
+
+var (
+	unlikelyCandidates = regexp.MustCompile(`(?i)banner|breadcrumb|comment|community|cookie|disqus|footer|header|` +
+		`menu|modal|nav|popup|promo|related|remark|share|sidebar|social|sponsor|subscribe|advert|\bads?\b`)
+	maybeCandidates   = regexp.MustCompile(`(?i)and|article|body|column|content|main|shadow`)
+	positiveAttribute = regexp.MustCompile(`(?i)article|body|content|entry|main|page|post|text|blog|story`)
+	negativeAttribute = regexp.MustCompile(`(?i)comment|footer|footnote|meta|nav|related|share|sidebar|social|` +
+		`sponsor|widget|\bads?\b`)
+)
+
+// skippedElements never hold article text.
+var skippedElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true, atom.Svg: true,
+	atom.Nav: true, atom.Header: true, atom.Footer: true, atom.Aside: true, atom.Form: true,
+	atom.Button: true, atom.Input: true, atom.Select: true, atom.Textarea: true, atom.Iframe: true,
+}
+
+// blockElements start a new line in the extracted text.
+var blockElements = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true, atom.Main: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Li: true, atom.Ul: true, atom.Ol: true, atom.Pre: true, atom.Blockquote: true, atom.Br: true,
+	atom.Table: true, atom.Tr: true, atom.Td: true, atom.Th: true, atom.Dd: true, atom.Dt: true,
+	atom.Figcaption: true,
+}
+
+// paragraphElements are scored by their text, crediting their ancestors.
+var paragraphElements = map[atom.Atom]bool{
+	atom.P: true, atom.Pre: true, atom.Td: true, atom.Blockquote: true, atom.Li: true,
+}
+
+// Extract returns the main text of the page read from r, with one line per
+// paragraph. The text is empty when the page has no content worth reading.
+func Extract(r io.Reader) (string, error) {
+	doc, err := html.ParseWithOptions(r, html.ParseOptionEnableScripting(false))
+	if err != nil {
+		return "", err
+	}
+	removeBoilerplate(doc)
+	root := bestCandidate(doc)
+	if root == nil {
+		return "", nil
+	}
+	var b strings.Builder
+	writeText(&b, root)
+	return truncate(normalize(b.String()), MaxTextLength), nil
+}
+
+func removeBoilerplate(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.CommentNode || (c.Type == html.ElementNode && unlikely(c)) {
+			n.RemoveChild(c)
+		} else {
+			removeBoilerplate(c)
+		}
+		c = next
+	}
+}
+
+func unlikely(n *html.Node) bool {
+	if skippedElements[n.DataAtom] {
+		return true
+	}
+	if n.DataAtom == atom.Body || n.DataAtom == atom.Html || n.DataAtom == atom.Article || n.DataAtom == atom.Main {
+		return false
+	}
+	match := attr(n, "class") + " " + attr(n, "id")
+	return unlikelyCandidates.MatchString(match) && !maybeCandidates.MatchString(match)
+}
+
+// bestCandidate scores the ancestors of every paragraph by the amount of
+// text they contain and returns the highest scoring one.
+func bestCandidate(doc *html.Node) *html.Node {
+	scores := map[*html.Node]float64{}
+	var visit func(n *html.Node)
+	visit = func(n *html.Node) {
+		if n.Type == html.ElementNode && paragraphElements[n.DataAtom] {
+			text := normalize(textOf(n))
+			if length := utf8.RuneCountInString(text); length >= 25 {
+				score := 1 + float64(strings.Count(text, ",")) + min(float64(length)/100, 3)
+				if parent := n.Parent; parent != nil {
+					addScore(scores, parent, score)
+					if grandparent := parent.Parent; grandparent != nil {
+						addScore(scores, grandparent, score/2)
+					}
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			visit(c)
+		}
+	}
+	visit(doc)
+
+	var best *html.Node
+	bestScore := 0.0
+	for n, score := range scores {
+		score *= 1 - linkDensity(n)
+		if best == nil || score > bestScore {
+			best, bestScore = n, score
+		}
+	}
+	if best == nil {
+		best = findElement(doc, atom.Article)
+	}
+	if best == nil {
+		best = findElement(doc, atom.Body)
+	}
+	return best
+}
+
+func addScore(scores map[*html.Node]float64, n *html.Node, score float64) {
+	if _, ok := scores[n]; !ok {
+		scores[n] = classWeight(n)
+	}
+	scores[n] += score
+}
+
+func classWeight(n *html.Node) float64 {
+	weight := 0.0
+	for _, value := range []string{attr(n, "class"), attr(n, "id")} {
+		if value == "" {
+			continue
+		}
+		if negativeAttribute.MatchString(value) {
+			weight -= 25
+		}
+		if positiveAttribute.MatchString(value) {
+			weight += 25
+		}
+	}
+	if n.DataAtom == atom.Article || n.DataAtom == atom.Main {
+		weight += 10
+	}
+	return weight
+}
+
+// linkDensity is the fraction of the text of n that is inside links.
+func linkDensity(n *html.Node) float64 {
+	total := len(textOf(n))
+	if total == 0 {
+		return 0
+	}
+	links := 0
+	var visit func(n *html.Node)
+	visit = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.A {
+			links += len(textOf(n))
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			visit(c)
+		}
+	}
+	visit(n)
+	return float64(links) / float64(total)
+}
+
+func textOf(n *html.Node) string {
+	var b strings.Builder
+	var visit func(n *html.Node)
+	visit = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			visit(c)
+		}
+	}
+	visit(n)
+	return b.String()
+}
+
+func writeText(b *strings.Builder, n *html.Node) {
+	if n.Type == html.TextNode {
+		b.WriteString(n.Data)
+		return
+	}
+	block := n.Type == html.ElementNode && blockElements[n.DataAtom]
+	if block {
+		b.WriteByte('\n')
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		writeText(b, c)
+	}
+	if block {
+		b.WriteByte('\n')
+	}
+}
+
+// normalize collapses white space within lines and drops empty lines.
+func normalize(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func truncate(text string, maxRunes int) string {
+	if utf8.RuneCountInString(text) <= maxRunes {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:maxRunes])
+}
+
+func findElement(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findElement(c, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,45 @@
+package readability
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const article = `<!doctype html>
+<html>
+<head><title>Structured concurrency</title><style>p { color: red }</style></head>
+<body>
+  <nav><a href="/">Home</a> <a href="/blog">Blog</a></nav>
+  <div class="sidebar">
+    <p>Subscribe to our newsletter, it is full of great content and updates.</p>
+  </div>
+  <div id="content" class="post">
+    <h1>Structured concurrency in Go</h1>
+    <p>Goroutines are cheap, but leaking them is easy, so every goroutine should have an owner.</p>
+    <p>An errgroup ties the lifetime of goroutines to a function call, and cancels the others on error.</p>
+    <script>track("read")</script>
+  </div>
+  <div class="comments">
+    <p>First comment, great article, thanks for writing it up!</p>
+  </div>
+  <footer><p>Copyright 2024, all rights reserved by the author of this blog.</p></footer>
+</body>
+</html>`
+
+func TestExtract(t *testing.T) {
+	text, err := Extract(strings.NewReader(article))
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Structured concurrency in Go\n"+
+		"Goroutines are cheap, but leaking them is easy, so every goroutine should have an owner.\n"+
+		"An errgroup ties the lifetime of goroutines to a function call, and cancels the others on error.", text)
+}
+
+func TestExtractWithoutParagraphs(t *testing.T) {
+	text, err := Extract(strings.NewReader(`<html><body><nav>Menu</nav><div>Just   a short note</div></body></html>`))
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Just a short note", text)
+}
@@ -0,0 +1,49 @@
+// Package schedule runs periodic background tasks.
+package schedule
+
+import (
+	"context"
+	"time"
+)
+
+// Runner repeatedly runs a task until it is stopped.
+type Runner struct {
+	stopping chan struct{}
+	abort    context.CancelFunc
+	done     chan struct{}
+}
+
+// Every runs fn right away and then every interval until Stop is called.
+// Runs never overlap, a run taking longer than interval delays the next one.
+func Every(interval time.Duration, fn func(ctx context.Context)) *Runner {
+	ctx, abort := context.WithCancel(context.Background())
+	r := &Runner{stopping: make(chan struct{}), abort: abort, done: make(chan struct{})}
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			fn(ctx)
+			select {
+			case <-r.stopping:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return r
+}
+
+// Stop waits for the current run to finish, cancelling its context when ctx is done.
+func (r *Runner) Stop(ctx context.Context) error {
+	close(r.stopping)
+	defer r.abort()
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		r.abort()
+		<-r.done
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,33 @@
+package schedule
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvery(t *testing.T) {
+	runs := make(chan struct{}, 10)
+	r := Every(time.Millisecond, func(ctx context.Context) {
+		runs <- struct{}{}
+	})
+	<-runs
+	<-runs
+
+	assert.Nil(t, r.Stop(context.Background()))
+}
+
+func TestStopCancelsSlowRun(t *testing.T) {
+	started := make(chan struct{})
+	r := Every(time.Hour, func(ctx context.Context) {
+		close(started)
+		<-ctx.Done()
+	})
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, r.Stop(ctx), context.DeadlineExceeded)
+}
@@ -0,0 +1,65 @@
+// Package trash permanently deletes the bookmarks kept in the trash past their retention period.
+package trash
+
+import (
+	"context"
+	"time"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/domain"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/schedule"
+)
+
+const (
+	DefaultPurgeInterval = time.Hour
+	purgeBatchSize       = 100
+)
+
+// Purger deletes the bookmarks trashed longer than the retention period ago.
+type Purger struct {
+	repo      domain.BookmarkRepository
+	retention time.Duration
+	interval  time.Duration
+	logger    *logging.Logger
+	runner    *schedule.Runner
+}
+
+// NewPurger returns a Purger keeping trashed bookmarks for retention, zero keeps them forever.
+func NewPurger(repo domain.BookmarkRepository, retention time.Duration, interval time.Duration,
+	logger *logging.Logger) *Purger {
+	if interval <= 0 {
+		interval = DefaultPurgeInterval
+	}
+	return &Purger{repo: repo, retention: retention, interval: interval, logger: logger}
+}
+
+// Start purges the trash right away and then every interval until Stop is called.
+func (p *Purger) Start() {
+	p.runner = schedule.Every(p.interval, func(ctx context.Context) {
+		for {
+			n, err := p.RunOnce(ctx)
+			if err != nil {
+				p.logger.Errorf("Error while purging trashed bookmarks: %v", err)
+			}
+			if n < purgeBatchSize || err != nil {
+				return
+			}
+		}
+	})
+}
+
+func (p *Purger) Stop(ctx context.Context) error {
+	return p.runner.Stop(ctx)
+}
+
+// RunOnce purges a batch of trashed bookmarks and returns how many were deleted.
+func (p *Purger) RunOnce(ctx context.Context) (int, error) {
+	if p.retention <= 0 {
+		return 0, nil
+	}
+	n, err := p.repo.PurgeDeleted(ctx, time.Now().Add(-p.retention), purgeBatchSize)
+	if n > 0 {
+		p.logger.Infof("Purged %d trashed bookmarks", n)
+	}
+	return n, err
+}
@@ -0,0 +1,22 @@
+package bookmarks
+
+import (
+	"context"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/config"
+	"github.com/sivaprasadreddy/bookmarks-go/internal/logging"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// SetPassword sets the password of the user with the given email in the database of the
+// configured storage backend, which lets accounts without a usable password log in again.
+func SetPassword(cfg config.AppConfig, email string, password string) error {
+	app := &App{cfg: cfg, logger: logging.NewLogger(cfg)}
+	app.initRepositories()
+	defer app.closeDb()
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	return app.repos.users.UpdatePassword(context.Background(), email, string(hash))
+}
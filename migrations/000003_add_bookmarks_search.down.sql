@@ -0,0 +1,2 @@
+drop index if exists bookmarks_search_vector_idx;
+alter table bookmarks drop column if exists search_vector;
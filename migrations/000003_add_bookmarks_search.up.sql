@@ -0,0 +1,7 @@
+alter table bookmarks add column search_vector tsvector
+    generated always as (
+        setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+        setweight(to_tsvector('simple', coalesce(url, '')), 'B')
+    ) stored;
+
+create index bookmarks_search_vector_idx on bookmarks using gin (search_vector);
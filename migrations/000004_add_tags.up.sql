@@ -0,0 +1,28 @@
+create table tags
+(
+    id   bigserial not null,
+    name varchar   not null,
+    primary key (id),
+    unique (name)
+);
+
+create table bookmark_tags
+(
+    bookmark_id bigint not null references bookmarks (id) on delete cascade,
+    tag_id      bigint not null references tags (id) on delete cascade,
+    primary key (bookmark_id, tag_id)
+);
+
+create index bookmark_tags_tag_id_idx on bookmark_tags (tag_id);
+
+insert into tags(name) values ('docker'), ('java'), ('spring-boot'), ('testing'), ('ci');
+
+insert into bookmark_tags(bookmark_id, tag_id)
+select b.id, t.id
+from bookmarks b
+         join tags t on
+    (t.name = 'docker' and b.url like '%docker%') or
+    (t.name = 'java' and b.url not like '%docker%') or
+    (t.name = 'spring-boot' and b.url like '%spring-boot%') or
+    (t.name = 'testing' and b.url like '%testing%') or
+    (t.name = 'ci' and b.url like '%continuous-integration%');
@@ -0,0 +1,3 @@
+drop index if exists bookmarks_owner_id_idx;
+alter table bookmarks drop column if exists owner_id;
+drop table if exists users;
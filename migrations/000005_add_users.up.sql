@@ -0,0 +1,20 @@
+create table users
+(
+    id            bigserial not null,
+    name          varchar   not null,
+    email         varchar   not null,
+    password_hash varchar   not null,
+    created_at    timestamp not null,
+    primary key (id),
+    unique (email)
+);
+
+-- password: demo1234
+insert into users(name, email, password_hash, created_at) values
+('Demo', 'demo@example.com', '$2a$10$0guy84wpgm6p2BN/m9KY9.huyy6gcKkY5l6mMkqMLYXwihD5/Xbjq', CURRENT_TIMESTAMP);
+
+alter table bookmarks add column owner_id bigint references users (id) on delete cascade;
+update bookmarks set owner_id = (select id from users where email = 'demo@example.com');
+alter table bookmarks alter column owner_id set not null;
+
+create index bookmarks_owner_id_idx on bookmarks (owner_id);
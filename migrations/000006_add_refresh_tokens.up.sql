@@ -0,0 +1,13 @@
+create table refresh_tokens
+(
+    id         bigserial not null,
+    user_id    bigint    not null references users (id) on delete cascade,
+    token_hash varchar   not null,
+    expires_at timestamp not null,
+    created_at timestamp not null,
+    revoked_at timestamp,
+    primary key (id),
+    unique (token_hash)
+);
+
+create index refresh_tokens_user_id_idx on refresh_tokens (user_id);
@@ -0,0 +1,16 @@
+create table api_keys
+(
+    id           bigserial not null,
+    user_id      bigint    not null references users (id) on delete cascade,
+    label        varchar   not null,
+    scope        varchar   not null,
+    prefix       varchar   not null,
+    key_hash     varchar   not null,
+    created_at   timestamp not null,
+    last_used_at timestamp,
+    revoked_at   timestamp,
+    primary key (id),
+    unique (key_hash)
+);
+
+create index api_keys_user_id_idx on api_keys (user_id);
@@ -0,0 +1,4 @@
+alter table bookmarks
+    drop column canonical_url,
+    drop column favicon_url,
+    drop column description;
@@ -0,0 +1,4 @@
+alter table bookmarks
+    add column description   text    not null default '',
+    add column favicon_url   varchar not null default '',
+    add column canonical_url varchar not null default '';
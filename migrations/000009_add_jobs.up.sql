@@ -0,0 +1,19 @@
+create table jobs
+(
+    id           bigserial not null,
+    user_id      bigint    not null references users (id) on delete cascade,
+    kind         varchar   not null,
+    payload      jsonb     not null default '{}',
+    status       varchar   not null,
+    attempts     int       not null default 0,
+    max_attempts int       not null,
+    last_error   text      not null default '',
+    run_at       timestamp not null,
+    locked_at    timestamp,
+    created_at   timestamp not null,
+    updated_at   timestamp,
+    primary key (id)
+);
+
+create index jobs_runnable_idx on jobs (run_at, id) where status in ('pending', 'running');
+create index jobs_user_id_idx on jobs (user_id, id);
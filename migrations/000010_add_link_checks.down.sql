@@ -0,0 +1,7 @@
+drop index if exists bookmarks_link_checked_at_idx;
+
+alter table bookmarks
+    drop column link_failures,
+    drop column link_checked_at,
+    drop column link_final_url,
+    drop column link_status_code;
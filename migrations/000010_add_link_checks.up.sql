@@ -0,0 +1,7 @@
+alter table bookmarks
+    add column link_status_code int,
+    add column link_final_url   varchar not null default '',
+    add column link_checked_at  timestamp,
+    add column link_failures    int     not null default 0;
+
+create index bookmarks_link_checked_at_idx on bookmarks (link_checked_at nulls first, id);
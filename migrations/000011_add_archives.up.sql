@@ -0,0 +1,13 @@
+-- archives outlive their bookmarks until their stored copy has been purged
+create table archives
+(
+    bookmark_id  bigint    not null,
+    storage_key  varchar   not null,
+    size         bigint    not null,
+    content_type varchar   not null,
+    created_at   timestamp not null,
+    expires_at   timestamp,
+    primary key (bookmark_id)
+);
+
+create index archives_expires_at_idx on archives (expires_at);
@@ -0,0 +1,12 @@
+drop index if exists bookmarks_search_vector_idx;
+alter table bookmarks drop column search_vector;
+
+alter table bookmarks drop column content;
+
+alter table bookmarks add column search_vector tsvector
+    generated always as (
+        setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+        setweight(to_tsvector('simple', coalesce(url, '')), 'B')
+    ) stored;
+
+create index bookmarks_search_vector_idx on bookmarks using gin (search_vector);
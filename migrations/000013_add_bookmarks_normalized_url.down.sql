@@ -0,0 +1,3 @@
+drop index if exists bookmarks_owner_normalized_url_key;
+
+alter table bookmarks drop column normalized_url;
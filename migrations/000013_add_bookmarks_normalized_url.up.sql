@@ -0,0 +1,4 @@
+-- null for bookmarks saved before urls were normalized, see GET /api/bookmarks/duplicates
+alter table bookmarks add column normalized_url varchar;
+
+create unique index bookmarks_owner_normalized_url_key on bookmarks (owner_id, normalized_url);
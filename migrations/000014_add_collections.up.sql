@@ -0,0 +1,25 @@
+create table collections
+(
+    id         bigserial not null,
+    owner_id   bigint    not null references users (id) on delete cascade,
+    -- deleting a collection cascades or re-parents its children, see CollectionRepository.Delete
+    parent_id  bigint references collections (id),
+    name       varchar   not null,
+    created_at timestamp not null,
+    updated_at timestamp,
+    primary key (id)
+);
+
+create index collections_owner_id_parent_id_idx on collections (owner_id, parent_id);
+create index collections_parent_id_idx on collections (parent_id);
+
+create table collection_bookmarks
+(
+    collection_id bigint not null references collections (id) on delete cascade,
+    bookmark_id   bigint not null references bookmarks (id) on delete cascade,
+    position      int    not null,
+    primary key (collection_id, bookmark_id)
+);
+
+create index collection_bookmarks_position_idx on collection_bookmarks (collection_id, position);
+create index collection_bookmarks_bookmark_id_idx on collection_bookmarks (bookmark_id);
@@ -0,0 +1,2 @@
+-- incremented on every edit of a bookmark, used for optimistic concurrency control
+alter table bookmarks add column version int not null default 1;
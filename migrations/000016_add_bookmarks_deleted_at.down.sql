@@ -0,0 +1,7 @@
+delete from bookmarks where deleted_at is not null;
+
+drop index bookmarks_deleted_at_idx;
+drop index bookmarks_owner_normalized_url_key;
+create unique index bookmarks_owner_normalized_url_key on bookmarks (owner_id, normalized_url);
+
+alter table bookmarks drop column deleted_at;
@@ -0,0 +1,8 @@
+-- set when a bookmark is moved to the trash, trashed bookmarks are purged after the retention period
+alter table bookmarks add column deleted_at timestamp;
+
+-- a trashed bookmark does not keep its url from being bookmarked again
+drop index bookmarks_owner_normalized_url_key;
+create unique index bookmarks_owner_normalized_url_key on bookmarks (owner_id, normalized_url) where deleted_at is null;
+
+create index bookmarks_deleted_at_idx on bookmarks (deleted_at) where deleted_at is not null;
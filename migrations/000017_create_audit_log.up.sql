@@ -0,0 +1,30 @@
+create table audit_log
+(
+    id          bigserial not null,
+    -- no foreign keys, entries outlive the users and bookmarks they are about
+    owner_id    bigint    not null,
+    actor_id    bigint    not null,
+    api_key_id  bigint,
+    bookmark_id bigint    not null,
+    action      varchar   not null,
+    changes     jsonb     not null,
+    client_ip   varchar   not null,
+    created_at  timestamp not null,
+    primary key (id)
+);
+
+create index audit_log_owner_id_idx on audit_log (owner_id, id);
+create index audit_log_bookmark_id_idx on audit_log (bookmark_id, id);
+
+create function audit_log_append_only() returns trigger as
+$$
+begin
+    raise exception 'audit_log is append-only';
+end;
+$$ language plpgsql;
+
+create trigger audit_log_append_only
+    before update or delete
+    on audit_log
+    for each row
+execute function audit_log_append_only();
@@ -0,0 +1 @@
+-- The published password is not restored, see 000018_disable_demo_password.up.sql.
@@ -0,0 +1,8 @@
+package migrations
+
+import "embed"
+
+// FS holds the Postgres migrations and, in the sqlite directory, those of the SQLite storage backend.
+//
+//go:embed *.sql sqlite/*.sql
+var FS embed.FS
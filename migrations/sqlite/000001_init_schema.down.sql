@@ -0,0 +1,12 @@
+drop table if exists audit_log;
+drop table if exists collection_bookmarks;
+drop table if exists collections;
+drop table if exists archives;
+drop table if exists jobs;
+drop table if exists bookmark_tags;
+drop table if exists tags;
+drop table if exists bookmarks_fts;
+drop table if exists bookmarks;
+drop table if exists api_keys;
+drop table if exists refresh_tokens;
+drop table if exists users;
@@ -0,0 +1,202 @@
+-- the schema of the Postgres migrations up to 000017_create_audit_log, timestamps are stored
+-- as text in UTC and ids are never reused, like the bigserial ids in Postgres
+create table users
+(
+    id            integer primary key autoincrement,
+    name          varchar   not null,
+    email         varchar   not null unique,
+    password_hash varchar   not null,
+    created_at    timestamp not null
+);
+
+create table refresh_tokens
+(
+    id         integer primary key autoincrement,
+    user_id    integer   not null references users (id) on delete cascade,
+    token_hash varchar   not null unique,
+    expires_at timestamp not null,
+    created_at timestamp not null,
+    revoked_at timestamp
+);
+
+create index refresh_tokens_user_id_idx on refresh_tokens (user_id);
+
+create table api_keys
+(
+    id           integer primary key autoincrement,
+    user_id      integer   not null references users (id) on delete cascade,
+    label        varchar   not null,
+    scope        varchar   not null,
+    prefix       varchar   not null,
+    key_hash     varchar   not null unique,
+    created_at   timestamp not null,
+    last_used_at timestamp,
+    revoked_at   timestamp
+);
+
+create index api_keys_user_id_idx on api_keys (user_id);
+
+create table bookmarks
+(
+    id               integer primary key autoincrement,
+    owner_id         integer   not null references users (id) on delete cascade,
+    url              varchar   not null,
+    -- null for bookmarks saved before urls were normalized, see GET /api/bookmarks/duplicates
+    normalized_url   varchar,
+    title            varchar   not null,
+    description      text      not null default '',
+    favicon_url      varchar   not null default '',
+    canonical_url    varchar   not null default '',
+    content          text      not null default '',
+    link_status_code int,
+    link_final_url   varchar   not null default '',
+    link_checked_at  timestamp,
+    link_failures    int       not null default 0,
+    version          int       not null default 1,
+    created_at       timestamp not null,
+    updated_at       timestamp,
+    deleted_at       timestamp
+);
+
+create index bookmarks_owner_id_idx on bookmarks (owner_id);
+create unique index bookmarks_owner_normalized_url_key on bookmarks (owner_id, normalized_url) where deleted_at is null;
+create index bookmarks_link_checked_at_idx on bookmarks (link_checked_at, id);
+create index bookmarks_deleted_at_idx on bookmarks (deleted_at) where deleted_at is not null;
+
+-- full-text index of the bookmarks, kept in sync by the triggers below
+create virtual table bookmarks_fts using fts5
+(
+    title,
+    url,
+    content,
+    content = 'bookmarks',
+    content_rowid = 'id',
+    tokenize = 'porter unicode61'
+);
+
+create trigger bookmarks_fts_insert
+    after insert
+    on bookmarks
+begin
+    insert into bookmarks_fts(rowid, title, url, content) values (new.id, new.title, new.url, new.content);
+end;
+
+create trigger bookmarks_fts_delete
+    after delete
+    on bookmarks
+begin
+    insert into bookmarks_fts(bookmarks_fts, rowid, title, url, content)
+    values ('delete', old.id, old.title, old.url, old.content);
+end;
+
+create trigger bookmarks_fts_update
+    after update of title, url, content
+    on bookmarks
+begin
+    insert into bookmarks_fts(bookmarks_fts, rowid, title, url, content)
+    values ('delete', old.id, old.title, old.url, old.content);
+    insert into bookmarks_fts(rowid, title, url, content) values (new.id, new.title, new.url, new.content);
+end;
+
+create table tags
+(
+    id   integer primary key autoincrement,
+    name varchar not null unique
+);
+
+create table bookmark_tags
+(
+    bookmark_id integer not null references bookmarks (id) on delete cascade,
+    tag_id      integer not null references tags (id) on delete cascade,
+    primary key (bookmark_id, tag_id)
+);
+
+create index bookmark_tags_tag_id_idx on bookmark_tags (tag_id);
+
+create table jobs
+(
+    id           integer primary key autoincrement,
+    user_id      integer   not null references users (id) on delete cascade,
+    kind         varchar   not null,
+    payload      blob      not null default '{}',
+    status       varchar   not null,
+    attempts     int       not null default 0,
+    max_attempts int       not null,
+    last_error   text      not null default '',
+    run_at       timestamp not null,
+    locked_at    timestamp,
+    created_at   timestamp not null,
+    updated_at   timestamp
+);
+
+create index jobs_runnable_idx on jobs (run_at, id) where status in ('pending', 'running');
+create index jobs_user_id_idx on jobs (user_id, id);
+
+-- archives outlive their bookmarks until their stored copy has been purged
+create table archives
+(
+    bookmark_id  integer   not null primary key,
+    storage_key  varchar   not null,
+    size         integer   not null,
+    content_type varchar   not null,
+    created_at   timestamp not null,
+    expires_at   timestamp
+);
+
+create index archives_expires_at_idx on archives (expires_at);
+
+create table collections
+(
+    id         integer primary key autoincrement,
+    owner_id   integer   not null references users (id) on delete cascade,
+    -- deleting a collection cascades or re-parents its children, see CollectionRepository.Delete
+    parent_id  integer references collections (id),
+    name       varchar   not null,
+    created_at timestamp not null,
+    updated_at timestamp
+);
+
+create index collections_owner_id_parent_id_idx on collections (owner_id, parent_id);
+create index collections_parent_id_idx on collections (parent_id);
+
+create table collection_bookmarks
+(
+    collection_id integer not null references collections (id) on delete cascade,
+    bookmark_id   integer not null references bookmarks (id) on delete cascade,
+    position      int     not null,
+    primary key (collection_id, bookmark_id)
+);
+
+create index collection_bookmarks_position_idx on collection_bookmarks (collection_id, position);
+create index collection_bookmarks_bookmark_id_idx on collection_bookmarks (bookmark_id);
+
+create table audit_log
+(
+    id          integer primary key autoincrement,
+    -- no foreign keys, entries outlive the users and bookmarks they are about
+    owner_id    integer   not null,
+    actor_id    integer   not null,
+    api_key_id  integer,
+    bookmark_id integer   not null,
+    action      varchar   not null,
+    changes     text      not null,
+    client_ip   varchar   not null,
+    created_at  timestamp not null
+);
+
+create index audit_log_owner_id_idx on audit_log (owner_id, id);
+create index audit_log_bookmark_id_idx on audit_log (bookmark_id, id);
+
+create trigger audit_log_no_update
+    before update
+    on audit_log
+begin
+    select raise(abort, 'audit_log is append-only');
+end;
+
+create trigger audit_log_no_delete
+    before delete
+    on audit_log
+begin
+    select raise(abort, 'audit_log is append-only');
+end;
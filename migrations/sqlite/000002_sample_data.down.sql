@@ -0,0 +1,3 @@
+delete from bookmarks;
+delete from tags;
+delete from users where email = 'demo@example.com';
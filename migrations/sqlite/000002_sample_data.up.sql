@@ -0,0 +1,24 @@
+-- password: demo1234
+insert into users(name, email, password_hash, created_at) values
+('Demo', 'demo@example.com', '$2a$10$0guy84wpgm6p2BN/m9KY9.huyy6gcKkY5l6mMkqMLYXwihD5/Xbjq', CURRENT_TIMESTAMP);
+
+with demo(id) as (select id from users where email = 'demo@example.com')
+insert into bookmarks(owner_id, url, title, created_at) values
+((select id from demo), 'https://linuxize.com/post/how-to-remove-docker-images-containers-volumes-and-networks/','How To Remove Docker Containers, Images, Volumes, and Networks',CURRENT_TIMESTAMP),
+((select id from demo), 'https://reflectoring.io/unit-testing-spring-boot/','All You Need To Know About Unit Testing with Spring Boot',CURRENT_TIMESTAMP),
+((select id from demo), 'https://blog.jooq.org/2014/06/25/flyway-and-jooq-for-unbeatable-sql-development-productivity/','Flyway and jOOQ for Unbeatable SQL Development Productivity',CURRENT_TIMESTAMP),
+((select id from demo), 'https://www.marcobehler.com/guides/java-microservices-a-practical-guide','Java Microservices: A Practical Guide',CURRENT_TIMESTAMP),
+((select id from demo), 'https://sivalabs.in/2020/02/spring-boot-integration-testing-using-testcontainers-starter/','SpringBoot Integration Testing using TestContainers Starter',CURRENT_TIMESTAMP),
+((select id from demo), 'https://medium.com/faun/continuous-integration-of-java-project-with-github-actions-7a8a0e8246ef','Continuous Integration of Java project with GitHub Actions',CURRENT_TIMESTAMP);
+
+insert into tags(name) values ('docker'), ('java'), ('spring-boot'), ('testing'), ('ci');
+
+insert into bookmark_tags(bookmark_id, tag_id)
+select b.id, t.id
+from bookmarks b
+         join tags t on
+    (t.name = 'docker' and b.url like '%docker%') or
+    (t.name = 'java' and b.url not like '%docker%') or
+    (t.name = 'spring-boot' and b.url like '%spring-boot%') or
+    (t.name = 'testing' and b.url like '%testing%') or
+    (t.name = 'ci' and b.url like '%continuous-integration%');
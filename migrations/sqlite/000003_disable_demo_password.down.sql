@@ -0,0 +1 @@
+-- The published password is not restored, see 000003_disable_demo_password.up.sql.
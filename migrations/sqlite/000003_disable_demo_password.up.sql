@@ -0,0 +1,6 @@
+-- Earlier versions created demo@example.com with the published password demo1234 and made it the owner
+-- of the bookmarks that predate accounts. The account keeps those bookmarks but can no longer log in
+-- until a new password is set using `bookmarks set-password demo@example.com`.
+update users set password_hash = '!'
+where email = 'demo@example.com'
+  and password_hash = '$2a$10$0guy84wpgm6p2BN/m9KY9.huyy6gcKkY5l6mMkqMLYXwihD5/Xbjq';
@@ -0,0 +1,340 @@
+package testsupport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sivaprasadreddy/bookmarks-go/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// RunBookmarkRepositoryContract checks the behavior every domain.BookmarkRepository
+// implementation must share. newOwner returns the id of a new user without bookmarks,
+// so that the checks do not depend on each other.
+func RunBookmarkRepositoryContract(t *testing.T, repo domain.BookmarkRepository, newOwner func() int) {
+	ctx := context.Background()
+	create := func(t *testing.T, ctx context.Context, ownerID int, title, url string, tags ...string) domain.Bookmark {
+		b, err := repo.Create(ctx, domain.Bookmark{OwnerID: ownerID, Title: title, URL: url, Tags: tags,
+			CreatedDate: time.Now()})
+		require.Nil(t, err)
+		return b
+	}
+
+	t.Run("create and find", func(t *testing.T) {
+		ownerID := newOwner()
+		created := create(t, ctx, ownerID, "Go", "https://go.dev", "lang", "go")
+		assert.NotZero(t, created.ID)
+		assert.Equal(t, 1, created.Version)
+
+		found, err := repo.FindByID(ctx, ownerID, created.ID)
+		require.Nil(t, err)
+		assert.Equal(t, "Go", found.Title)
+		assert.Equal(t, "https://go.dev", found.URL)
+		assert.Equal(t, []string{"go", "lang"}, found.Tags)
+		assert.Nil(t, found.DeletedDate)
+
+		_, err = repo.FindByID(ctx, newOwner(), created.ID)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+		_, err = repo.FindByID(ctx, ownerID, created.ID+1000)
+		assert.ErrorIs(t, err, domain.ErrBookmarkNotFound)
+	})
+
+	t.Run("rejects invalid bookmarks", func(t *testing.T) {
+		ownerID := newOwner()
+		for _, b := range []domain.Bookmark{
+			{OwnerID: ownerID, Title: " ", URL: "https://go.dev"},
+			{OwnerID: ownerID, Title: "Go", URL: "ftp://go.dev"},
+			{OwnerID: ownerID, Title: "Go", URL: "go.dev"},
+			{OwnerID: ownerID, Title: "Go", URL: "https://go.dev", Tags: []string{strings.Repeat("t", 51)}},
+		} {
+			_, err := repo.Create(ctx, b)
+			assert.ErrorIs(t, err, domain.ErrValidation, b.URL)
+		}
+		bookmarks, err := repo.FindAll(ctx, ownerID)
+		require.Nil(t, err)
+		assert.Empty(t, bookmarks)
+	})
+
+	t.Run("rejects duplicate urls", func(t *testing.T) {
+		ownerID := newOwner()
+		existing := create(t, ctx, ownerID, "Go blog", "https://go.dev/blog/")
+
+		_, err := repo.Create(ctx, domain.Bookmark{OwnerID: ownerID, Title: "Again", URL: "http://Go.dev/blog"})
+		var dup *domain.DuplicateURLError
+		require.True(t, errors.As(err, &dup))
+		assert.Equal(t, existing.ID, dup.ExistingID)
+		assert.ErrorIs(t, err, domain.ErrConflict)
+
+		other := create(t, ctx, ownerID, "Go", "https://go.dev")
+		other.URL = "https://go.dev/blog"
+		_, err = repo.Update(ctx, other)
+		assert.ErrorIs(t, err, domain.ErrDuplicateURL)
+
+		create(t, ctx, newOwner(), "Go blog", "https://go.dev/blog")
+	})
+
+	t.Run("finds existing urls", func(t *testing.T) {
+		ownerID := newOwner()
+		create(t, ctx, ownerID, "Go", "https://go.dev")
+
+		existing, err := repo.ExistingURLs(ctx, ownerID, []string{"https://go.dev", "https://pkg.go.dev"})
+		require.Nil(t, err)
+		assert.Equal(t, []string{"https://go.dev"}, existing)
+	})
+
+	t.Run("updates with optimistic concurrency", func(t *testing.T) {
+		ownerID := newOwner()
+		b := create(t, ctx, ownerID, "Go", "https://go.dev", "go")
+
+		b.Title, b.Tags = "The Go Programming Language", nil
+		_, err := repo.Update(ctx, b)
+		require.Nil(t, err)
+		found, err := repo.FindByID(ctx, ownerID, b.ID)
+		require.Nil(t, err)
+		assert.Equal(t, "The Go Programming Language", found.Title)
+		assert.Equal(t, []string{"go"}, found.Tags)
+		assert.Equal(t, 2, found.Version)
+
+		// b still has the first version
+		_, err = repo.Update(ctx, b)
+		assert.ErrorIs(t, err, domain.ErrStaleBookmark)
+		assert.ErrorIs(t, err, domain.ErrPreconditionFailed)
+
+		found.Tags = []string{}
+		_, err = repo.Update(ctx, found)
+		require.Nil(t, err)
+		found, err = repo.FindByID(ctx, ownerID, b.ID)
+		require.Nil(t, err)
+		assert.Empty(t, found.Tags)
+		assert.Equal(t, 3, found.Version)
+
+		found.ID += 1000
+		_, err = repo.Update(ctx, found)
+		assert.ErrorIs(t, err, domain.ErrBookmarkNotFound)
+	})
+
+	t.Run("updates metadata and content", func(t *testing.T) {
+		ownerID := newOwner()
+		b := create(t, ctx, ownerID, "https://go.dev", "https://go.dev")
+
+		b.Title, b.Description = "The Go Programming Language", "Build simple, secure, scalable systems"
+		require.Nil(t, repo.UpdateMetadata(ctx, b))
+		require.Nil(t, repo.UpdateContent(ctx, ownerID, b.ID, "Go is expressive, concise and efficient"))
+
+		found, err := repo.FindByID(ctx, ownerID, b.ID)
+		require.Nil(t, err)
+		assert.Equal(t, "The Go Programming Language", found.Title)
+		assert.Equal(t, "Build simple, secure, scalable systems", found.Description)
+		assert.Equal(t, 2, found.Version)
+
+		// b still has the first version
+		b.Title = "Go"
+		assert.ErrorIs(t, repo.UpdateMetadata(ctx, b), domain.ErrStaleBookmark)
+		found, err = repo.FindByID(ctx, ownerID, b.ID)
+		require.Nil(t, err)
+		assert.Equal(t, "The Go Programming Language", found.Title)
+		page, err := repo.FindPage(ctx, domain.BookmarkQuery{OwnerID: ownerID, Query: "concise", Size: 10})
+		require.Nil(t, err)
+		require.Len(t, page.Bookmarks, 1)
+		assert.Contains(t, page.Bookmarks[0].Snippet, "<mark>concise</mark>")
+	})
+
+	t.Run("moves deleted bookmarks to the trash", func(t *testing.T) {
+		ownerID := newOwner()
+		b := create(t, ctx, ownerID, "Go", "https://go.dev", "go")
+		kept := create(t, ctx, ownerID, "Go blog", "https://go.dev/blog")
+
+		require.Nil(t, repo.Delete(ctx, ownerID, b.ID))
+		assert.ErrorIs(t, repo.Delete(ctx, ownerID, b.ID), domain.ErrBookmarkNotFound)
+		_, err := repo.FindByID(ctx, ownerID, b.ID)
+		assert.ErrorIs(t, err, domain.ErrBookmarkNotFound)
+		bookmarks, err := repo.FindAll(ctx, ownerID)
+		require.Nil(t, err)
+		assert.Equal(t, []int{kept.ID}, bookmarkIDs(bookmarks))
+		trash, err := repo.FindTrash(ctx, ownerID)
+		require.Nil(t, err)
+		require.Equal(t, []int{b.ID}, bookmarkIDs(trash))
+		assert.NotNil(t, trash[0].DeletedDate)
+
+		again := create(t, ctx, ownerID, "Go again", "https://go.dev/")
+		_, err = repo.Restore(ctx, ownerID, b.ID)
+		assert.ErrorIs(t, err, domain.ErrDuplicateURL)
+		require.Nil(t, repo.Delete(ctx, ownerID, again.ID))
+
+		restored, err := repo.Restore(ctx, ownerID, b.ID)
+		require.Nil(t, err)
+		assert.Nil(t, restored.DeletedDate)
+		assert.Equal(t, []string{"go"}, restored.Tags)
+		_, err = repo.Restore(ctx, ownerID, b.ID)
+		assert.ErrorIs(t, err, domain.ErrBookmarkNotFound)
+	})
+
+	t.Run("purges the trash", func(t *testing.T) {
+		ownerID := newOwner()
+		b := create(t, ctx, ownerID, "Go", "https://go.dev")
+		require.Nil(t, repo.Delete(ctx, ownerID, b.ID))
+
+		n, err := repo.PurgeDeleted(ctx, time.Now().Add(-time.Hour), 100)
+		require.Nil(t, err)
+		assert.Zero(t, n)
+		for {
+			n, err = repo.PurgeDeleted(ctx, time.Now().Add(time.Minute), 100)
+			require.Nil(t, err)
+			if n == 0 {
+				break
+			}
+		}
+		trash, err := repo.FindTrash(ctx, ownerID)
+		require.Nil(t, err)
+		assert.Empty(t, trash)
+		_, err = repo.Restore(ctx, ownerID, b.ID)
+		assert.ErrorIs(t, err, domain.ErrBookmarkNotFound)
+	})
+
+	t.Run("pages through bookmarks", func(t *testing.T) {
+		ownerID := newOwner()
+		var ids []int
+		for i, tags := range [][]string{{"go"}, {"go", "web"}, {"web"}, {}, {"go"}} {
+			b := create(t, ctx, ownerID, fmt.Sprintf("Bookmark %d", i), fmt.Sprintf("https://example.com/%d", i), tags...)
+			ids = append(ids, b.ID)
+		}
+		sort, err := domain.ParseSort("title")
+		require.Nil(t, err)
+		query := domain.BookmarkQuery{OwnerID: ownerID, Page: 1, Size: 2, Sort: sort}
+
+		page, err := repo.FindPage(ctx, query)
+		require.Nil(t, err)
+		assert.Equal(t, int64(5), page.Total)
+		assert.True(t, page.HasMore)
+		assert.Equal(t, ids[:2], bookmarkIDs(page.Bookmarks))
+
+		query.Page = 3
+		page, err = repo.FindPage(ctx, query)
+		require.Nil(t, err)
+		assert.False(t, page.HasMore)
+		assert.Equal(t, ids[4:], bookmarkIDs(page.Bookmarks))
+
+		query.Page, query.After = 1, ids[1]
+		page, err = repo.FindPage(ctx, query)
+		require.Nil(t, err)
+		assert.Equal(t, ids[2:4], bookmarkIDs(page.Bookmarks))
+
+		query.After, query.Size, query.Sort = 0, 10, []domain.SortField{{Field: "id", Desc: true}}
+		query.Tags = []string{"go", "web"}
+		page, err = repo.FindPage(ctx, query)
+		require.Nil(t, err)
+		assert.Equal(t, []int{ids[1]}, bookmarkIDs(page.Bookmarks))
+
+		query.AnyTag = true
+		page, err = repo.FindPage(ctx, query)
+		require.Nil(t, err)
+		assert.Equal(t, []int{ids[4], ids[2], ids[1], ids[0]}, bookmarkIDs(page.Bookmarks))
+
+		query.Tags, query.LinkStatus = nil, domain.LinkBroken
+		page, err = repo.FindPage(ctx, query)
+		require.Nil(t, err)
+		assert.Empty(t, page.Bookmarks)
+		query.LinkStatus = domain.LinkUnchecked
+		page, err = repo.FindPage(ctx, query)
+		require.Nil(t, err)
+		assert.Len(t, page.Bookmarks, 5)
+	})
+
+	t.Run("searches bookmarks", func(t *testing.T) {
+		ownerID := newOwner()
+		title := create(t, ctx, ownerID, "Concurrency patterns", "https://go.dev/talks/concurrency")
+		content := create(t, ctx, ownerID, "Talks", "https://go.dev/talks")
+		require.Nil(t, repo.UpdateContent(ctx, ownerID, content.ID, "A talk about concurrency in Go"))
+		create(t, ctx, ownerID, "Generics", "https://go.dev/doc/tutorial/generics")
+		sort, err := domain.ParseSort("-relevance")
+		require.Nil(t, err)
+
+		page, err := repo.FindPage(ctx, domain.BookmarkQuery{OwnerID: ownerID, Query: "concurrency", Size: 10,
+			Sort: sort})
+		require.Nil(t, err)
+		assert.Equal(t, []int{title.ID, content.ID}, bookmarkIDs(page.Bookmarks))
+		assert.Greater(t, page.Bookmarks[0].Rank, page.Bookmarks[1].Rank)
+
+		page, err = repo.FindPage(ctx, domain.BookmarkQuery{OwnerID: ownerID, Query: "concurrency -patterns",
+			Size: 10, Sort: sort})
+		require.Nil(t, err)
+		assert.Equal(t, []int{content.ID}, bookmarkIDs(page.Bookmarks))
+	})
+
+	t.Run("streams bookmarks in id order", func(t *testing.T) {
+		ownerID := newOwner()
+		first := create(t, ctx, ownerID, "Go", "https://go.dev", "go")
+		second := create(t, ctx, ownerID, "Go blog", "https://go.dev/blog")
+
+		var ids []int
+		err := repo.ForEach(ctx, ownerID, func(b domain.Bookmark) error {
+			ids = append(ids, b.ID)
+			if b.ID == first.ID {
+				assert.Equal(t, []string{"go"}, b.Tags)
+			}
+			return nil
+		})
+		require.Nil(t, err)
+		assert.Equal(t, []int{first.ID, second.ID}, ids)
+
+		stop := errors.New("stop")
+		err = repo.ForEach(ctx, ownerID, func(domain.Bookmark) error { return stop })
+		assert.ErrorIs(t, err, stop)
+	})
+
+	t.Run("creates bookmarks in a batch", func(t *testing.T) {
+		ownerID := newOwner()
+		created, err := repo.CreateAll(ctx, []domain.Bookmark{
+			{OwnerID: ownerID, Title: "Go", URL: "https://go.dev", Tags: []string{"go"}, CreatedDate: time.Now()},
+			{OwnerID: ownerID, Title: "Go blog", URL: "https://go.dev/blog", CreatedDate: time.Now()},
+		})
+		require.Nil(t, err)
+		require.Len(t, created, 2)
+		assert.NotZero(t, created[0].ID)
+		assert.Equal(t, []string{}, created[1].Tags)
+
+		bookmarks, err := repo.FindAll(ctx, ownerID)
+		require.Nil(t, err)
+		assert.Len(t, bookmarks, 2)
+	})
+
+	t.Run("rolls back failed transactions", func(t *testing.T) {
+		ownerID := newOwner()
+		failure := errors.New("failure")
+		err := repo.InTx(ctx, func(ctx context.Context) error {
+			create(t, ctx, ownerID, "Go", "https://go.dev")
+			return failure
+		})
+		assert.ErrorIs(t, err, failure)
+		err = repo.InTx(ctx, func(ctx context.Context) error {
+			create(t, ctx, ownerID, "Go blog", "https://go.dev/blog")
+			// a failing nested transaction only rolls back its own changes
+			err := repo.InTx(ctx, func(ctx context.Context) error {
+				create(t, ctx, ownerID, "Go again", "https://go.dev/blog/again")
+				return failure
+			})
+			assert.ErrorIs(t, err, failure)
+			return nil
+		})
+		require.Nil(t, err)
+
+		bookmarks, err := repo.FindAll(ctx, ownerID)
+		require.Nil(t, err)
+		require.Len(t, bookmarks, 1)
+		assert.Equal(t, "Go blog", bookmarks[0].Title)
+	})
+}
+
+func bookmarkIDs(bookmarks []domain.Bookmark) []int {
+	ids := []int{}
+	for _, b := range bookmarks {
+		ids = append(ids, b.ID)
+	}
+	return ids
+}
@@ -22,7 +22,7 @@ func InitPostgresContainer() *PostgresContainer {
 	ctx := context.Background()
 	pgContainer, err := SetupPostgres(ctx)
 	if err != nil {
-		log.Fatalf("failed to setup Postgres container")
+		log.Fatalf("failed to setup Postgres container: %v", err)
 		return nil
 	}
 	overrideEnv(pgContainer)